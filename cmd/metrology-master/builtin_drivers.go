@@ -0,0 +1,17 @@
+package main
+
+// Meter drivers register their types on import. Drivers kept out of this tree are added
+// by another file of this package importing them, behind a build tag if they are optional.
+import (
+	_ "github.com/lan143/metrology-master/internal/meter/dlms"
+	_ "github.com/lan143/metrology-master/internal/meter/iec62056"
+	_ "github.com/lan143/metrology-master/internal/meter/mbus"
+	_ "github.com/lan143/metrology-master/internal/meter/mercury-206"
+	_ "github.com/lan143/metrology-master/internal/meter/mercury-230"
+	_ "github.com/lan143/metrology-master/internal/meter/modbus"
+	_ "github.com/lan143/metrology-master/internal/meter/pulsar-electro"
+	_ "github.com/lan143/metrology-master/internal/meter/pulsar-heat"
+	_ "github.com/lan143/metrology-master/internal/meter/pulsar-pulse"
+	_ "github.com/lan143/metrology-master/internal/meter/pulsar-water"
+	_ "github.com/lan143/metrology-master/internal/meter/wmbus"
+)
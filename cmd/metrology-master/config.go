@@ -2,8 +2,11 @@ package main
 
 import (
 	"flag"
+	"github.com/lan143/metrology-master/internal/bus"
 	"github.com/lan143/metrology-master/internal/ha"
 	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	"github.com/lan143/metrology-master/internal/meter/modbus"
 	"github.com/lan143/metrology-master/pkg/flag/flagutil"
 	"github.com/lan143/metrology-master/pkg/mqtt"
 	"github.com/lan143/metrology-master/pkg/serial"
@@ -14,10 +17,13 @@ type Config struct {
 	HA     *ha.Config
 	Serial map[string]*serial.Config
 	Meters map[string]*meter.Config
+	// ModbusModels are register maps referenced by modbus meters.
+	ModbusModels map[string]*meter.ModelConfig
 }
 
 func (c *Config) Export(flags *flag.FlagSet) {
 	c.Meters = make(map[string]*meter.Config)
+	c.ModbusModels = make(map[string]*meter.ModelConfig)
 
 	flagutil.Subset(flags, "mqtt", func(sub *flag.FlagSet) {
 		c.MQTT = mqtt.Export(sub)
@@ -30,7 +36,11 @@ func (c *Config) Export(flags *flag.FlagSet) {
 
 		flagutil.Func(sub, "include", "", func(name string) error {
 			flagutil.Subset(sub, name, func(sub *flag.FlagSet) {
-				c.Serial[name] = serial.Export(sub)
+				c.Serial[name] = serial.Export(sub, serial.Defaults{
+					Timeout: bus.DefaultTimeout,
+					Retries: bus.DefaultRetries,
+					Backoff: bus.DefaultBackoff,
+				})
 			})
 
 			return nil
@@ -41,7 +51,16 @@ func (c *Config) Export(flags *flag.FlagSet) {
 
 		flagutil.Func(sub, "include", "", func(name string) error {
 			flagutil.Subset(sub, name, func(sub *flag.FlagSet) {
-				c.Meters[name] = meter.Export(sub)
+				c.Meters[name] = meter.Export(sub, driver.ExportOptions)
+			})
+
+			return nil
+		})
+	})
+	flagutil.Subset(flags, modbus.ModelsSection, func(sub *flag.FlagSet) {
+		flagutil.Func(sub, "include", "", func(name string) error {
+			flagutil.Subset(sub, name, func(sub *flag.FlagSet) {
+				c.ModbusModels[name] = meter.ExportModel(sub)
 			})
 
 			return nil
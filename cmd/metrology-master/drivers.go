@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	"github.com/lan143/metrology-master/pkg/cmd"
+	"os"
+	"strings"
+)
+
+// DriversCommand prints the supported meter types with the options they declare.
+type DriversCommand struct{}
+
+func (c *DriversCommand) Run(_ cmd.Context) error {
+	var b strings.Builder
+
+	for _, d := range driver.Drivers() {
+		fmt.Fprintf(&b, "%s\t%s\ttransport: %s\n", d.Type, d.Description, d.Transport)
+
+		if d.Options == nil {
+			continue
+		}
+
+		flags := flag.NewFlagSet(d.Type, flag.ContinueOnError)
+		d.Options(flags)
+
+		flags.VisitAll(func(f *flag.Flag) {
+			usage := f.Usage
+			if f.DefValue != "" && f.DefValue != "0" {
+				usage += fmt.Sprintf(", %s by default", f.DefValue)
+			}
+
+			// options of subsets are set by the keys they include
+			fmt.Fprintf(&b, "\t%s\t%s\n", strings.TrimSuffix(f.Name, ".include"), usage)
+		})
+	}
+
+	b.WriteString("options of every type: type, port or host by the transport, timeout, retries, backoff, export\n")
+	b.WriteString("options of meters syncing their clock: time-sync-threshold, the time is not synced when 0, and time-sync-interval\n")
+
+	_, _ = os.Stdout.WriteString(b.String())
+
+	return nil
+}
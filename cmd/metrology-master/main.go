@@ -3,9 +3,18 @@ package main
 import (
 	"flag"
 	"github.com/lan143/metrology-master/internal/ha"
+	"github.com/lan143/metrology-master/internal/job"
 	"github.com/lan143/metrology-master/internal/scheduler"
 	"github.com/lan143/metrology-master/pkg/cmd"
 	"go.uber.org/zap"
+	"os"
+	"time"
+)
+
+const (
+	busStatsPeriod = 5 * time.Minute
+	// cliLogOutput keeps logs of commands printing their results apart from the results.
+	cliLogOutput = "stderr"
 )
 
 type Command struct {
@@ -39,6 +48,12 @@ func (c *Command) Init(log *zap.Logger) error {
 	}
 
 	c.scheduler = scheduler.NewScheduler(c.log)
+	for name, port := range c.serial.buses {
+		c.scheduler.AddPeriodicJob(
+			job.NewPublishBusStatsJob(name, port, c.mqtt.client, c.log),
+			busStatsPeriod,
+		)
+	}
 
 	c.discoveryMgr = ha.NewDiscoveryMgr(c.mqtt.client, c.log)
 	err = c.discoveryMgr.Init(*c.config.HA)
@@ -75,5 +90,45 @@ func (c *Command) Run(ctx cmd.Context) error {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "scan" {
+		cmd.Main(
+			&ScanCommand{},
+			cmd.WithArgs(append([]string{os.Args[0]}, os.Args[2:]...)),
+			cmd.WithLogOutput(cliLogOutput),
+		)
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "mbus-scan" {
+		cmd.Main(
+			&MBusScanCommand{},
+			cmd.WithArgs(append([]string{os.Args[0]}, os.Args[2:]...)),
+			cmd.WithLogOutput(cliLogOutput),
+		)
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "profile" {
+		cmd.Main(
+			&ProfileCommand{},
+			cmd.WithArgs(append([]string{os.Args[0]}, os.Args[2:]...)),
+			cmd.WithLogOutput(cliLogOutput),
+		)
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "drivers" {
+		cmd.Main(
+			&DriversCommand{},
+			cmd.WithArgs(append([]string{os.Args[0]}, os.Args[2:]...)),
+			cmd.WithLogOutput(cliLogOutput),
+		)
+
+		return
+	}
+
 	cmd.Main(&Command{})
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	mbus_meter "github.com/lan143/metrology-master/internal/meter/mbus"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"github.com/lan143/metrology-master/pkg/cmd"
+	"github.com/lan143/metrology-master/pkg/serial"
+	"go.uber.org/zap"
+	"os"
+	"strings"
+)
+
+// MBusScanCommand searches M-Bus devices on a port by the secondary address.
+type MBusScanCommand struct {
+	Command
+
+	port string
+	yaml bool
+}
+
+func (c *MBusScanCommand) Setup(flags *flag.FlagSet) {
+	c.Command.Setup(flags)
+
+	flags.StringVar(
+		&c.port,
+		"port",
+		"",
+		"",
+	)
+	flags.BoolVar(
+		&c.yaml,
+		"yaml",
+		false,
+		"",
+	)
+}
+
+func (c *MBusScanCommand) Init(log *zap.Logger) error {
+	zap.ReplaceGlobals(log)
+	c.log = log
+
+	config, ok := c.config.Serial[c.port]
+	if !ok {
+		return fmt.Errorf("port \"%s\" not found in ports list", c.port)
+	}
+
+	return c.InitSerial(map[string]*serial.Config{c.port: config})
+}
+
+func (c *MBusScanCommand) Run(ctx cmd.Context) error {
+	port := c.serial.buses[c.port]
+
+	// a collision must not be retried, it is resolved by narrowing the selection
+	protocol := mbus_m.NewMBus(
+		port,
+		bus.Policy{Timeout: port.Policy().Timeout},
+		c.log,
+	)
+
+	var results []mbus_m.Data
+	err := protocol.Scan(ctx, func(data mbus_m.Data) {
+		results = append(results, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.yaml {
+		c.printYAML(results)
+	} else {
+		c.printTable(results)
+	}
+
+	return nil
+}
+
+func (c *MBusScanCommand) printTable(results []mbus_m.Data) {
+	if len(results) == 0 {
+		fmt.Println("no devices found")
+		return
+	}
+
+	for _, result := range results {
+		kind, ok := mbus_meter.Kind(result.Address.Medium)
+		if !ok {
+			kind = fmt.Sprintf("medium 0x%02X", result.Address.Medium)
+		}
+
+		fmt.Printf(
+			"%s\t%s\t%s\tversion: %d\trecords: %d\n",
+			result.Address,
+			result.Address.ManufacturerCode(),
+			kind,
+			result.Address.Version,
+			len(result.Records),
+		)
+	}
+}
+
+func (c *MBusScanCommand) printYAML(results []mbus_m.Data) {
+	var b strings.Builder
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, "meter_"+result.Address.ID)
+	}
+
+	b.WriteString("meters:\n")
+	b.WriteString("  - include:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "      - %s\n", name)
+	}
+
+	for i, result := range results {
+		fmt.Fprintf(&b, "  # %s, medium: 0x%02X, version: %d\n", result.Address.ManufacturerCode(), result.Address.Medium, result.Address.Version)
+		fmt.Fprintf(&b, "  - %s:\n", names[i])
+		fmt.Fprintf(&b, "      type: %s\n", mbus_meter.Type)
+		fmt.Fprintf(&b, "      uid: \"%s\"\n", result.Address)
+		fmt.Fprintf(&b, "      port: %s\n", c.port)
+		b.WriteString("      export:\n")
+		b.WriteString("        - mqtt\n")
+	}
+
+	_, _ = os.Stdout.WriteString(b.String())
+}
@@ -3,56 +3,151 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
 	"github.com/lan143/metrology-master/internal/job"
 	"github.com/lan143/metrology-master/internal/meter"
-	pulsar_t1 "github.com/lan143/metrology-master/internal/meter/pulsar-electro"
-	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	"github.com/lan143/metrology-master/internal/meter/modbus"
+	"github.com/lan143/metrology-master/internal/transport"
+	"go.uber.org/zap"
+	"net"
 )
 
 type Meters struct {
-	electricMeters map[string]meter.ElectricMeter
+	// meters are all meters by name, whatever their kind.
+	meters map[string]meter.Meter
+
+	// hosts are connections to TCP devices shared by meters with the same host.
+	hosts map[string]*bus.Bus
 }
 
+// InitMeters builds the meters by the drivers of their types, see builtin_drivers.go.
 func (c *Command) InitMeters(configs map[string]*meter.Config) error {
-	c.meters.electricMeters = make(map[string]meter.ElectricMeter)
+	c.meters.meters = make(map[string]meter.Meter)
+	c.meters.hosts = make(map[string]*bus.Bus)
 
 	for name, config := range configs {
-		switch config.Type {
-		case pulsar_t1.Type:
-			port, ok := c.serial.ports[config.Port]
-			if !ok {
-				return fmt.Errorf("port \"%s\" not found in ports list", config.Port)
-			}
-
-			protocol := pulsar_m.NewPulsar(port, c.log)
-
-			address, err := pulsar_m.ParseAddress(config.UID)
-			if err != nil {
-				return fmt.Errorf("parse address \"%s\": %s", config.UID, err.Error())
-			}
-			m := pulsar_t1.NewPulsarElectro(
-				pulsar_t1.Config{Address: address},
-				protocol,
-				c.log,
-			)
-			err = m.Init(context.Background())
-			if err != nil {
-				return err
-			}
-
-			c.meters.electricMeters[name] = m
-			c.scheduler.AddJob(
-				job.NewUpdateMeterJob(
-					m,
-					c.mqtt.client,
-					c.log,
-				),
-			)
-			c.discoveryMgr.AddMeter(m)
-		default:
-			return fmt.Errorf("unsupported meter type \"%s\"", name)
+		d, ok := driver.Lookup(config.Type)
+		if !ok {
+			return fmt.Errorf("unsupported meter type \"%s\", types are listed by the drivers command", config.Type)
+		}
+
+		err := d.Validate(config)
+		if err != nil {
+			return fmt.Errorf("meter \"%s\": %s", name, err.Error())
+		}
+
+		m, err := d.New(c, name, config)
+		if err != nil {
+			return err
+		}
+
+		err = c.addMeter(name, config, m)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// addMeter initializes the meter, schedules the update of its state and the time sync
+// if the meter supports it, and announces it to Home Assistant.
+func (c *Command) addMeter(name string, config *meter.Config, m meter.Meter) error {
+	syncer, ok := m.(meter.TimeSyncer)
+	if ok && config.TimeSyncThreshold > 0 {
+		err := config.ValidateTimeSync()
+		if err != nil {
+			return fmt.Errorf("meter \"%s\": %s", name, err.Error())
+		}
+	}
+
+	err := m.Init(context.Background())
+	if err != nil {
+		return err
+	}
+
+	c.meters.meters[name] = m
+	c.scheduler.AddJob(
+		job.NewUpdateMeterJob(
+			m,
+			c.mqtt.client,
+			c.discoveryMgr.Announce,
+			c.log,
+		),
+	)
+	if ok && config.TimeSyncThreshold > 0 {
+		c.scheduler.AddPeriodicJob(
+			job.NewSyncTimeJob(syncer, c.log),
+			config.TimeSyncInterval,
+		)
+	}
+	c.discoveryMgr.AddMeter(m)
+
+	return nil
+}
+
+// Port implements driver.Env.
+func (c *Command) Port(config *meter.Config) (*bus.Bus, bus.Policy, error) {
+	port, ok := c.serial.buses[config.Port]
+	if !ok {
+		return nil, bus.Policy{}, fmt.Errorf("port \"%s\" not found in ports list", config.Port)
+	}
+
+	return port, buildPolicy(port.Policy(), config), nil
+}
+
+// Host implements driver.Env, the bus stats of a host are published like the ones of a port.
+func (c *Command) Host(config *meter.Config, defaultPort string) (*bus.Bus, bus.Policy, error) {
+	address := config.Host
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+
+	host, ok := c.meters.hosts[address]
+	if !ok {
+		log := c.log.With(zap.String("host", address))
+		host = bus.NewBus(transport.NewTCP(address, log), 0, bus.DefaultPolicy(), log)
+
+		c.meters.hosts[address] = host
+		c.scheduler.AddPeriodicJob(
+			job.NewPublishBusStatsJob(address, host, c.mqtt.client, c.log),
+			busStatsPeriod,
+		)
+	}
+
+	return host, buildPolicy(host.Policy(), config), nil
+}
+
+// Resource implements driver.Env.
+func (c *Command) Resource(section string, name string) (interface{}, bool) {
+	switch section {
+	case modbus.ModelsSection:
+		model, ok := c.config.ModbusModels[name]
+
+		return model, ok
+	default:
+		return nil, false
+	}
+}
+
+// Log implements driver.Env.
+func (c *Command) Log() *zap.Logger {
+	return c.log
+}
+
+func buildPolicy(policy bus.Policy, config *meter.Config) bus.Policy {
+	if config.Timeout > 0 {
+		policy.Timeout = config.Timeout
+	}
+
+	if config.Retries >= 0 {
+		policy.Retries = config.Retries
+	}
+
+	if config.Backoff > 0 {
+		policy.Backoff = config.Backoff
+	}
+
+	return policy
+}
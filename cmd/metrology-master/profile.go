@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	dlms_meter "github.com/lan143/metrology-master/internal/meter/dlms"
+	dlms_m "github.com/lan143/metrology-master/internal/protocol/dlms"
+	"github.com/lan143/metrology-master/pkg/cmd"
+	"github.com/lan143/metrology-master/pkg/serial"
+	"go.uber.org/zap"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultProfilePeriod = 24 * time.Hour
+)
+
+// ProfileCommand prints the load profile of a DLMS/COSEM meter from the config.
+type ProfileCommand struct {
+	Command
+
+	meter  string
+	obis   string
+	period time.Duration
+}
+
+func (c *ProfileCommand) Setup(flags *flag.FlagSet) {
+	c.Command.Setup(flags)
+
+	flags.StringVar(
+		&c.meter,
+		"meter",
+		"",
+		"",
+	)
+	flags.StringVar(
+		&c.obis,
+		"obis",
+		dlms_m.LoadProfileOBIS.String(),
+		"",
+	)
+	flags.DurationVar(
+		&c.period,
+		"period",
+		DefaultProfilePeriod,
+		"",
+	)
+}
+
+func (c *ProfileCommand) Init(log *zap.Logger) error {
+	zap.ReplaceGlobals(log)
+	c.log = log
+
+	config, err := c.meterConfig()
+	if err != nil {
+		return err
+	}
+
+	port, ok := c.config.Serial[config.Port]
+	if !ok {
+		return fmt.Errorf("port \"%s\" not found in ports list", config.Port)
+	}
+
+	return c.InitSerial(map[string]*serial.Config{config.Port: port})
+}
+
+func (c *ProfileCommand) Run(ctx cmd.Context) error {
+	config, err := c.meterConfig()
+	if err != nil {
+		return err
+	}
+
+	obis, err := dlms_m.ParseOBIS(c.obis)
+	if err != nil {
+		return err
+	}
+
+	protocol, settings, err := dlms_meter.NewProtocol(c, config, config.Options.(*dlms_meter.Options))
+	if err != nil {
+		return err
+	}
+
+	to := time.Now()
+	from := to.Add(-c.period)
+
+	var profile dlms_m.Profile
+	err = protocol.Session(ctx, settings, func(ctx context.Context, session *dlms_m.Session) error {
+		profile, err = session.ReadProfile(ctx, obis, from, to)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c.printProfile(profile)
+
+	return nil
+}
+
+func (c *ProfileCommand) meterConfig() (*meter.Config, error) {
+	config, ok := c.config.Meters[c.meter]
+	if !ok {
+		return nil, fmt.Errorf("meter \"%s\" not found in meters list", c.meter)
+	}
+
+	if config.Type != dlms_meter.Type {
+		return nil, fmt.Errorf("meter \"%s\" is not a %s meter", c.meter, dlms_meter.Type)
+	}
+
+	err := config.Options.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", c.meter, err.Error())
+	}
+
+	return config, nil
+}
+
+func (c *ProfileCommand) printProfile(profile dlms_m.Profile) {
+	var b strings.Builder
+
+	header := make([]string, 0, len(profile.Columns))
+	for _, column := range profile.Columns {
+		name := column.OBIS.String()
+		if column.ScalerUnit != nil && column.ScalerUnit.Unit != dlms_m.UnitNone {
+			name += ", " + column.ScalerUnit.Unit.String()
+		}
+
+		header = append(header, name)
+	}
+
+	b.WriteString(strings.Join(header, "\t"))
+	b.WriteString("\n")
+
+	for _, row := range profile.Rows {
+		fields := make([]string, 0, len(row))
+		for _, value := range row {
+			fields = append(fields, formatProfileValue(value))
+		}
+
+		b.WriteString(strings.Join(fields, "\t"))
+		b.WriteString("\n")
+	}
+
+	_, _ = os.Stdout.WriteString(b.String())
+}
+
+func formatProfileValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []byte:
+		return fmt.Sprintf("%X", v)
+	case nil:
+		return ""
+	}
+
+	if f, ok := dlms_m.Float(value); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	pulsar_t1 "github.com/lan143/metrology-master/internal/meter/pulsar-electro"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"github.com/lan143/metrology-master/pkg/cmd"
+	"github.com/lan143/metrology-master/pkg/serial"
+	"go.uber.org/zap"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultScanTimeout = 300 * time.Millisecond
+)
+
+type ScanCommand struct {
+	Command
+
+	port    string
+	from    string
+	to      string
+	timeout time.Duration
+	yaml    bool
+}
+
+type scanResult struct {
+	address [4]byte
+	model   string
+	version pulsar_m.Version
+}
+
+func (c *ScanCommand) Setup(flags *flag.FlagSet) {
+	c.Command.Setup(flags)
+
+	flags.StringVar(
+		&c.port,
+		"port",
+		"",
+		"",
+	)
+	flags.StringVar(
+		&c.from,
+		"from",
+		"",
+		"",
+	)
+	flags.StringVar(
+		&c.to,
+		"to",
+		"",
+		"",
+	)
+	flags.DurationVar(
+		&c.timeout,
+		"timeout",
+		DefaultScanTimeout,
+		"",
+	)
+	flags.BoolVar(
+		&c.yaml,
+		"yaml",
+		false,
+		"",
+	)
+}
+
+func (c *ScanCommand) Init(log *zap.Logger) error {
+	zap.ReplaceGlobals(log)
+	c.log = log
+
+	config, ok := c.config.Serial[c.port]
+	if !ok {
+		return fmt.Errorf("port \"%s\" not found in ports list", c.port)
+	}
+
+	return c.InitSerial(map[string]*serial.Config{c.port: config})
+}
+
+func (c *ScanCommand) Run(ctx cmd.Context) error {
+	protocol := pulsar_m.NewPulsar(
+		c.serial.buses[c.port],
+		bus.Policy{Timeout: c.timeout},
+		c.log,
+	)
+
+	addresses, err := c.scanAddresses(ctx, protocol)
+	if err != nil {
+		return err
+	}
+
+	results := make([]scanResult, 0, len(addresses))
+	for _, address := range addresses {
+		result, err := c.identify(ctx, protocol, address)
+		if err != nil {
+			c.log.Error(
+				"identify device",
+				zap.String("address", formatAddress(address)),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	if c.yaml {
+		c.printYAML(results)
+	} else {
+		c.printTable(results)
+	}
+
+	return nil
+}
+
+func (c *ScanCommand) scanAddresses(ctx context.Context, protocol *pulsar_m.Pulsar) ([][4]byte, error) {
+	if c.from == "" {
+		address, err := protocol.ReadUID(ctx, pulsar_m.BroadcastAddress)
+		if err != nil {
+			if errors.Is(err, pulsar_m.ErrDeviceNotResponding) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		return [][4]byte{address}, nil
+	}
+
+	from, err := pulsar_m.ParseAddress(c.from)
+	if err != nil {
+		return nil, fmt.Errorf("parse address \"%s\": %s", c.from, err.Error())
+	}
+
+	to := from
+	if c.to != "" {
+		to, err = pulsar_m.ParseAddress(c.to)
+		if err != nil {
+			return nil, fmt.Errorf("parse address \"%s\": %s", c.to, err.Error())
+		}
+	}
+
+	var addresses [][4]byte
+	for i := uint64(addressToUint(from)); i <= uint64(addressToUint(to)); i++ {
+		// every device answers the broadcast address
+		if uintToAddress(uint32(i)) == pulsar_m.BroadcastAddress {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return addresses, nil
+		default:
+		}
+
+		address, err := protocol.ReadUID(ctx, uintToAddress(uint32(i)))
+		if err == nil {
+			addresses = append(addresses, address)
+		} else if !errors.Is(err, pulsar_m.ErrDeviceNotResponding) {
+			c.log.Error(
+				"probe address",
+				zap.String("address", formatAddress(uintToAddress(uint32(i)))),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return addresses, nil
+}
+
+func (c *ScanCommand) identify(ctx context.Context, protocol *pulsar_m.Pulsar, address [4]byte) (scanResult, error) {
+	version, err := protocol.GetVersion(ctx, address)
+	if err != nil {
+		return scanResult{}, err
+	}
+
+	model, err := pulsar_t1.ReadModelName(ctx, protocol, address, c.log)
+	if err != nil {
+		c.log.Error(
+			"read model name",
+			zap.String("address", formatAddress(address)),
+			zap.Error(err),
+		)
+		model = "unknown"
+	}
+
+	return scanResult{
+		address: address,
+		model:   model,
+		version: version,
+	}, nil
+}
+
+func (c *ScanCommand) printTable(results []scanResult) {
+	if len(results) == 0 {
+		fmt.Println("no devices found")
+		return
+	}
+
+	for _, result := range results {
+		fmt.Printf(
+			"%s\t%s\tsw: %s\thw: %s\n",
+			formatAddress(result.address),
+			result.model,
+			result.version.SWVersion,
+			result.version.HWVersion,
+		)
+	}
+}
+
+func (c *ScanCommand) printYAML(results []scanResult) {
+	var b strings.Builder
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, "meter_"+strings.TrimPrefix(formatAddress(result.address), "0x"))
+	}
+
+	b.WriteString("meters:\n")
+	b.WriteString("  - include:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "      - %s\n", name)
+	}
+
+	for i, result := range results {
+		fmt.Fprintf(&b, "  # %s, sw: %s, hw: %s\n", result.model, result.version.SWVersion, result.version.HWVersion)
+		fmt.Fprintf(&b, "  - %s:\n", names[i])
+		fmt.Fprintf(&b, "      type: %s\n", pulsar_t1.Type)
+		fmt.Fprintf(&b, "      uid: \"%s\"\n", formatAddress(result.address))
+		fmt.Fprintf(&b, "      port: %s\n", c.port)
+		b.WriteString("      export:\n")
+		b.WriteString("        - mqtt\n")
+	}
+
+	_, _ = os.Stdout.WriteString(b.String())
+}
+
+func formatAddress(address [4]byte) string {
+	return fmt.Sprintf("0x%02X%02X%02X%02X", address[0], address[1], address[2], address[3])
+}
+
+func addressToUint(address [4]byte) uint32 {
+	return uint32(address[0])<<24 | uint32(address[1])<<16 | uint32(address[2])<<8 | uint32(address[3])
+}
+
+func uintToAddress(v uint32) [4]byte {
+	return [4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
@@ -2,61 +2,104 @@ package main
 
 import (
 	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"github.com/lan143/metrology-master/internal/transport"
 	"github.com/lan143/metrology-master/pkg/serial"
 	serial2 "go.bug.st/serial"
+	"go.uber.org/zap"
 	"io"
+	"os"
+	"strings"
 )
 
+// stdinPort reads the port from the standard input, e.g. telegrams of rtl-wmbus.
+const stdinPort = "stdin"
+
 type Serial struct {
 	ports map[string]io.ReadWriteCloser
+	buses map[string]*bus.Bus
 }
 
 func (c *Command) InitSerial(configs map[string]*serial.Config) error {
 	c.serial.ports = make(map[string]io.ReadWriteCloser, len(configs))
+	c.serial.buses = make(map[string]*bus.Bus, len(configs))
 
 	for name, config := range configs {
-		var stopBits serial2.StopBits
-		switch config.StopBits {
-		case 1:
-			stopBits = serial2.OneStopBit
-		case 2:
-			stopBits = serial2.OnePointFiveStopBits
-		case 3:
-			stopBits = serial2.TwoStopBits
-		default:
-			return errors.New("unsupported stop bits setting")
-		}
-
-		var parity serial2.Parity
-		switch config.Parity {
-		case 0:
-			parity = serial2.NoParity
-		case 1:
-			parity = serial2.OddParity
-		case 2:
-			parity = serial2.EvenParity
-		case 3:
-			parity = serial2.MarkParity
-		case 4:
-			parity = serial2.SpaceParity
-		default:
-			return errors.New("unsupported parity setting")
-		}
-
-		mode := &serial2.Mode{
-			BaudRate: config.BaudRate,
-			DataBits: config.DataBits,
-			StopBits: stopBits,
-			Parity:   parity,
-		}
+		log := c.log.With(zap.String("port", name))
 
-		port, err := serial2.Open(config.Port, mode)
+		port, err := c.openPort(config, log)
 		if err != nil {
 			return err
 		}
 
 		c.serial.ports[name] = port
+		c.serial.buses[name] = bus.NewBus(
+			port,
+			config.FrameGap,
+			bus.Policy{
+				Timeout: config.Timeout,
+				Retries: config.Retries,
+				Backoff: config.Backoff,
+			},
+			log,
+		)
 	}
 
 	return nil
 }
+
+func (c *Command) openPort(config *serial.Config, log *zap.Logger) (io.ReadWriteCloser, error) {
+	if config.Port == stdinPort {
+		return os.Stdin, nil
+	}
+
+	if strings.HasPrefix(config.Port, transport.TCPScheme) {
+		return transport.NewTCP(strings.TrimPrefix(config.Port, transport.TCPScheme), log), nil
+	}
+
+	if strings.HasPrefix(config.Port, transport.RFC2217Scheme) {
+		return transport.NewRFC2217(strings.TrimPrefix(config.Port, transport.RFC2217Scheme), *config, log)
+	}
+
+	var stopBits serial2.StopBits
+	switch config.StopBits {
+	case 1:
+		stopBits = serial2.OneStopBit
+	case 2:
+		stopBits = serial2.OnePointFiveStopBits
+	case 3:
+		stopBits = serial2.TwoStopBits
+	default:
+		return nil, errors.New("unsupported stop bits setting")
+	}
+
+	var parity serial2.Parity
+	switch config.Parity {
+	case 0:
+		parity = serial2.NoParity
+	case 1:
+		parity = serial2.OddParity
+	case 2:
+		parity = serial2.EvenParity
+	case 3:
+		parity = serial2.MarkParity
+	case 4:
+		parity = serial2.SpaceParity
+	default:
+		return nil, errors.New("unsupported parity setting")
+	}
+
+	mode := serial2.Mode{
+		BaudRate: config.BaudRate,
+		DataBits: config.DataBits,
+		StopBits: stopBits,
+		Parity:   parity,
+	}
+
+	port, err := serial2.Open(config.Port, &mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.NewSerial(port, mode), nil
+}
@@ -0,0 +1,48 @@
+package bus
+
+import (
+	"errors"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrBaudRateNotSupported = errors.New("the port does not support baud rate switching")
+)
+
+// BaudRateSwitcher is implemented by ports which can change the baud rate on the fly.
+type BaudRateSwitcher interface {
+	SetBaudRate(baudRate int) error
+	// ResetBaudRate restores the configured baud rate.
+	ResetBaudRate() error
+}
+
+// SetBaudRate changes the baud rate of the port until the end of the transaction,
+// other devices on the bus keep using the configured one.
+func (t *Tx) SetBaudRate(baudRate int) error {
+	port, ok := t.bus.port.(BaudRateSwitcher)
+	if !ok {
+		return ErrBaudRateNotSupported
+	}
+
+	t.bus.log.Debug("set baud rate", zap.Int("baudRate", baudRate))
+
+	err := port.SetBaudRate(baudRate)
+	if err != nil {
+		return err
+	}
+
+	t.baudRateChanged = true
+
+	return nil
+}
+
+func (t *Tx) resetBaudRate() {
+	if !t.baudRateChanged {
+		return
+	}
+
+	err := t.bus.port.(BaudRateSwitcher).ResetBaudRate()
+	if err != nil {
+		t.bus.log.Error("reset baud rate", zap.Error(err))
+	}
+}
@@ -0,0 +1,165 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	rxQueueSize = 64
+	rxChunkSize = 255
+)
+
+var (
+	ErrClosed = errors.New("the bus is closed")
+)
+
+// Bus owns a half-duplex port shared by several devices. Transactions are executed
+// one at a time with an inter-frame gap between them, bytes received outside of
+// a transaction are discarded.
+type Bus struct {
+	port   io.ReadWriteCloser
+	gap    time.Duration
+	policy Policy
+
+	lock   chan struct{}
+	rx     chan []byte
+	closed chan struct{}
+	last   time.Time
+
+	transactions atomic.Uint64
+	retries      atomic.Uint64
+	failures     atomic.Uint64
+	droppedBytes atomic.Uint64
+	crcErrors    atomic.Uint64
+
+	log *zap.Logger
+}
+
+type Tx struct {
+	bus *Bus
+
+	baudRateChanged bool
+}
+
+func NewBus(port io.ReadWriteCloser, gap time.Duration, policy Policy, log *zap.Logger) *Bus {
+	b := &Bus{
+		port:   port,
+		gap:    gap,
+		policy: policy,
+		lock:   make(chan struct{}, 1),
+		rx:     make(chan []byte, rxQueueSize),
+		closed: make(chan struct{}),
+		log:    log,
+	}
+	go b.receive()
+
+	return b
+}
+
+// Policy returns the default request policy of the port.
+func (b *Bus) Policy() Policy {
+	return b.policy
+}
+
+func (b *Bus) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closed:
+		return ErrClosed
+	case b.lock <- struct{}{}:
+	}
+	defer func() {
+		b.last = time.Now()
+		<-b.lock
+	}()
+
+	if wait := b.gap - time.Since(b.last); wait > 0 {
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	b.drain()
+	b.transactions.Add(1)
+
+	tx := &Tx{bus: b}
+	defer tx.resetBaudRate()
+
+	return fn(tx)
+}
+
+func (b *Bus) drain() {
+	for {
+		select {
+		case data := <-b.rx:
+			b.log.Debug("drop unexpected bytes", zap.Binary("bytes", data))
+		default:
+			return
+		}
+	}
+}
+
+func (b *Bus) receive() {
+	defer close(b.closed)
+
+	buff := make([]byte, rxChunkSize)
+
+	for {
+		n, err := b.port.Read(buff)
+		if err != nil {
+			b.log.Error("read port", zap.Error(err))
+		}
+
+		if n == 0 {
+			b.log.Debug("read port - eof")
+			break
+		}
+
+		b.log.Debug("receive bytes", zap.Binary("bytes", buff[:n]))
+
+		data := make([]byte, n)
+		copy(data, buff[:n])
+
+		select {
+		case b.rx <- data:
+		default:
+			b.log.Error("receive queue overflow, drop bytes", zap.Binary("bytes", data))
+		}
+	}
+}
+
+func (t *Tx) Write(data []byte) error {
+	t.bus.log.Debug("send bytes", zap.Binary("bytes", data))
+
+	_, err := t.bus.port.Write(data)
+
+	return err
+}
+
+// Read returns the next chunk of received bytes.
+func (t *Tx) Read(ctx context.Context) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case data := <-t.bus.rx:
+		return data, nil
+	case <-t.bus.closed:
+		return nil, ErrClosed
+	}
+}
+
+// ReportLineErrors accounts bytes and frames discarded by the protocol decoder.
+func (t *Tx) ReportLineErrors(droppedBytes, crcErrors uint64) {
+	t.bus.droppedBytes.Add(droppedBytes)
+	t.bus.crcErrors.Add(crcErrors)
+}
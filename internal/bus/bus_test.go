@@ -0,0 +1,236 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestBus returns a bus on one end of a pipe and the other end as the device.
+func newTestBus(t *testing.T, gap time.Duration) (*Bus, net.Conn) {
+	t.Helper()
+
+	port, device := net.Pipe()
+	t.Cleanup(func() {
+		_ = port.Close()
+		_ = device.Close()
+	})
+
+	return NewBus(port, gap, DefaultPolicy(), zap.NewNop()), device
+}
+
+// echo writes back every chunk the device reads.
+func echo(device net.Conn) {
+	buf := make([]byte, rxChunkSize)
+
+	for {
+		n, err := device.Read(buf)
+		if err != nil {
+			return
+		}
+
+		_, err = device.Write(buf[:n])
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestTransactionsDoNotOverlap(t *testing.T) {
+	b, device := newTestBus(t, 0)
+	go echo(device)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running int
+		overlap bool
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			err := b.Transaction(context.Background(), func(tx *Tx) error {
+				mu.Lock()
+				running++
+				overlap = overlap || running > 1
+				mu.Unlock()
+
+				defer func() {
+					mu.Lock()
+					running--
+					mu.Unlock()
+				}()
+
+				err := tx.Write([]byte{byte(i)})
+				if err != nil {
+					return err
+				}
+
+				data, err := tx.Read(context.Background())
+				if err != nil {
+					return err
+				}
+
+				if !bytes.Equal(data, []byte{byte(i)}) {
+					t.Errorf("transaction %d read % X", i, data)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if overlap {
+		t.Fatal("transactions overlapped")
+	}
+
+	if got := b.Stats().Transactions; got != 8 {
+		t.Fatalf("transactions: got %d, want 8", got)
+	}
+}
+
+func TestTransactionFrameGap(t *testing.T) {
+	const gap = 50 * time.Millisecond
+
+	b, _ := newTestBus(t, gap)
+
+	var ends, starts []time.Time
+	for i := 0; i < 2; i++ {
+		err := b.Transaction(context.Background(), func(*Tx) error {
+			starts = append(starts, time.Now())
+			ends = append(ends, time.Now())
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if wait := starts[1].Sub(ends[0]); wait < gap {
+		t.Fatalf("second transaction started %s after the first one, want at least %s", wait, gap)
+	}
+}
+
+func TestTransactionDropsUnexpectedBytes(t *testing.T) {
+	b, device := newTestBus(t, 0)
+
+	_, err := device.Write([]byte{0xFF, 0xFE})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the receive goroutine queues the bytes written outside of a transaction
+	for len(b.rx) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	err = b.Transaction(context.Background(), func(tx *Tx) error {
+		go func() {
+			_, _ = device.Write([]byte{0x01})
+		}()
+
+		data, err := tx.Read(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(data, []byte{0x01}) {
+			t.Errorf("got % X, want 01", data)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadOnClosedBus(t *testing.T) {
+	b, device := newTestBus(t, 0)
+
+	_ = device.Close()
+	<-b.closed
+
+	err := b.Transaction(context.Background(), func(tx *Tx) error {
+		_, err := tx.Read(context.Background())
+
+		return err
+	})
+	if !errors.Is(err, ErrClosed) {
+		t.Fatalf("got %v, want %v", err, ErrClosed)
+	}
+}
+
+type baudRatePort struct {
+	net.Conn
+
+	err      error
+	baudRate int
+	resets   int
+}
+
+func (p *baudRatePort) SetBaudRate(baudRate int) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.baudRate = baudRate
+
+	return nil
+}
+
+func (p *baudRatePort) ResetBaudRate() error {
+	p.resets++
+
+	return nil
+}
+
+func TestBaudRateIsResetAfterTransaction(t *testing.T) {
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name   string
+		err    error
+		resets int
+	}{
+		{"switched", nil, 1},
+		{"switch failed", failed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, device := net.Pipe()
+			defer device.Close()
+
+			port := &baudRatePort{Conn: conn, err: tt.err}
+			b := NewBus(port, 0, DefaultPolicy(), zap.NewNop())
+			defer port.Close()
+
+			err := b.Transaction(context.Background(), func(tx *Tx) error {
+				return tx.SetBaudRate(9600)
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+
+			if port.resets != tt.resets {
+				t.Fatalf("resets: got %d, want %d", port.resets, tt.resets)
+			}
+		})
+	}
+}
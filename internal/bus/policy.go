@@ -0,0 +1,94 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	DefaultTimeout = 1 * time.Second
+	DefaultRetries = 2
+	DefaultBackoff = 100 * time.Millisecond
+)
+
+type Policy struct {
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Timeout: DefaultTimeout,
+		Retries: DefaultRetries,
+		Backoff: DefaultBackoff,
+	}
+}
+
+// Request executes fn in a transaction, every attempt is limited by the policy timeout
+// from the start of its transaction, the wait for the bus is limited by ctx only.
+// Attempts which timed out are repeated with an exponential backoff, other errors
+// are returned immediately.
+func (b *Bus) Request(ctx context.Context, policy Policy, fn func(ctx context.Context, tx *Tx) error) error {
+	var err error
+
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt > 0 {
+			b.retries.Add(1)
+
+			delay := policy.Backoff << (attempt - 1)
+			b.log.Warn(
+				"retry request",
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				b.failures.Add(1)
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+
+		err = b.attempt(ctx, policy.Timeout, fn)
+		if err == nil {
+			if attempt > 0 {
+				b.log.Info("request succeeded after retry", zap.Int("attempt", attempt))
+			}
+
+			return nil
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	b.failures.Add(1)
+	b.log.Warn(
+		"request failed",
+		zap.Int("retries", policy.Retries),
+		zap.Error(err),
+	)
+
+	return err
+}
+
+func (b *Bus) attempt(ctx context.Context, timeout time.Duration, fn func(ctx context.Context, tx *Tx) error) error {
+	return b.Transaction(ctx, func(tx *Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return fn(ctx, tx)
+	})
+}
@@ -0,0 +1,130 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestRetries(t *testing.T) {
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name     string
+		timeouts int
+		err      error
+		want     error
+		calls    int
+		retries  uint64
+		failures uint64
+	}{
+		{"success", 0, nil, nil, 1, 0, 0},
+		{"success after a timeout", 1, nil, nil, 2, 1, 0},
+		{"every attempt timed out", 3, nil, context.DeadlineExceeded, 3, 2, 1},
+		{"other errors are not retried", 0, failed, failed, 1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBus(t, 0)
+			policy := Policy{Timeout: 20 * time.Millisecond, Retries: 2, Backoff: time.Millisecond}
+
+			var calls int
+			err := b.Request(context.Background(), policy, func(ctx context.Context, tx *Tx) error {
+				calls++
+				if calls <= tt.timeouts {
+					<-ctx.Done()
+
+					return ctx.Err()
+				}
+
+				return tt.err
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if calls != tt.calls {
+				t.Errorf("calls: got %d, want %d", calls, tt.calls)
+			}
+
+			stats := b.Stats()
+			if stats.Retries != tt.retries || stats.Failures != tt.failures {
+				t.Errorf("got %d retries and %d failures, want %d and %d",
+					stats.Retries, stats.Failures, tt.retries, tt.failures)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutExcludesWaitForBus(t *testing.T) {
+	b, _ := newTestBus(t, 0)
+
+	busy := make(chan struct{})
+	done := make(chan struct{})
+
+	// a long session holds the bus for several timeouts of the next request
+	go func() {
+		defer close(done)
+
+		_ = b.Transaction(context.Background(), func(*Tx) error {
+			close(busy)
+			time.Sleep(100 * time.Millisecond)
+
+			return nil
+		})
+	}()
+
+	<-busy
+
+	policy := Policy{Timeout: 20 * time.Millisecond, Retries: 2, Backoff: time.Millisecond}
+	err := b.Request(context.Background(), policy, func(ctx context.Context, tx *Tx) error {
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-done
+
+	if stats := b.Stats(); stats.Retries != 0 || stats.Failures != 0 {
+		t.Fatalf("got %d retries and %d failures, want none", stats.Retries, stats.Failures)
+	}
+}
+
+func TestRequestCanceledWhileWaitingForBus(t *testing.T) {
+	b, _ := newTestBus(t, 0)
+
+	busy := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		_ = b.Transaction(context.Background(), func(*Tx) error {
+			close(busy)
+			<-release
+
+			return nil
+		})
+	}()
+	defer close(release)
+
+	<-busy
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var called bool
+	err := b.Request(ctx, DefaultPolicy(), func(context.Context, *Tx) error {
+		called = true
+
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if called {
+		t.Fatal("request ran without the bus")
+	}
+}
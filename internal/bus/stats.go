@@ -0,0 +1,19 @@
+package bus
+
+type Stats struct {
+	Transactions uint64 `json:"transactions"`
+	Retries      uint64 `json:"retries"`
+	Failures     uint64 `json:"failures"`
+	DroppedBytes uint64 `json:"droppedBytes"`
+	CRCErrors    uint64 `json:"crcErrors"`
+}
+
+func (b *Bus) Stats() Stats {
+	return Stats{
+		Transactions: b.transactions.Load(),
+		Retries:      b.retries.Load(),
+		Failures:     b.failures.Load(),
+		DroppedBytes: b.droppedBytes.Load(),
+		CRCErrors:    b.crcErrors.Load(),
+	}
+}
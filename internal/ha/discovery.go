@@ -58,212 +58,70 @@ func (m *DiscoveryMgr) Run() error {
 	return nil
 }
 
-func (m *DiscoveryMgr) sendDiscovery(mtr meter.Meter) error {
-	params := mtr.GetParams()
-	if params.Flags.HasPowerConsumption() {
-		data, err := m.buildDiscoveryPowerConsumption(mtr)
-		if err != nil {
-			return err
-		}
-
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"power-consumption",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
-		}
-
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "PowerConsumption"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
+// Announce sends the discovery of the meter again, e.g. when its measurements became known.
+func (m *DiscoveryMgr) Announce(mtr meter.Meter) error {
+	if !m.config.AutoDiscovery {
+		return nil
 	}
 
-	if params.Flags.HasFrequency() {
-		data, err := m.buildDiscoveryFrequency(mtr)
-		if err != nil {
-			return err
-		}
+	return m.sendDiscovery(mtr)
+}
 
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"frequency",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
+func (m *DiscoveryMgr) sendDiscovery(mtr meter.Meter) error {
+	params := mtr.GetParams()
+	for _, measurement := range params.Measurements {
+		oType := "sensor"
+		build := m.buildDiscoverySensor
+		if measurement.Binary {
+			oType = "binary_sensor"
+			build = m.buildDiscoveryBinarySensor
 		}
 
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "Frequency"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
-	}
-
-	if params.Flags.HasVoltage() {
-		data, err := m.buildDiscoveryVoltage(mtr)
+		data, err := build(mtr, measurement)
 		if err != nil {
 			return err
 		}
 
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"voltage",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
-		}
-
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "Voltage"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
-	}
-
-	if params.Flags.HasCurrent() {
-		data, err := m.buildDiscoveryCurrent(mtr)
+		err = m.publishDiscovery(oType, measurement.ID, params.UID, measurement.Key, data)
 		if err != nil {
 			return err
 		}
-
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"current",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
-		}
-
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "Current"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
 	}
 
-	if params.Flags.HasActivePower() {
-		data, err := m.buildDiscoveryActivePower(mtr)
-		if err != nil {
-			return err
-		}
-
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"active_power",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
-		}
-
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "Active power"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
-	}
-
-	if params.Flags.HasReactivePower() {
-		data, err := m.buildDiscoveryReactivePower(mtr)
-		if err != nil {
-			return err
-		}
-
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"reactive_power",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
-		}
+	return nil
+}
 
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "Reactive power"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
+func (m *DiscoveryMgr) publishDiscovery(oType string, name string, uid string, typ string, data []byte) error {
+	topic := m.buildDiscoveryTopic(oType, name, uid)
+	token := m.mqttClient.Publish(
+		topic,
+		1,
+		false,
+		data,
+	)
+	if token.Error() != nil {
+		return token.Error()
 	}
 
-	if params.Flags.HasFullPower() {
-		data, err := m.buildDiscoveryFullPower(mtr)
-		if err != nil {
-			return err
-		}
+	m.log.Debug(
+		"publish discovery",
+		zap.String("type", typ),
+		zap.String("topic", topic),
+		zap.String("payload", string(data)),
+	)
 
-		topic := m.buildDiscoveryTopic(
-			"sensor",
-			"full_power",
-			params.UID,
-		)
-		token := m.mqttClient.Publish(
-			topic,
-			1,
-			false,
-			data,
-		)
-		if token.Error() != nil {
-			return token.Error()
-		}
+	return nil
+}
 
-		m.log.Debug(
-			"publish discovery",
-			zap.String("type", "Full power"),
-			zap.String("topic", topic),
-			zap.String("payload", string(data)),
-		)
+func (m *DiscoveryMgr) buildDevice(mtr meter.Meter) entity.Device {
+	return entity.Device{
+		Identifiers:  []string{mtr.GetParams().UID},
+		HWVersion:    mtr.GetParams().HWVersion,
+		Manufacturer: mtr.GetParams().Manufacturer,
+		Model:        mtr.GetParams().Model,
+		Name:         mtr.GetParams().Name,
+		SWVersion:    mtr.GetParams().SWVersion,
 	}
-
-	return nil
 }
 
 // example: homeassistant/sensor/0x08833976/power-consumption/config
@@ -271,31 +129,21 @@ func (m *DiscoveryMgr) buildDiscoveryTopic(oType string, name string, uid string
 	return fmt.Sprintf("%s/%s/%s/%s/config", m.config.Prefix, oType, name, uid)
 }
 
-func (m *DiscoveryMgr) buildDiscoveryPowerConsumption(mtr meter.Meter) ([]byte, error) {
+func (m *DiscoveryMgr) buildDiscoverySensor(mtr meter.Meter, measurement meter.Measurement) ([]byte, error) {
 	objectID := strings.ToLower(
 		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
 	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID
+	uniqueID := mtr.GetParams().UID + "_" + objectID + measurement.UniqueSuffix
+	precision := measurement.Precision
 
 	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.powerConsumption }}",
-		UnitOfMeasurement: "kWh",
-		DeviceClass:       enum.DeviceClassEnergy,
-		StateClass:        enum.StateClassTotal,
-		Base: entity.Base{
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
+		StateTopic:                mtr.GetParams().StateTopic,
+		ValueTemplate:             "{{ " + valueExpression(measurement.Key) + " }}",
+		UnitOfMeasurement:         measurement.Unit,
+		DeviceClass:               measurement.DeviceClass,
+		StateClass:                measurement.StateClass,
+		SuggestedDisplayPrecision: &precision,
+		Base:                      m.buildBase(mtr, measurement, objectID, uniqueID),
 	}
 	data, err := json.Marshal(obj)
 	if err != nil {
@@ -305,31 +153,17 @@ func (m *DiscoveryMgr) buildDiscoveryPowerConsumption(mtr meter.Meter) ([]byte,
 	return data, nil
 }
 
-func (m *DiscoveryMgr) buildDiscoveryFrequency(mtr meter.Meter) ([]byte, error) {
+func (m *DiscoveryMgr) buildDiscoveryBinarySensor(mtr meter.Meter, measurement meter.Measurement) ([]byte, error) {
 	objectID := strings.ToLower(
 		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
 	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID + "_frequency"
+	uniqueID := mtr.GetParams().UID + "_" + objectID + measurement.UniqueSuffix
 
-	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.frequency }}",
-		UnitOfMeasurement: "Hz",
-		DeviceClass:       enum.DeviceClassFrequency,
-		StateClass:        enum.StateClassMeasurement,
-		Base: entity.Base{
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
+	obj := entity.BinarySensor{
+		StateTopic:    mtr.GetParams().StateTopic,
+		ValueTemplate: "{{ 'ON' if " + valueExpression(measurement.Key) + " else 'OFF' }}",
+		DeviceClass:   measurement.BinaryDeviceClass,
+		Base:          m.buildBase(mtr, measurement, objectID, uniqueID),
 	}
 	data, err := json.Marshal(obj)
 	if err != nil {
@@ -339,175 +173,48 @@ func (m *DiscoveryMgr) buildDiscoveryFrequency(mtr meter.Meter) ([]byte, error)
 	return data, nil
 }
 
-func (m *DiscoveryMgr) buildDiscoveryVoltage(mtr meter.Meter) ([]byte, error) {
-	objectID := strings.ToLower(
-		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
-	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID + "_voltage"
+func (m *DiscoveryMgr) buildBase(mtr meter.Meter, measurement meter.Measurement, objectID string, uniqueID string) entity.Base {
+	base := entity.Base{
+		Name:        measurement.Name,
+		Device:      m.buildDevice(mtr),
+		ObjectID:    objectID,
+		UniqueID:    uniqueID,
+		ForceUpdate: true,
 
-	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.voltage }}",
-		UnitOfMeasurement: "V",
-		DeviceClass:       enum.DeviceClassVoltage,
-		StateClass:        enum.StateClassMeasurement,
-		Base: entity.Base{
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
+		AvailabilityTopic: mtr.GetParams().AvailabilityTopic,
 	}
-	data, err := json.Marshal(obj)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
-}
 
-func (m *DiscoveryMgr) buildDiscoveryCurrent(mtr meter.Meter) ([]byte, error) {
-	objectID := strings.ToLower(
-		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
-	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID + "_current"
-
-	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.current }}",
-		UnitOfMeasurement: "A",
-		DeviceClass:       enum.DeviceClassCurrent,
-		StateClass:        enum.StateClassMeasurement,
-		Base: entity.Base{
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
-	}
-	data, err := json.Marshal(obj)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
-}
-
-func (m *DiscoveryMgr) buildDiscoveryActivePower(mtr meter.Meter) ([]byte, error) {
-	objectID := strings.ToLower(
-		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
-	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID + "_active_power"
-
-	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.activePower }}",
-		UnitOfMeasurement: "W",
-		DeviceClass:       enum.DeviceClassPower,
-		StateClass:        enum.StateClassMeasurement,
-		Base: entity.Base{
-			Name: "Active power",
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
-	}
-	data, err := json.Marshal(obj)
-	if err != nil {
-		return nil, err
+	if measurement.Diagnostic {
+		base.EntityCategory = enum.EntityCategoryDiagnostic
 	}
 
-	return data, nil
+	return base
 }
 
-func (m *DiscoveryMgr) buildDiscoveryReactivePower(mtr meter.Meter) ([]byte, error) {
-	objectID := strings.ToLower(
-		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
-	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID + "_reactive_power"
-
-	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.reactivePower }}",
-		UnitOfMeasurement: "VAr",
-		DeviceClass:       enum.DeviceClassPower,
-		StateClass:        enum.StateClassMeasurement,
-		Base: entity.Base{
-			Name: "Reactive power",
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
-	}
-	data, err := json.Marshal(obj)
-	if err != nil {
-		return nil, err
+// valueExpression returns the template expression of the state value: value_json.l1.voltage,
+// keys which are not identifiers are indexed, e.g. value_json['hot-water'].
+func valueExpression(key string) string {
+	expr := "value_json"
+	for _, part := range strings.Split(key, ".") {
+		if isIdentifier(part) {
+			expr += "." + part
+		} else {
+			expr += "['" + part + "']"
+		}
 	}
 
-	return data, nil
+	return expr
 }
 
-func (m *DiscoveryMgr) buildDiscoveryFullPower(mtr meter.Meter) ([]byte, error) {
-	objectID := strings.ToLower(
-		strings.ReplaceAll(mtr.GetParams().Name, " ", "_"),
-	)
-	uniqueID := mtr.GetParams().UID + "_" + objectID + "_full_power"
-
-	obj := entity.Sensor{
-		StateTopic:        mtr.GetParams().StateTopic,
-		ValueTemplate:     "{{ value_json.fullPower }}",
-		UnitOfMeasurement: "VA",
-		DeviceClass:       enum.DeviceClassPower,
-		StateClass:        enum.StateClassMeasurement,
-		Base: entity.Base{
-			Name: "Full power",
-			Device: entity.Device{
-				Identifiers:  []string{mtr.GetParams().UID},
-				HWVersion:    mtr.GetParams().HWVersion,
-				Manufacturer: mtr.GetParams().Manufacturer,
-				Model:        mtr.GetParams().Model,
-				Name:         mtr.GetParams().Name,
-				SWVersion:    mtr.GetParams().SWVersion,
-			},
-			ObjectID:    objectID,
-			UniqueID:    uniqueID,
-			ForceUpdate: true,
-		},
-	}
-	data, err := json.Marshal(obj)
-	if err != nil {
-		return nil, err
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
 	}
 
-	return data, nil
+	return s != ""
 }
@@ -11,4 +11,6 @@ type Base struct {
 	ObjectID       string              `json:"object_id,omitempty"`
 	UniqueID       string              `json:"unique_id"`
 	ForceUpdate    bool                `json:"force_update,omitempty"`
+	// AvailabilityTopic receives online and offline, the entity is unavailable until online.
+	AvailabilityTopic string `json:"availability_topic,omitempty"`
 }
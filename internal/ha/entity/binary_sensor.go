@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"github.com/lan143/metrology-master/internal/ha/enum"
+)
+
+type BinarySensor struct {
+	StateTopic    string                       `json:"state_topic,omitempty"`
+	ValueTemplate string                       `json:"value_template,omitempty"`
+	PayloadOn     string                       `json:"payload_on,omitempty"`
+	PayloadOff    string                       `json:"payload_off,omitempty"`
+	DeviceClass   enum.BinarySensorDeviceClass `json:"device_class,omitempty"`
+	Base
+}
@@ -10,5 +10,7 @@ type Sensor struct {
 	UnitOfMeasurement string           `json:"unit_of_measurement,omitempty"`
 	DeviceClass       enum.DeviceClass `json:"device_class,omitempty"`
 	StateClass        enum.StateClass  `json:"state_class"`
+	// SuggestedDisplayPrecision is the number of decimals Home Assistant displays.
+	SuggestedDisplayPrecision *int `json:"suggested_display_precision,omitempty"`
 	Base
 }
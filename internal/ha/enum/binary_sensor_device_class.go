@@ -0,0 +1,8 @@
+package enum
+
+type BinarySensorDeviceClass string
+
+const (
+	BinarySensorDeviceClassMoisture BinarySensorDeviceClass = "moisture"
+	BinarySensorDeviceClassTamper   BinarySensorDeviceClass = "tamper"
+)
@@ -3,9 +3,15 @@ package enum
 type DeviceClass string
 
 const (
-	DeviceClassCurrent   DeviceClass = "current"
-	DeviceClassEnergy    DeviceClass = "energy"
-	DeviceClassFrequency DeviceClass = "frequency"
-	DeviceClassPower     DeviceClass = "power"
-	DeviceClassVoltage   DeviceClass = "voltage"
+	DeviceClassCurrent        DeviceClass = "current"
+	DeviceClassDuration       DeviceClass = "duration"
+	DeviceClassEnergy         DeviceClass = "energy"
+	DeviceClassFrequency      DeviceClass = "frequency"
+	DeviceClassGas            DeviceClass = "gas"
+	DeviceClassPower          DeviceClass = "power"
+	DeviceClassPowerFactor    DeviceClass = "power_factor"
+	DeviceClassTemperature    DeviceClass = "temperature"
+	DeviceClassVoltage        DeviceClass = "voltage"
+	DeviceClassVolumeFlowRate DeviceClass = "volume_flow_rate"
+	DeviceClassWater          DeviceClass = "water"
 )
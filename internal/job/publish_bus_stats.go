@@ -0,0 +1,58 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+)
+
+type PublishBusStatsJob struct {
+	name       string
+	bus        *bus.Bus
+	mqttClient mqtt.Client
+	log        *zap.Logger
+}
+
+func NewPublishBusStatsJob(
+	name string,
+	bus *bus.Bus,
+	mqttClient mqtt.Client,
+	log *zap.Logger,
+) *PublishBusStatsJob {
+	return &PublishBusStatsJob{
+		name:       name,
+		bus:        bus,
+		mqttClient: mqttClient,
+		log:        log,
+	}
+}
+
+// example: metrology-master/bus/rs485/stats
+func (j *PublishBusStatsJob) Execute(_ context.Context) error {
+	stats := j.bus.Stats()
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	topic := "metrology-master/bus/" + j.name + "/stats"
+	token := j.mqttClient.Publish(topic, 1, false, data)
+	if token.Error() != nil {
+		return token.Error()
+	}
+
+	j.log.Info(
+		"bus stats",
+		zap.String("port", j.name),
+		zap.Uint64("transactions", stats.Transactions),
+		zap.Uint64("retries", stats.Retries),
+		zap.Uint64("failures", stats.Failures),
+		zap.Uint64("droppedBytes", stats.DroppedBytes),
+		zap.Uint64("crcErrors", stats.CRCErrors),
+	)
+
+	return nil
+}
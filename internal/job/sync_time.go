@@ -0,0 +1,28 @@
+package job
+
+import (
+	"context"
+	"github.com/lan143/metrology-master/internal/meter"
+	"go.uber.org/zap"
+)
+
+type SyncTimeJob struct {
+	meter meter.TimeSyncer
+	log   *zap.Logger
+}
+
+func NewSyncTimeJob(meter meter.TimeSyncer, log *zap.Logger) *SyncTimeJob {
+	return &SyncTimeJob{
+		meter: meter,
+		log:   log,
+	}
+}
+
+func (j *SyncTimeJob) Execute(ctx context.Context) error {
+	j.log.Debug(
+		"sync time",
+		zap.String("uid", j.meter.GetParams().UID),
+	)
+
+	return j.meter.SyncTime(ctx)
+}
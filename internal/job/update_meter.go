@@ -9,91 +9,96 @@ import (
 	"go.uber.org/zap"
 )
 
-type UpdateElectricMeterJob struct {
-	meter      meter.ElectricMeter
+const (
+	availabilityOnline  = "online"
+	availabilityOffline = "offline"
+)
+
+// UpdateMeterJob publishes the state of a meter of any kind. Meters with an availability topic
+// are announced again when they come online, their measurements may be known by then only.
+type UpdateMeterJob struct {
+	meter      meter.Meter
 	mqttClient mqtt.Client
+	announce   func(meter.Meter) error
 	log        *zap.Logger
+
+	// availability is the last payload published to the availability topic.
+	availability string
 }
 
 func NewUpdateMeterJob(
-	meter meter.ElectricMeter,
+	meter meter.Meter,
 	mqttClient mqtt.Client,
+	announce func(meter.Meter) error,
 	log *zap.Logger,
-) *UpdateElectricMeterJob {
-	return &UpdateElectricMeterJob{
+) *UpdateMeterJob {
+	return &UpdateMeterJob{
 		meter:      meter,
 		mqttClient: mqttClient,
+		announce:   announce,
 		log:        log,
 	}
 }
 
-func (j *UpdateElectricMeterJob) Execute(ctx context.Context) error {
-	var (
-		err    error
-		state  = mqtt2.State{}
-		params = j.meter.GetParams()
-	)
+func (j *UpdateMeterJob) Execute(ctx context.Context) error {
+	err := publishState(ctx, j.meter, j.mqttClient, j.log)
 
-	if params.Flags.HasPowerConsumption() {
-		state.PowerConsumption, err = j.meter.GetPowerConsumption(ctx)
-		if err != nil {
-			return err
+	if topic := j.meter.GetParams().AvailabilityTopic; topic != "" {
+		availability := availabilityOffline
+		if err == nil {
+			availability = availabilityOnline
 		}
-	}
 
-	if params.Flags.HasFrequency() {
-		state.Frequency, err = j.meter.GetFrequency(ctx)
-		if err != nil {
-			return err
-		}
+		j.publishAvailability(topic, availability)
 	}
 
-	if params.Flags.HasVoltage() {
-		state.Voltage, err = j.meter.GetVoltage(ctx)
-		if err != nil {
-			return err
-		}
-	}
+	return err
+}
 
-	if params.Flags.HasCurrent() {
-		state.Current, err = j.meter.GetCurrent(ctx)
-		if err != nil {
-			return err
-		}
+func (j *UpdateMeterJob) publishAvailability(topic string, availability string) {
+	if availability == j.availability {
+		return
 	}
 
-	if params.Flags.HasActivePower() {
-		state.ActivePower, err = j.meter.GetActivePower(ctx)
+	if availability == availabilityOnline {
+		err := j.announce(j.meter)
 		if err != nil {
-			return err
+			j.log.Error("announce meter", zap.String("uid", j.meter.GetParams().UID), zap.Error(err))
+
+			return
 		}
 	}
 
-	if params.Flags.HasReactivePower() {
-		state.ReactivePower, err = j.meter.GetReactivePower(ctx)
-		if err != nil {
-			return err
-		}
+	token := j.mqttClient.Publish(topic, 1, true, availability)
+	if token.Error() != nil {
+		j.log.Error("publish availability", zap.String("topic", topic), zap.Error(token.Error()))
+
+		return
 	}
 
-	if params.Flags.HasFullPower() {
-		state.FullPower, err = j.meter.GetFullPower(ctx)
-		if err != nil {
-			return err
-		}
+	j.availability = availability
+}
+
+// publishState reads the measurements of the meter and publishes them to its state topic.
+func publishState(ctx context.Context, mtr meter.Meter, mqttClient mqtt.Client, log *zap.Logger) error {
+	params := mtr.GetParams()
+
+	readings, err := mtr.Read(ctx)
+	if err != nil {
+		return err
 	}
 
-	data, err := json.Marshal(state)
+	data, err := json.Marshal(mqtt2.NewState(params.Measurements, readings))
 	if err != nil {
 		return err
 	}
 
-	token := j.mqttClient.Publish(params.StateTopic, 1, false, data)
+	token := mqttClient.Publish(params.StateTopic, 1, false, data)
 	if token.Error() != nil {
 		return token.Error()
 	}
 
-	j.log.Debug(
+	log.Debug(
 		"publish state",
 		zap.String("topic", params.StateTopic),
 		zap.String("payload", string(data)),
@@ -2,35 +2,114 @@ package meter
 
 import (
 	"flag"
+	"fmt"
 	"github.com/lan143/metrology-master/pkg/flag/flagutil"
+	"time"
+)
+
+const (
+	DefaultTimeSyncInterval = 24 * time.Hour
+)
+
+type (
+	// Options are the options of a meter type, the driver of the type declares them.
+	Options interface {
+		// Validate checks the options, e.g. that the required ones are set.
+		Validate() error
+	}
+
+	// OptionsFunc exports the options of the meter type to the flags of the meter.
+	OptionsFunc func(typ string, flags *flag.FlagSet) (Options, error)
 )
 
 type Config struct {
-	Type   string
-	UID    string
-	Port   string
+	Type string
+	Port string
+	// Host is the address of a Modbus TCP device, it is used instead of Port.
+	Host   string
 	Export []string
+
+	TimeSyncThreshold time.Duration
+	TimeSyncInterval  time.Duration
+
+	// Timeout, Retries and Backoff override the port settings when set.
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+
+	// Options are the options of the type, they are exported once the type is set.
+	Options Options
+}
+
+// ValidateTimeSync checks the time sync options of meters which sync their clock.
+func (c *Config) ValidateTimeSync() error {
+	if c.TimeSyncInterval <= 0 {
+		return fmt.Errorf("time-sync-interval must be positive, got %s", c.TimeSyncInterval)
+	}
+
+	return nil
 }
 
-func Export(flags *flag.FlagSet) *Config {
+// Export exports the options shared by every meter type, the options of the type
+// are exported by options when the type is set.
+func Export(flags *flag.FlagSet, options OptionsFunc) *Config {
 	c := &Config{}
 
+	flagutil.Func(flags, "type", "", func(typ string) error {
+		if c.Type != "" {
+			return fmt.Errorf("type is already set to \"%s\"", c.Type)
+		}
+
+		o, err := options(typ, flags)
+		if err != nil {
+			return err
+		}
+
+		c.Type = typ
+		c.Options = o
+
+		return nil
+	})
 	flags.StringVar(
-		&c.Type,
-		"type",
+		&c.Port,
+		"port",
 		"",
 		"",
 	)
 	flags.StringVar(
-		&c.UID,
-		"uid",
+		&c.Host,
+		"host",
 		"",
 		"",
 	)
-	flags.StringVar(
-		&c.Port,
-		"port",
+	flags.DurationVar(
+		&c.TimeSyncThreshold,
+		"time-sync-threshold",
+		0,
 		"",
+	)
+	flags.DurationVar(
+		&c.TimeSyncInterval,
+		"time-sync-interval",
+		DefaultTimeSyncInterval,
+		"",
+	)
+	flags.DurationVar(
+		&c.Timeout,
+		"timeout",
+		0,
+		"",
+	)
+	flags.IntVar(
+		&c.Retries,
+		"retries",
+		-1,
+		"",
+	)
+	flags.DurationVar(
+		&c.Backoff,
+		"backoff",
+		0,
 		"",
 	)
 	flagutil.Func(flags, "export", "", func(name string) error {
@@ -48,3 +127,23 @@ func Export(flags *flag.FlagSet) *Config {
 
 	return c
 }
+
+// ExportOBIS exports the option mapping metrics onto data set addresses of IEC 62056-21
+// meters and onto logical names of DLMS/COSEM registers.
+func ExportOBIS(flags *flag.FlagSet, name, usage string) map[string]string {
+	codes := make(map[string]string)
+
+	flagutil.Subset(flags, name, func(sub *flag.FlagSet) {
+		flagutil.Func(sub, "include", usage, func(metric string) error {
+			flagutil.Func(sub, metric, "", func(code string) error {
+				codes[metric] = code
+
+				return nil
+			})
+
+			return nil
+		})
+	})
+
+	return codes
+}
@@ -0,0 +1,122 @@
+package dlms
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	dlms_m "github.com/lan143/metrology-master/internal/protocol/dlms"
+	"strconv"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "DLMS/COSEM electricity meter over HDLC",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of DLMS/COSEM meters.
+type Options struct {
+	UID      string
+	UnitID   int
+	Password string
+	OBIS     map[string]string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"physical address, every device answers when empty",
+	)
+	flags.IntVar(
+		&o.UnitID,
+		"unit-id",
+		1,
+		"logical device",
+	)
+	flags.StringVar(
+		&o.Password,
+		"password",
+		"",
+		"password of the reading client, the public client is used when empty",
+	)
+	o.OBIS = meter.ExportOBIS(flags, "obis", "logical names of registers by metric")
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UnitID < 0 || o.UnitID > int(dlms_m.AllStation) {
+		return fmt.Errorf("unit id %d is out of range", o.UnitID)
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, name string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	protocol, settings, err := NewProtocol(env, config, options)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	m, err := NewDLMS(
+		Config{
+			Name:     name,
+			Settings: settings,
+			OBIS:     options.OBIS,
+		},
+		protocol,
+		env.Log(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	return m, nil
+}
+
+// NewProtocol returns the client and the association settings: uid is the physical
+// address, all devices on the line answer when it is empty, unit-id is the logical device.
+// The public client associates without a password, the reading client with the password.
+func NewProtocol(env driver.Env, config *meter.Config, options *Options) (*dlms_m.DLMS, dlms_m.Settings, error) {
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, dlms_m.Settings{}, err
+	}
+
+	settings := dlms_m.Settings{
+		Client: dlms_m.ClientPublic,
+		Server: dlms_m.ServerAddress{
+			Logical:  uint16(options.UnitID),
+			Physical: dlms_m.AllStation,
+		},
+		Security: dlms_m.SecurityLowest,
+	}
+
+	if options.UID != "" {
+		physical, err := strconv.ParseUint(options.UID, 0, 16)
+		if err != nil || physical > uint64(dlms_m.AllStation) {
+			return nil, dlms_m.Settings{}, fmt.Errorf("invalid physical address \"%s\"", options.UID)
+		}
+
+		settings.Server.Physical = uint16(physical)
+	}
+
+	if options.Password != "" {
+		settings.Client = dlms_m.ClientReader
+		settings.Security = dlms_m.SecurityLow
+		settings.Password = options.Password
+	}
+
+	return dlms_m.NewDLMS(port, policy, env.Log()), settings, nil
+}
@@ -0,0 +1,330 @@
+package dlms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	dlms_m "github.com/lan143/metrology-master/internal/protocol/dlms"
+	"go.uber.org/zap"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	Type         string = "dlms"
+	model               = "DLMS/COSEM"
+	manufacturer        = "DLMS"
+)
+
+// readoutMaxAge lets the getters called by one update share a session,
+// associating takes several round trips, so values are read all at once.
+const readoutMaxAge = 10 * time.Second
+
+// DefaultOBIS maps metrics onto the registers of the electricity meters, used when
+// the config has no mapping. Registers missing in the device are skipped.
+var DefaultOBIS = map[string]string{
+	meter.MetricPowerConsumption:                    "1.0.1.8.0.255",
+	meter.TariffMetric(1):                           "1.0.1.8.1.255",
+	meter.TariffMetric(2):                           "1.0.1.8.2.255",
+	meter.TariffMetric(3):                           "1.0.1.8.3.255",
+	meter.TariffMetric(4):                           "1.0.1.8.4.255",
+	meter.MetricFrequency:                           "1.0.14.7.0.255",
+	meter.MetricVoltage:                             "1.0.12.7.0.255",
+	meter.MetricCurrent:                             "1.0.11.7.0.255",
+	meter.MetricActivePower:                         "1.0.1.7.0.255",
+	meter.MetricReactivePower:                       "1.0.3.7.0.255",
+	meter.MetricFullPower:                           "1.0.9.7.0.255",
+	meter.MetricPowerFactor:                         "1.0.13.7.0.255",
+	meter.PhaseMetric(1, meter.MetricVoltage):       "1.0.32.7.0.255",
+	meter.PhaseMetric(2, meter.MetricVoltage):       "1.0.52.7.0.255",
+	meter.PhaseMetric(3, meter.MetricVoltage):       "1.0.72.7.0.255",
+	meter.PhaseMetric(1, meter.MetricCurrent):       "1.0.31.7.0.255",
+	meter.PhaseMetric(2, meter.MetricCurrent):       "1.0.51.7.0.255",
+	meter.PhaseMetric(3, meter.MetricCurrent):       "1.0.71.7.0.255",
+	meter.PhaseMetric(1, meter.MetricActivePower):   "1.0.21.7.0.255",
+	meter.PhaseMetric(2, meter.MetricActivePower):   "1.0.41.7.0.255",
+	meter.PhaseMetric(3, meter.MetricActivePower):   "1.0.61.7.0.255",
+	meter.PhaseMetric(1, meter.MetricReactivePower): "1.0.23.7.0.255",
+	meter.PhaseMetric(2, meter.MetricReactivePower): "1.0.43.7.0.255",
+	meter.PhaseMetric(3, meter.MetricReactivePower): "1.0.63.7.0.255",
+	meter.PhaseMetric(1, meter.MetricPowerFactor):   "1.0.33.7.0.255",
+	meter.PhaseMetric(2, meter.MetricPowerFactor):   "1.0.53.7.0.255",
+	meter.PhaseMetric(3, meter.MetricPowerFactor):   "1.0.73.7.0.255",
+}
+
+type (
+	Config struct {
+		// Name is the meter name in the config, it identifies the meter
+		// when the device has no serial number object.
+		Name     string
+		Settings dlms_m.Settings
+		// OBIS maps metrics onto logical names of registers.
+		OBIS map[string]string
+	}
+
+	dlms struct {
+		config  Config
+		service *dlms_m.DLMS
+		log     *zap.Logger
+
+		params   meter.Params
+		tariffs  int
+		phases   int
+		objects  map[string]dlms_m.OBIS
+		scalers  map[string]dlms_m.ScalerUnit
+		values   map[string]float64
+		readTime time.Time
+	}
+)
+
+func NewDLMS(config Config, service *dlms_m.DLMS, log *zap.Logger) (meter.ElectricMeter, error) {
+	if len(config.OBIS) == 0 {
+		config.OBIS = DefaultOBIS
+	}
+
+	objects := make(map[string]dlms_m.OBIS, len(config.OBIS))
+	for metric, code := range config.OBIS {
+		if !meter.IsElectricMetric(metric) {
+			return nil, fmt.Errorf("unknown metric \"%s\"", metric)
+		}
+
+		obis, err := dlms_m.ParseOBIS(code)
+		if err != nil {
+			return nil, fmt.Errorf("metric \"%s\": %s", metric, err.Error())
+		}
+
+		objects[metric] = obis
+	}
+
+	return &dlms{
+		config:  config,
+		service: service,
+		log:     log,
+		objects: objects,
+	}, nil
+}
+
+func (m *dlms) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *dlms) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
+// Init reads the identification and the scaler_unit of the mapped registers,
+// registers the device does not define are skipped.
+func (m *dlms) Init(ctx context.Context) error {
+	var serialNumber, deviceName string
+
+	err := m.service.Session(ctx, m.config.Settings, func(ctx context.Context, session *dlms_m.Session) error {
+		var err error
+
+		serialNumber, err = session.ReadString(ctx, dlms_m.SerialNumberOBIS)
+		if err != nil && !isUndefined(err) {
+			return err
+		}
+
+		deviceName, err = session.ReadString(ctx, dlms_m.LogicalDeviceNameOBIS)
+		if err != nil && !isUndefined(err) {
+			return err
+		}
+
+		m.scalers = make(map[string]dlms_m.ScalerUnit, len(m.objects))
+		for metric, obis := range m.objects {
+			scalerUnit, err := session.ReadScalerUnit(ctx, obis)
+			if err != nil {
+				if isUndefined(err) {
+					m.log.Debug("skip register", zap.String("metric", metric), zap.Stringer("obis", obis), zap.Error(err))
+					continue
+				}
+
+				return err
+			}
+
+			m.scalers[metric] = scalerUnit
+		}
+
+		return m.readValues(ctx, session)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.buildParams(strings.TrimSpace(serialNumber), strings.TrimSpace(deviceName))
+
+	return nil
+}
+
+func (m *dlms) GetPowerConsumption(ctx context.Context) (float64, error) {
+	if m.has(meter.MetricPowerConsumption) {
+		return m.read(ctx, meter.MetricPowerConsumption)
+	}
+
+	tariffs, err := m.GetTariffConsumption(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, value := range tariffs {
+		total += value
+	}
+
+	return total, nil
+}
+
+func (m *dlms) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	tariffs := make([]float64, m.tariffs)
+	for i := range tariffs {
+		var err error
+
+		tariffs[i], err = m.read(ctx, meter.TariffMetric(i+1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tariffs, nil
+}
+
+func (m *dlms) GetFrequency(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricFrequency)
+}
+
+func (m *dlms) GetVoltage(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricVoltage)
+}
+
+func (m *dlms) GetCurrent(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricCurrent)
+}
+
+func (m *dlms) GetActivePower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricActivePower)
+}
+
+func (m *dlms) GetReactivePower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricReactivePower)
+}
+
+func (m *dlms) GetFullPower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricFullPower)
+}
+
+func (m *dlms) GetPowerFactor(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricPowerFactor)
+}
+
+func (m *dlms) GetAngle(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricAngle)
+}
+
+// GetPhases returns values of the per-phase registers present in the device, the rest are zero.
+func (m *dlms) GetPhases(ctx context.Context) ([]meter.Phase, error) {
+	phases := make([]meter.Phase, m.phases)
+	for i := range phases {
+		values := make(map[string]float64)
+
+		for _, metric := range meter.PhaseMetrics {
+			name := meter.PhaseMetric(i+1, metric)
+			if !m.has(name) {
+				continue
+			}
+
+			value, err := m.read(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+
+			values[metric] = value
+		}
+
+		phases[i] = meter.Phase{
+			Voltage:       values[meter.MetricVoltage],
+			Current:       values[meter.MetricCurrent],
+			ActivePower:   values[meter.MetricActivePower],
+			ReactivePower: values[meter.MetricReactivePower],
+			FullPower:     values[meter.MetricFullPower],
+			PowerFactor:   values[meter.MetricPowerFactor],
+			Angle:         values[meter.MetricAngle],
+		}
+	}
+
+	return phases, nil
+}
+
+// has reports whether the metric is mapped onto a register defined in the device.
+func (m *dlms) has(metric string) bool {
+	_, ok := m.scalers[metric]
+
+	return ok
+}
+
+func (m *dlms) read(ctx context.Context, metric string) (float64, error) {
+	if !m.has(metric) {
+		return 0, fmt.Errorf("metric \"%s\" is not mapped to a register", metric)
+	}
+
+	if time.Since(m.readTime) > readoutMaxAge {
+		err := m.service.Session(ctx, m.config.Settings, m.readValues)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return m.values[metric], nil
+}
+
+func (m *dlms) readValues(ctx context.Context, session *dlms_m.Session) error {
+	values := make(map[string]float64, len(m.scalers))
+	for metric, scalerUnit := range m.scalers {
+		raw, err := session.ReadRegisterValue(ctx, m.objects[metric])
+		if err != nil {
+			return fmt.Errorf("metric \"%s\": %w", metric, err)
+		}
+
+		value := scalerUnit.Apply(raw)
+		switch scalerUnit.Unit {
+		case dlms_m.UnitWattHour, dlms_m.UnitVarHour, dlms_m.UnitVoltAmpereHour:
+			value /= 1000
+		}
+
+		values[metric] = math.Round(value*1000) / 1000
+	}
+
+	m.values = values
+	m.readTime = time.Now()
+
+	return nil
+}
+
+func (m *dlms) buildParams(serialNumber string, deviceName string) {
+	uid := serialNumber
+	if uid == "" {
+		uid = m.config.Name
+	}
+
+	// the logical device name starts with the manufacturer FLAG id
+	vendor := manufacturer
+	if len(deviceName) >= 3 {
+		vendor = deviceName[:3]
+	}
+
+	m.tariffs, m.phases = meter.ElectricMetricCounts(m.has)
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("power-meter/%s/state", uid),
+		Manufacturer: vendor,
+		Model:        model,
+		Name:         vendor + " " + model,
+		Measurements: meter.ElectricMeasurements(m.has),
+	}
+}
+
+func isUndefined(err error) bool {
+	return errors.Is(err, dlms_m.ErrObjectUndefined) || errors.Is(err, dlms_m.ErrObjectUnavailable)
+}
@@ -0,0 +1,144 @@
+package driver
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"github.com/lan143/metrology-master/internal/meter"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+)
+
+// Transport is the way a driver reaches its meters.
+type Transport string
+
+const (
+	// TransportSerial meters are on the bus of a port.
+	TransportSerial Transport = "serial"
+	// TransportSerialOrTCP meters are on the bus of a port or behind a TCP host.
+	TransportSerialOrTCP Transport = "serial, tcp"
+	// TransportReceiver meters send telegrams to the wireless receiver on a port.
+	TransportReceiver Transport = "receiver"
+)
+
+type (
+	// Env gives drivers the transports and the config sections of the application, they
+	// are shared by the meters.
+	Env interface {
+		// Port returns the bus of the port of the meter and the policy of its requests.
+		Port(config *meter.Config) (*bus.Bus, bus.Policy, error)
+		// Host returns the connection to the host of the meter and the policy of its requests,
+		// defaultPort is used when the host has no port.
+		Host(config *meter.Config, defaultPort string) (*bus.Bus, bus.Policy, error)
+		// Resource returns the named entry of a config section shared by meters, the driver
+		// reading the section knows the type of its entries.
+		Resource(section string, name string) (interface{}, bool)
+		Log() *zap.Logger
+	}
+
+	// Factory builds the meter of the config, the meter is initialized by the caller.
+	Factory func(env Env, name string, config *meter.Config) (meter.Meter, error)
+
+	// OptionsFunc exports the options of the driver to the flags of a meter, the usage
+	// of a flag describes the option.
+	OptionsFunc func(flags *flag.FlagSet) meter.Options
+
+	Driver struct {
+		Type        string
+		Description string
+		Transport   Transport
+		// Options are the options of the driver besides the ones shared by every type,
+		// nil when the driver has none.
+		Options OptionsFunc
+		New     Factory
+	}
+)
+
+var (
+	mu      sync.RWMutex
+	drivers = make(map[string]Driver)
+)
+
+// Register makes the driver available by its type, driver packages call it from init.
+// It panics if the driver has no type or factory, or its type is registered twice.
+func Register(driver Driver) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if driver.Type == "" || driver.New == nil {
+		panic("driver: Register driver without type or factory")
+	}
+
+	if _, ok := drivers[driver.Type]; ok {
+		panic("driver: Register called twice for type " + driver.Type)
+	}
+
+	drivers[driver.Type] = driver
+}
+
+// Lookup returns the driver of the meter type.
+func Lookup(typ string) (Driver, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	driver, ok := drivers[typ]
+
+	return driver, ok
+}
+
+// Drivers returns the registered drivers sorted by type.
+func Drivers() []Driver {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make([]Driver, 0, len(drivers))
+	for _, driver := range drivers {
+		result = append(result, driver)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+
+	return result
+}
+
+// Validate checks that the config sets the transport of the driver and validates its options.
+func (d Driver) Validate(config *meter.Config) error {
+	switch d.Transport {
+	case TransportSerialOrTCP:
+		if config.Port == "" && config.Host == "" {
+			return fmt.Errorf("port or host is required")
+		}
+	default:
+		if config.Port == "" {
+			return fmt.Errorf("port is required")
+		}
+	}
+
+	if config.Options == nil {
+		return nil
+	}
+
+	return config.Options.Validate()
+}
+
+// ExportOptions exports the options of the driver of the meter type to the flags of the meter.
+func ExportOptions(typ string, flags *flag.FlagSet) (meter.Options, error) {
+	d, ok := Lookup(typ)
+	if !ok {
+		return nil, fmt.Errorf("unsupported meter type \"%s\", types are listed by the drivers command", typ)
+	}
+
+	if d.Options == nil {
+		return nil, nil
+	}
+
+	return d.Options(flags), nil
+}
+
+// Required returns the error of the required option which is not set.
+func Required(name string) error {
+	return fmt.Errorf("option \"%s\" is required", name)
+}
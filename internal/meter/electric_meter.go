@@ -4,12 +4,19 @@ import "context"
 
 type ElectricMeter interface {
 	GetPowerConsumption(ctx context.Context) (float64, error)
+	// GetTariffConsumption returns the energy registers starting from T1.
+	GetTariffConsumption(ctx context.Context) ([]float64, error)
 	GetFrequency(ctx context.Context) (float64, error)
 	GetVoltage(ctx context.Context) (float64, error)
 	GetCurrent(ctx context.Context) (float64, error)
 	GetActivePower(ctx context.Context) (float64, error)
 	GetReactivePower(ctx context.Context) (float64, error)
 	GetFullPower(ctx context.Context) (float64, error)
+	GetPowerFactor(ctx context.Context) (float64, error)
+	// GetAngle returns the angle between voltage and current in degrees.
+	GetAngle(ctx context.Context) (float64, error)
+	// GetPhases returns per-phase values starting from L1.
+	GetPhases(ctx context.Context) ([]Phase, error)
 
 	Meter
 }
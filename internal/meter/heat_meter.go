@@ -0,0 +1,13 @@
+package meter
+
+import "context"
+
+type HeatMeter interface {
+	GetSupplyTemperature(ctx context.Context) (float64, error)
+	GetReturnTemperature(ctx context.Context) (float64, error)
+	GetFlow(ctx context.Context) (float64, error)
+	GetHeatEnergy(ctx context.Context) (float64, error)
+	GetOperatingTime(ctx context.Context) (float64, error)
+
+	Meter
+}
@@ -0,0 +1,67 @@
+package iec62056
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	iec_m "github.com/lan143/metrology-master/internal/protocol/iec62056"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "IEC 62056-21 electricity meter, mode C readout",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of IEC 62056-21 meters.
+type Options struct {
+	UID  string
+	OBIS map[string]string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"device address, every device answers when empty",
+	)
+	o.OBIS = meter.ExportOBIS(flags, "obis", "data set addresses by metric")
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	return nil
+}
+
+func newMeter(env driver.Env, name string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := NewIEC62056(
+		Config{
+			Name:    name,
+			Address: options.UID,
+			OBIS:    options.OBIS,
+		},
+		iec_m.NewIEC62056(port, policy, env.Log()),
+		env.Log(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	return m, nil
+}
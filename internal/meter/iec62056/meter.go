@@ -0,0 +1,297 @@
+package iec62056
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	iec_m "github.com/lan143/metrology-master/internal/protocol/iec62056"
+	"go.uber.org/zap"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	Type string = "iec62056"
+)
+
+// readoutMaxAge lets the getters called by one update share a readout,
+// a readout takes seconds and returns every value at once.
+const readoutMaxAge = 10 * time.Second
+
+// DefaultOBIS maps metrics onto the standard OBIS codes, used when the config has no mapping.
+var DefaultOBIS = map[string]string{
+	meter.MetricPowerConsumption:                  "1.8.0",
+	meter.TariffMetric(1):                         "1.8.1",
+	meter.TariffMetric(2):                         "1.8.2",
+	meter.TariffMetric(3):                         "1.8.3",
+	meter.TariffMetric(4):                         "1.8.4",
+	meter.MetricFrequency:                         "14.7.0",
+	meter.MetricActivePower:                       "1.7.0",
+	meter.MetricPowerFactor:                       "13.7.0",
+	meter.PhaseMetric(1, meter.MetricVoltage):     "32.7.0",
+	meter.PhaseMetric(2, meter.MetricVoltage):     "52.7.0",
+	meter.PhaseMetric(3, meter.MetricVoltage):     "72.7.0",
+	meter.PhaseMetric(1, meter.MetricCurrent):     "31.7.0",
+	meter.PhaseMetric(2, meter.MetricCurrent):     "51.7.0",
+	meter.PhaseMetric(3, meter.MetricCurrent):     "71.7.0",
+	meter.PhaseMetric(1, meter.MetricActivePower): "21.7.0",
+	meter.PhaseMetric(2, meter.MetricActivePower): "41.7.0",
+	meter.PhaseMetric(3, meter.MetricActivePower): "61.7.0",
+	meter.PhaseMetric(1, meter.MetricPowerFactor): "33.7.0",
+	meter.PhaseMetric(2, meter.MetricPowerFactor): "53.7.0",
+	meter.PhaseMetric(3, meter.MetricPowerFactor): "73.7.0",
+}
+
+// serialNumberCodes are the data sets holding the serial number of the device.
+var serialNumberCodes = []string{"C.1.0", "0.0.0"}
+
+type (
+	Config struct {
+		// Name is the meter name in the config, it identifies the meter
+		// when the readout contains no serial number.
+		Name    string
+		Address string
+		// OBIS maps metrics onto data set addresses, OBIS codes or vendor names.
+		OBIS map[string]string
+	}
+
+	iec62056 struct {
+		config  Config
+		service *iec_m.IEC62056
+		log     *zap.Logger
+
+		params   meter.Params
+		tariffs  int
+		phases   int
+		values   map[string]iec_m.DataSet
+		readTime time.Time
+	}
+)
+
+func NewIEC62056(config Config, service *iec_m.IEC62056, log *zap.Logger) (meter.ElectricMeter, error) {
+	if len(config.OBIS) == 0 {
+		config.OBIS = DefaultOBIS
+	}
+
+	for metric := range config.OBIS {
+		if !meter.IsElectricMetric(metric) {
+			return nil, fmt.Errorf("unknown metric \"%s\"", metric)
+		}
+	}
+
+	return &iec62056{
+		config:  config,
+		service: service,
+		log:     log,
+	}, nil
+}
+
+func (m *iec62056) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *iec62056) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
+func (m *iec62056) Init(ctx context.Context) error {
+	ident, data, err := m.service.Readout(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	m.store(data)
+	m.buildParams(ident)
+
+	return nil
+}
+
+func (m *iec62056) GetPowerConsumption(ctx context.Context) (float64, error) {
+	if m.has(meter.MetricPowerConsumption) {
+		return m.read(ctx, meter.MetricPowerConsumption)
+	}
+
+	tariffs, err := m.GetTariffConsumption(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, value := range tariffs {
+		total += value
+	}
+
+	return total, nil
+}
+
+func (m *iec62056) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	tariffs := make([]float64, m.tariffs)
+	for i := range tariffs {
+		var err error
+
+		tariffs[i], err = m.read(ctx, meter.TariffMetric(i+1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tariffs, nil
+}
+
+func (m *iec62056) GetFrequency(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricFrequency)
+}
+
+func (m *iec62056) GetVoltage(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricVoltage)
+}
+
+func (m *iec62056) GetCurrent(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricCurrent)
+}
+
+func (m *iec62056) GetActivePower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricActivePower)
+}
+
+func (m *iec62056) GetReactivePower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricReactivePower)
+}
+
+func (m *iec62056) GetFullPower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricFullPower)
+}
+
+func (m *iec62056) GetPowerFactor(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricPowerFactor)
+}
+
+func (m *iec62056) GetAngle(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricAngle)
+}
+
+// GetPhases returns values of the per-phase data sets present in the readout, the rest are zero.
+func (m *iec62056) GetPhases(ctx context.Context) ([]meter.Phase, error) {
+	phases := make([]meter.Phase, m.phases)
+	for i := range phases {
+		values := make(map[string]float64)
+
+		for _, metric := range meter.PhaseMetrics {
+			name := meter.PhaseMetric(i+1, metric)
+			if !m.has(name) {
+				continue
+			}
+
+			value, err := m.read(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+
+			values[metric] = value
+		}
+
+		phases[i] = meter.Phase{
+			Voltage:       values[meter.MetricVoltage],
+			Current:       values[meter.MetricCurrent],
+			ActivePower:   values[meter.MetricActivePower],
+			ReactivePower: values[meter.MetricReactivePower],
+			FullPower:     values[meter.MetricFullPower],
+			PowerFactor:   values[meter.MetricPowerFactor],
+			Angle:         values[meter.MetricAngle],
+		}
+	}
+
+	return phases, nil
+}
+
+// has reports whether the metric is mapped and present in the last readout.
+func (m *iec62056) has(metric string) bool {
+	code, ok := m.config.OBIS[metric]
+	if !ok {
+		return false
+	}
+
+	_, ok = m.values[normalizeAddress(code)]
+
+	return ok
+}
+
+func (m *iec62056) read(ctx context.Context, metric string) (float64, error) {
+	code, ok := m.config.OBIS[metric]
+	if !ok {
+		return 0, fmt.Errorf("metric \"%s\" is not mapped to a data set", metric)
+	}
+
+	if time.Since(m.readTime) > readoutMaxAge {
+		_, data, err := m.service.Readout(ctx, m.config.Address)
+		if err != nil {
+			return 0, err
+		}
+
+		m.store(data)
+	}
+
+	set, ok := m.values[normalizeAddress(code)]
+	if !ok {
+		return 0, fmt.Errorf("data set \"%s\" is missing in the readout", code)
+	}
+
+	value, err := set.Float()
+	if err != nil {
+		return 0, fmt.Errorf("data set \"%s\": %s", code, err.Error())
+	}
+
+	switch strings.ToLower(set.Unit) {
+	case "kw", "kvar", "kva":
+		value *= 1000
+	case "wh", "varh":
+		value /= 1000
+	}
+
+	return math.Round(value*1000) / 1000, nil
+}
+
+func (m *iec62056) store(data []iec_m.DataSet) {
+	m.values = make(map[string]iec_m.DataSet, len(data))
+	for _, set := range data {
+		m.values[normalizeAddress(set.Address)] = set
+	}
+
+	m.readTime = time.Now()
+}
+
+func (m *iec62056) buildParams(ident iec_m.Identification) {
+	uid := m.config.Name
+	for _, code := range serialNumberCodes {
+		if set, ok := m.values[code]; ok && set.Value != "" {
+			uid = set.Value
+			break
+		}
+	}
+
+	m.tariffs, m.phases = meter.ElectricMetricCounts(m.has)
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("power-meter/%s/state", uid),
+		Manufacturer: ident.Manufacturer,
+		Model:        ident.Model,
+		Name:         ident.Manufacturer + " " + ident.Model,
+		Measurements: meter.ElectricMeasurements(m.has),
+	}
+}
+
+// normalizeAddress strips the medium and channel prefix and the billing period
+// suffix of OBIS codes: 1-0:1.8.0*255 becomes 1.8.0.
+func normalizeAddress(address string) string {
+	if i := strings.IndexByte(address, ':'); i >= 0 {
+		address = address[i+1:]
+	}
+
+	if i := strings.IndexAny(address, "*&"); i >= 0 {
+		address = address[:i]
+	}
+
+	return address
+}
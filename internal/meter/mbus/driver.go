@@ -0,0 +1,94 @@
+package mbus
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"go.uber.org/zap"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Wired M-Bus meter, the medium of the device selects the kind of meter",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of wired M-Bus meters.
+type Options struct {
+	UID        string
+	EnergyUnit string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"primary address 0..250 or secondary address, required",
+	)
+	flags.StringVar(
+		&o.EnergyUnit,
+		"energy-unit",
+		"",
+		"unit of the heat energy: kWh or Gcal",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	return nil
+}
+
+// newMeter reads the device first, the medium in its identification selects the kind of meter.
+func newMeter(env driver.Env, name string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	primary, secondary, err := mbus_m.ParseAddress(options.UID)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	source := NewSource(mbus_m.NewMBus(port, policy, env.Log()), primary, secondary)
+
+	kind, err := Probe(context.Background(), source)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	return New(kind, Config{EnergyUnit: options.EnergyUnit}, source, env.Log())
+}
+
+// New returns the wired or wireless M-Bus meter of the kind.
+func New(kind string, config Config, source Source, log *zap.Logger) (meter.Meter, error) {
+	switch kind {
+	case KindElectricity:
+		return NewElectricMeter(config, source, log), nil
+	case KindWater:
+		return NewWaterMeter(config, source, log), nil
+	case KindGas:
+		return NewGasMeter(config, source, log), nil
+	case KindHeat:
+		return NewHeatMeter(config, source, log)
+	default:
+		return nil, fmt.Errorf("unsupported meter kind \"%s\"", kind)
+	}
+}
@@ -0,0 +1,127 @@
+package mbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"go.uber.org/zap"
+	"math"
+)
+
+type electricMeter struct {
+	mbus
+
+	tariffs int
+}
+
+func NewElectricMeter(config Config, source Source, log *zap.Logger) meter.ElectricMeter {
+	return &electricMeter{
+		mbus: mbus{
+			config: config,
+			source: source,
+			log:    log,
+		},
+	}
+}
+
+func (m *electricMeter) Init(ctx context.Context) error {
+	err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	metricQuantities := map[string]mbus_m.Quantity{
+		meter.MetricPowerConsumption: mbus_m.QuantityEnergy,
+		meter.MetricActivePower:      mbus_m.QuantityPower,
+		meter.MetricVoltage:          mbus_m.QuantityVoltage,
+		meter.MetricCurrent:          mbus_m.QuantityCurrent,
+	}
+	has := func(metric string) bool {
+		if quantity, ok := metricQuantities[metric]; ok {
+			return m.has(quantity)
+		}
+
+		for tariff := 1; tariff <= meter.MaxTariffs; tariff++ {
+			if metric == meter.TariffMetric(tariff) {
+				_, ok := m.find(mbus_m.QuantityEnergy, uint64(tariff))
+
+				return ok
+			}
+		}
+
+		return false
+	}
+
+	m.tariffs, _ = meter.ElectricMetricCounts(has)
+	m.buildParams(KindElectricity, "power-meter", meter.ElectricMeasurements(has))
+
+	return nil
+}
+
+func (m *electricMeter) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
+func (m *electricMeter) GetPowerConsumption(ctx context.Context) (float64, error) {
+	return m.readRounded(ctx, mbus_m.QuantityEnergy, 0)
+}
+
+func (m *electricMeter) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	tariffs := make([]float64, m.tariffs)
+	for i := range tariffs {
+		var err error
+
+		tariffs[i], err = m.readRounded(ctx, mbus_m.QuantityEnergy, uint64(i+1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tariffs, nil
+}
+
+func (m *electricMeter) GetFrequency(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("frequency is not supported by %s", models[KindElectricity])
+}
+
+func (m *electricMeter) GetVoltage(ctx context.Context) (float64, error) {
+	return m.readRounded(ctx, mbus_m.QuantityVoltage, 0)
+}
+
+func (m *electricMeter) GetCurrent(ctx context.Context) (float64, error) {
+	return m.readRounded(ctx, mbus_m.QuantityCurrent, 0)
+}
+
+func (m *electricMeter) GetActivePower(ctx context.Context) (float64, error) {
+	return m.readRounded(ctx, mbus_m.QuantityPower, 0)
+}
+
+func (m *electricMeter) GetReactivePower(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("reactive power is not supported by %s", models[KindElectricity])
+}
+
+func (m *electricMeter) GetFullPower(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("full power is not supported by %s", models[KindElectricity])
+}
+
+func (m *electricMeter) GetPowerFactor(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("power factor is not supported by %s", models[KindElectricity])
+}
+
+func (m *electricMeter) GetAngle(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("voltage/current angle is not supported by %s", models[KindElectricity])
+}
+
+func (m *electricMeter) GetPhases(_ context.Context) ([]meter.Phase, error) {
+	return nil, fmt.Errorf("per-phase values are not supported by %s", models[KindElectricity])
+}
+
+func (m *electricMeter) readRounded(ctx context.Context, quantity mbus_m.Quantity, tariff uint64) (float64, error) {
+	value, err := m.read(ctx, quantity, tariff)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(value*1000) / 1000, nil
+}
@@ -0,0 +1,59 @@
+package mbus
+
+import (
+	"context"
+	"github.com/lan143/metrology-master/internal/meter"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"go.uber.org/zap"
+	"math"
+)
+
+// gasMeter has no getters of its own kind, it reads all its measurements at once.
+type gasMeter struct {
+	mbus
+}
+
+func NewGasMeter(config Config, source Source, log *zap.Logger) meter.Meter {
+	return &gasMeter{
+		mbus: mbus{
+			config: config,
+			source: source,
+			log:    log,
+		},
+	}
+}
+
+func (m *gasMeter) Init(ctx context.Context) error {
+	err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	has := meter.Metrics(meter.MetricVolume)
+	if m.has(mbus_m.QuantityVolumeFlow) {
+		has = meter.Metrics(meter.MetricVolume, meter.MetricFlow)
+	}
+
+	m.buildParams(KindGas, "gas-meter", meter.GasMeasurements(has))
+
+	return nil
+}
+
+func (m *gasMeter) Read(ctx context.Context) (meter.Readings, error) {
+	quantities := map[string]mbus_m.Quantity{
+		meter.MetricVolume: mbus_m.QuantityVolume,
+		meter.MetricFlow:   mbus_m.QuantityVolumeFlow,
+	}
+
+	readings := make(meter.Readings)
+	for _, measurement := range m.params.Measurements {
+		value, err := m.read(ctx, quantities[measurement.Metric], 0)
+		if err != nil {
+			return nil, err
+		}
+
+		readings[measurement.Key] = math.Round(value*1000) / 1000
+	}
+
+	return readings, nil
+}
@@ -0,0 +1,124 @@
+package mbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"go.uber.org/zap"
+	"math"
+)
+
+const (
+	EnergyUnitGcal string = "Gcal"
+	EnergyUnitKWh  string = "kWh"
+
+	kWhPerGcal float64 = 1163
+)
+
+type heatMeter struct {
+	mbus
+
+	// operatingTime is the quantity of the operating time, many meters report the on time only.
+	operatingTime mbus_m.Quantity
+}
+
+func NewHeatMeter(config Config, source Source, log *zap.Logger) (meter.HeatMeter, error) {
+	switch config.EnergyUnit {
+	case "":
+		config.EnergyUnit = EnergyUnitKWh
+	case EnergyUnitGcal, EnergyUnitKWh:
+	default:
+		return nil, fmt.Errorf("unsupported energy unit \"%s\"", config.EnergyUnit)
+	}
+
+	return &heatMeter{
+		mbus: mbus{
+			config: config,
+			source: source,
+			log:    log,
+		},
+	}, nil
+}
+
+func (m *heatMeter) Init(ctx context.Context) error {
+	err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.operatingTime = mbus_m.QuantityOperatingTime
+	if !m.has(m.operatingTime) {
+		m.operatingTime = mbus_m.QuantityOnTime
+	}
+
+	metricQuantities := map[string]mbus_m.Quantity{
+		meter.MetricHeatEnergy:        mbus_m.QuantityEnergy,
+		meter.MetricSupplyTemperature: mbus_m.QuantityFlowTemperature,
+		meter.MetricReturnTemperature: mbus_m.QuantityReturnTemperature,
+		meter.MetricFlow:              mbus_m.QuantityVolumeFlow,
+		meter.MetricOperatingTime:     m.operatingTime,
+	}
+	has := func(metric string) bool {
+		quantity, ok := metricQuantities[metric]
+
+		return ok && m.has(quantity)
+	}
+
+	m.buildParams(KindHeat, "heat-meter", meter.HeatMeasurements(has, m.config.EnergyUnit))
+
+	return nil
+}
+
+func (m *heatMeter) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadHeat(ctx, m)
+}
+
+func (m *heatMeter) GetSupplyTemperature(ctx context.Context) (float64, error) {
+	temperature, err := m.read(ctx, mbus_m.QuantityFlowTemperature, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(temperature*100) / 100, nil
+}
+
+func (m *heatMeter) GetReturnTemperature(ctx context.Context) (float64, error) {
+	temperature, err := m.read(ctx, mbus_m.QuantityReturnTemperature, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(temperature*100) / 100, nil
+}
+
+func (m *heatMeter) GetFlow(ctx context.Context) (float64, error) {
+	flow, err := m.read(ctx, mbus_m.QuantityVolumeFlow, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(flow*1000) / 1000, nil
+}
+
+func (m *heatMeter) GetHeatEnergy(ctx context.Context) (float64, error) {
+	energy, err := m.read(ctx, mbus_m.QuantityEnergy, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.config.EnergyUnit == EnergyUnitGcal {
+		return math.Round(energy/kWhPerGcal*10000) / 10000, nil
+	}
+
+	return math.Round(energy*100) / 100, nil
+}
+
+func (m *heatMeter) GetOperatingTime(ctx context.Context) (float64, error) {
+	hours, err := m.read(ctx, m.operatingTime, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(hours*100) / 100, nil
+}
@@ -0,0 +1,196 @@
+package mbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	Type string = "mbus"
+)
+
+// Kinds of meters by the medium of the device.
+const (
+	KindElectricity string = "electricity"
+	KindWater              = "water"
+	KindGas                = "gas"
+	KindHeat               = "heat"
+)
+
+// readoutMaxAge lets the getters called by one update share a response,
+// REQ_UD2 returns every record at once.
+const readoutMaxAge = 10 * time.Second
+
+var models = map[string]string{
+	KindElectricity: "M-Bus electricity meter",
+	KindWater:       "M-Bus water meter",
+	KindGas:         "M-Bus gas meter",
+	KindHeat:        "M-Bus heat meter",
+}
+
+type (
+	Config struct {
+		EnergyUnit string
+	}
+
+	// Source returns the user data of a device: read from the bus or received over the air.
+	Source interface {
+		ReadData(ctx context.Context) (mbus_m.Data, error)
+	}
+
+	// wiredSource reads the device by the primary or the secondary address.
+	wiredSource struct {
+		service   *mbus_m.MBus
+		primary   byte
+		secondary *mbus_m.SecondaryAddress
+
+		initialized bool
+	}
+
+	mbus struct {
+		config Config
+		source Source
+		log    *zap.Logger
+
+		params   meter.Params
+		data     mbus_m.Data
+		readTime time.Time
+	}
+)
+
+// Kind returns the kind of meter of the medium.
+func Kind(medium byte) (string, bool) {
+	switch medium {
+	case mbus_m.MediumElectricity:
+		return KindElectricity, true
+	case mbus_m.MediumWater, mbus_m.MediumWarmWater, mbus_m.MediumHotWater, mbus_m.MediumColdWater:
+		return KindWater, true
+	case mbus_m.MediumGas:
+		return KindGas, true
+	case mbus_m.MediumHeatOutlet, mbus_m.MediumHeatInlet, mbus_m.MediumHeatCooling:
+		return KindHeat, true
+	default:
+		return "", false
+	}
+}
+
+// NewSource returns the source reading the device on the bus, the secondary address is used when set.
+func NewSource(service *mbus_m.MBus, primary byte, secondary *mbus_m.SecondaryAddress) Source {
+	return &wiredSource{
+		service:   service,
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+// ReadData sends SND_NKE before the first read, so the frame count bit of the device
+// starts in sync, then reads the device.
+func (s *wiredSource) ReadData(ctx context.Context) (mbus_m.Data, error) {
+	if !s.initialized {
+		err := s.initialize(ctx)
+		if err != nil {
+			return mbus_m.Data{}, fmt.Errorf("initialize: %w", err)
+		}
+
+		s.initialized = true
+	}
+
+	if s.secondary != nil {
+		return s.service.ReadSecondary(ctx, *s.secondary)
+	}
+
+	return s.service.ReadData(ctx, s.primary)
+}
+
+func (s *wiredSource) initialize(ctx context.Context) error {
+	if s.secondary != nil {
+		return s.service.InitializeSecondary(ctx, *s.secondary)
+	}
+
+	return s.service.Initialize(ctx, s.primary)
+}
+
+// Probe reads the device and returns its kind of meter.
+func Probe(ctx context.Context, source Source) (string, error) {
+	data, err := source.ReadData(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	kind, ok := Kind(data.Address.Medium)
+	if !ok {
+		return "", fmt.Errorf("unsupported medium 0x%02X", data.Address.Medium)
+	}
+
+	return kind, nil
+}
+
+func (m *mbus) GetParams() meter.Params {
+	return m.params
+}
+
+// find returns the current value of the quantity: storage 0, the first subunit and
+// the instantaneous function. Tariff 0 is the total.
+func (m *mbus) find(quantity mbus_m.Quantity, tariff uint64) (mbus_m.Record, bool) {
+	for _, record := range m.data.Records {
+		if record.Quantity == quantity && record.Tariff == tariff && record.Storage == 0 &&
+			record.Subunit == 0 && record.Function == mbus_m.FunctionInstantaneous {
+			return record, true
+		}
+	}
+
+	return mbus_m.Record{}, false
+}
+
+func (m *mbus) has(quantity mbus_m.Quantity) bool {
+	_, ok := m.find(quantity, 0)
+
+	return ok
+}
+
+func (m *mbus) read(ctx context.Context, quantity mbus_m.Quantity, tariff uint64) (float64, error) {
+	if time.Since(m.readTime) > readoutMaxAge {
+		err := m.fetch(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	record, ok := m.find(quantity, tariff)
+	if !ok {
+		return 0, fmt.Errorf("no %s record of tariff %d", quantity, tariff)
+	}
+
+	return record.Value, nil
+}
+
+func (m *mbus) fetch(ctx context.Context) error {
+	data, err := m.source.ReadData(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.data = data
+	m.readTime = time.Now()
+
+	return nil
+}
+
+func (m *mbus) buildParams(kind string, topic string, measurements []meter.Measurement) {
+	uid := m.data.Address.ID
+	manufacturer := m.data.Address.ManufacturerCode()
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("%s/%s/state", topic, uid),
+		Manufacturer: manufacturer,
+		Model:        models[kind],
+		Name:         manufacturer + " " + models[kind],
+		SWVersion:    fmt.Sprintf("%d", m.data.Address.Version),
+		Measurements: measurements,
+	}
+}
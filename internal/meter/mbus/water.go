@@ -0,0 +1,70 @@
+package mbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"go.uber.org/zap"
+	"math"
+)
+
+type waterMeter struct {
+	mbus
+}
+
+func NewWaterMeter(config Config, source Source, log *zap.Logger) meter.WaterMeter {
+	return &waterMeter{
+		mbus: mbus{
+			config: config,
+			source: source,
+			log:    log,
+		},
+	}
+}
+
+func (m *waterMeter) Init(ctx context.Context) error {
+	err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	has := meter.Metrics(meter.MetricVolume)
+	if m.has(mbus_m.QuantityVolumeFlow) {
+		has = meter.Metrics(meter.MetricVolume, meter.MetricFlow)
+	}
+
+	m.buildParams(KindWater, "water-meter", meter.WaterMeasurements(has))
+
+	return nil
+}
+
+func (m *waterMeter) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadWater(ctx, m)
+}
+
+func (m *waterMeter) GetVolume(ctx context.Context) (float64, error) {
+	volume, err := m.read(ctx, mbus_m.QuantityVolume, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(volume*1000) / 1000, nil
+}
+
+func (m *waterMeter) GetFlow(ctx context.Context) (float64, error) {
+	flow, err := m.read(ctx, mbus_m.QuantityVolumeFlow, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(flow*1000) / 1000, nil
+}
+
+func (m *waterMeter) GetLeak(_ context.Context) (bool, error) {
+	return false, fmt.Errorf("leak detection is not supported by %s", models[KindWater])
+}
+
+func (m *waterMeter) GetTamper(_ context.Context) (bool, error) {
+	return false, fmt.Errorf("tamper detection is not supported by %s", models[KindWater])
+}
@@ -0,0 +1,473 @@
+package meter
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/ha/enum"
+	"strings"
+)
+
+// Measurement describes a value reported by a meter: where the value is in the state
+// and how Home Assistant shows it.
+type Measurement struct {
+	// Key is the key of the value in the state, values of a phase are nested, e.g. l1.voltage.
+	Key string
+	// Metric is the metric the value is read by, e.g. voltage, values of tariffs and
+	// phases have the metric of the meter total and the number of the tariff or phase.
+	Metric string
+	Tariff int
+	Phase  int
+	// ID names the entity in the discovery topic.
+	ID string
+	// UniqueSuffix is appended to the unique ID of the device, the main measurement has none.
+	UniqueSuffix string
+	Name         string
+	Unit         string
+	DeviceClass  enum.DeviceClass
+	StateClass   enum.StateClass
+	// Precision is the number of decimals to display.
+	Precision int
+	// Binary measurements have bool values, BinaryDeviceClass is used instead of DeviceClass.
+	Binary            bool
+	BinaryDeviceClass enum.BinarySensorDeviceClass
+	Diagnostic        bool
+}
+
+// Readings are the values of measurements by key: float64, or bool for binary measurements.
+type Readings map[string]interface{}
+
+// Metrics of water, gas and heat meters.
+const (
+	MetricVolume            string = "volume"
+	MetricFlow                     = "flow"
+	MetricLeak                     = "leak"
+	MetricTamper                   = "tamper"
+	MetricSupplyTemperature        = "supply-temperature"
+	MetricReturnTemperature        = "return-temperature"
+	MetricHeatEnergy               = "heat-energy"
+	MetricOperatingTime            = "operating-time"
+)
+
+// electricMetric is a metric of electricity meters: the measurement of the meter total,
+// its getter and the value of a phase, phase is nil for the metrics not measured per phase.
+type electricMetric struct {
+	measurement Measurement
+	get         func(m ElectricMeter, ctx context.Context) (float64, error)
+	// phaseName names the measurement of a phase after the phase, e.g. L1 voltage.
+	phaseName string
+	phase     func(p Phase) float64
+}
+
+// electricMetrics are the metrics of electricity meters in the order of their entities,
+// unnamed entities are named by Home Assistant after the device class.
+var electricMetrics = []electricMetric{
+	{
+		measurement: Measurement{
+			Key:         "powerConsumption",
+			Metric:      MetricPowerConsumption,
+			ID:          "power-consumption",
+			Unit:        "kWh",
+			DeviceClass: enum.DeviceClassEnergy,
+			StateClass:  enum.StateClassTotal,
+			Precision:   2,
+		},
+		get: ElectricMeter.GetPowerConsumption,
+	},
+	{
+		measurement: Measurement{
+			Key:          "frequency",
+			Metric:       MetricFrequency,
+			ID:           "frequency",
+			UniqueSuffix: "_frequency",
+			Unit:         "Hz",
+			DeviceClass:  enum.DeviceClassFrequency,
+			StateClass:   enum.StateClassMeasurement,
+			Precision:    2,
+		},
+		get: ElectricMeter.GetFrequency,
+	},
+	{
+		measurement: Measurement{
+			Key:          "voltage",
+			Metric:       MetricVoltage,
+			ID:           "voltage",
+			UniqueSuffix: "_voltage",
+			Unit:         "V",
+			DeviceClass:  enum.DeviceClassVoltage,
+			StateClass:   enum.StateClassMeasurement,
+			Precision:    1,
+		},
+		get:       ElectricMeter.GetVoltage,
+		phaseName: "voltage",
+		phase:     func(p Phase) float64 { return p.Voltage },
+	},
+	{
+		measurement: Measurement{
+			Key:          "current",
+			Metric:       MetricCurrent,
+			ID:           "current",
+			UniqueSuffix: "_current",
+			Unit:         "A",
+			DeviceClass:  enum.DeviceClassCurrent,
+			StateClass:   enum.StateClassMeasurement,
+			Precision:    2,
+		},
+		get:       ElectricMeter.GetCurrent,
+		phaseName: "current",
+		phase:     func(p Phase) float64 { return p.Current },
+	},
+	{
+		measurement: Measurement{
+			Key:          "activePower",
+			Metric:       MetricActivePower,
+			ID:           "active_power",
+			UniqueSuffix: "_active_power",
+			Name:         "Active power",
+			Unit:         "W",
+			DeviceClass:  enum.DeviceClassPower,
+			StateClass:   enum.StateClassMeasurement,
+		},
+		get:       ElectricMeter.GetActivePower,
+		phaseName: "active power",
+		phase:     func(p Phase) float64 { return p.ActivePower },
+	},
+	{
+		measurement: Measurement{
+			Key:          "reactivePower",
+			Metric:       MetricReactivePower,
+			ID:           "reactive_power",
+			UniqueSuffix: "_reactive_power",
+			Name:         "Reactive power",
+			Unit:         "VAr",
+			DeviceClass:  enum.DeviceClassPower,
+			StateClass:   enum.StateClassMeasurement,
+		},
+		get:       ElectricMeter.GetReactivePower,
+		phaseName: "reactive power",
+		phase:     func(p Phase) float64 { return p.ReactivePower },
+	},
+	{
+		measurement: Measurement{
+			Key:          "fullPower",
+			Metric:       MetricFullPower,
+			ID:           "full_power",
+			UniqueSuffix: "_full_power",
+			Name:         "Full power",
+			Unit:         "VA",
+			DeviceClass:  enum.DeviceClassPower,
+			StateClass:   enum.StateClassMeasurement,
+		},
+		get:       ElectricMeter.GetFullPower,
+		phaseName: "full power",
+		phase:     func(p Phase) float64 { return p.FullPower },
+	},
+	{
+		measurement: Measurement{
+			Key:          "powerFactor",
+			Metric:       MetricPowerFactor,
+			ID:           "power_factor",
+			UniqueSuffix: "_power_factor",
+			Name:         "Power factor",
+			DeviceClass:  enum.DeviceClassPowerFactor,
+			StateClass:   enum.StateClassMeasurement,
+			Precision:    3,
+		},
+		get:       ElectricMeter.GetPowerFactor,
+		phaseName: "power factor",
+		phase:     func(p Phase) float64 { return p.PowerFactor },
+	},
+	{
+		measurement: Measurement{
+			Key:          "angle",
+			Metric:       MetricAngle,
+			ID:           "angle",
+			UniqueSuffix: "_angle",
+			Name:         "Voltage/current angle",
+			Unit:         "°",
+			StateClass:   enum.StateClassMeasurement,
+			Precision:    1,
+		},
+		get:       ElectricMeter.GetAngle,
+		phaseName: "angle",
+		phase:     func(p Phase) float64 { return p.Angle },
+	},
+}
+
+// ElectricMeasurements returns the measurements of a meter which provides the metrics has
+// reports true for. The total energy is the sum of tariffs if the meter has no total register.
+func ElectricMeasurements(has func(metric string) bool) []Measurement {
+	var result []Measurement
+
+	for _, metric := range electricMetrics {
+		measurement := metric.measurement
+		if has(measurement.Metric) || (measurement.Metric == MetricPowerConsumption && has(TariffMetric(1))) {
+			result = append(result, measurement)
+		}
+	}
+
+	total, _ := findElectricMetric(MetricPowerConsumption)
+	for tariff := 1; tariff <= MaxTariffs && has(TariffMetric(tariff)); tariff++ {
+		measurement := total.measurement
+		measurement.Key = fmt.Sprintf("powerConsumptionT%d", tariff)
+		measurement.ID = fmt.Sprintf("power-consumption-t%d", tariff)
+		measurement.UniqueSuffix = fmt.Sprintf("_t%d", tariff)
+		measurement.Name = fmt.Sprintf("Tariff %d", tariff)
+		measurement.Tariff = tariff
+
+		result = append(result, measurement)
+	}
+
+	for phase := 1; phase <= MaxPhases; phase++ {
+		for _, metric := range electricMetrics {
+			if metric.phase == nil || !has(PhaseMetric(phase, metric.measurement.Metric)) {
+				continue
+			}
+
+			measurement := metric.measurement
+			measurement.Key = fmt.Sprintf("l%d.%s", phase, metric.measurement.Key)
+			measurement.ID = fmt.Sprintf("l%d_%s", phase, toSnakeCase(metric.measurement.Key))
+			measurement.UniqueSuffix = "_" + measurement.ID
+			measurement.Name = fmt.Sprintf("L%d %s", phase, metric.phaseName)
+			measurement.Phase = phase
+
+			result = append(result, measurement)
+		}
+	}
+
+	return result
+}
+
+// waterMetric is a metric of water meters: its measurement and getter.
+type waterMetric struct {
+	measurement Measurement
+	get         func(m WaterMeter, ctx context.Context) (interface{}, error)
+}
+
+var waterMetrics = []waterMetric{
+	{
+		measurement: Measurement{
+			Key:         "volume",
+			Metric:      MetricVolume,
+			ID:          "volume",
+			Unit:        "m³",
+			DeviceClass: enum.DeviceClassWater,
+			StateClass:  enum.StateClassTotalIncreasing,
+			Precision:   3,
+		},
+		get: func(m WaterMeter, ctx context.Context) (interface{}, error) { return m.GetVolume(ctx) },
+	},
+	{
+		measurement: flowMeasurement,
+		get:         func(m WaterMeter, ctx context.Context) (interface{}, error) { return m.GetFlow(ctx) },
+	},
+	{
+		measurement: Measurement{
+			Key:               "leak",
+			Metric:            MetricLeak,
+			ID:                "leak",
+			UniqueSuffix:      "_leak",
+			Name:              "Leak",
+			Binary:            true,
+			BinaryDeviceClass: enum.BinarySensorDeviceClassMoisture,
+		},
+		get: func(m WaterMeter, ctx context.Context) (interface{}, error) { return m.GetLeak(ctx) },
+	},
+	{
+		measurement: Measurement{
+			Key:               "tamper",
+			Metric:            MetricTamper,
+			ID:                "tamper",
+			UniqueSuffix:      "_tamper",
+			Name:              "Tamper",
+			Binary:            true,
+			BinaryDeviceClass: enum.BinarySensorDeviceClassTamper,
+			Diagnostic:        true,
+		},
+		get: func(m WaterMeter, ctx context.Context) (interface{}, error) { return m.GetTamper(ctx) },
+	},
+}
+
+// WaterMeasurements returns the measurements of a water meter which provides the metrics has reports true for.
+func WaterMeasurements(has func(metric string) bool) []Measurement {
+	var result []Measurement
+	for _, metric := range waterMetrics {
+		if has(metric.measurement.Metric) {
+			result = append(result, metric.measurement)
+		}
+	}
+
+	return result
+}
+
+var gasMeasurements = []Measurement{
+	{
+		Key:         "volume",
+		Metric:      MetricVolume,
+		ID:          "volume",
+		Unit:        "m³",
+		DeviceClass: enum.DeviceClassGas,
+		StateClass:  enum.StateClassTotalIncreasing,
+		Precision:   3,
+	},
+	flowMeasurement,
+}
+
+// GasMeasurements returns the measurements of a gas meter which provides the metrics has reports true for.
+func GasMeasurements(has func(metric string) bool) []Measurement {
+	var result []Measurement
+	for _, measurement := range gasMeasurements {
+		if has(measurement.Metric) {
+			result = append(result, measurement)
+		}
+	}
+
+	return result
+}
+
+// heatMetric is a metric of heat meters: its measurement and getter.
+type heatMetric struct {
+	measurement Measurement
+	get         func(m HeatMeter, ctx context.Context) (float64, error)
+}
+
+var heatMetrics = []heatMetric{
+	{
+		measurement: temperatureMeasurement(MetricSupplyTemperature, "supply", "Supply temperature"),
+		get:         HeatMeter.GetSupplyTemperature,
+	},
+	{
+		measurement: temperatureMeasurement(MetricReturnTemperature, "return", "Return temperature"),
+		get:         HeatMeter.GetReturnTemperature,
+	},
+	{
+		measurement: flowMeasurement,
+		get:         HeatMeter.GetFlow,
+	},
+	{
+		measurement: Measurement{
+			Key:         "heatEnergy",
+			Metric:      MetricHeatEnergy,
+			ID:          "heat_energy",
+			DeviceClass: enum.DeviceClassEnergy,
+			StateClass:  enum.StateClassTotalIncreasing,
+		},
+		get: HeatMeter.GetHeatEnergy,
+	},
+	{
+		measurement: Measurement{
+			Key:          "operatingTime",
+			Metric:       MetricOperatingTime,
+			ID:           "operating_time",
+			UniqueSuffix: "_operating_time",
+			Name:         "Operating time",
+			Unit:         "h",
+			DeviceClass:  enum.DeviceClassDuration,
+			StateClass:   enum.StateClassTotalIncreasing,
+			Diagnostic:   true,
+		},
+		get: HeatMeter.GetOperatingTime,
+	},
+}
+
+// HeatMeasurements returns the measurements of a heat meter which provides the metrics has reports true for,
+// heat energy is measured in kWh or Gcal.
+func HeatMeasurements(has func(metric string) bool, energyUnit string) []Measurement {
+	var result []Measurement
+	for _, metric := range heatMetrics {
+		measurement := metric.measurement
+		if !has(measurement.Metric) {
+			continue
+		}
+
+		if measurement.Metric == MetricHeatEnergy {
+			measurement.Unit = energyUnit
+			measurement.Precision = 2
+			if energyUnit == "Gcal" {
+				measurement.Precision = 4
+			}
+		}
+
+		result = append(result, measurement)
+	}
+
+	return result
+}
+
+// ChannelMeasurement returns the measurement of a counter of a multi-channel meter.
+func ChannelMeasurement(key string, name string, unit string, kind string) (Measurement, error) {
+	var deviceClass enum.DeviceClass
+	switch kind {
+	case ChannelKindWater:
+		deviceClass = enum.DeviceClassWater
+	case ChannelKindGas:
+		deviceClass = enum.DeviceClassGas
+	case ChannelKindElectricity:
+		deviceClass = enum.DeviceClassEnergy
+	default:
+		return Measurement{}, fmt.Errorf("unsupported channel kind \"%s\"", kind)
+	}
+
+	return Measurement{
+		Key:          key,
+		Metric:       key,
+		ID:           "channel_" + key,
+		UniqueSuffix: "_" + key,
+		Name:         name,
+		Unit:         unit,
+		DeviceClass:  deviceClass,
+		StateClass:   enum.StateClassTotalIncreasing,
+		Precision:    3,
+	}, nil
+}
+
+// Metrics returns the has function of the listed metrics.
+func Metrics(metrics ...string) func(metric string) bool {
+	set := make(map[string]bool, len(metrics))
+	for _, metric := range metrics {
+		set[metric] = true
+	}
+
+	return func(metric string) bool {
+		return set[metric]
+	}
+}
+
+var flowMeasurement = Measurement{
+	Key:          "flow",
+	Metric:       MetricFlow,
+	ID:           "flow",
+	UniqueSuffix: "_flow",
+	Name:         "Flow",
+	Unit:         "m³/h",
+	DeviceClass:  enum.DeviceClassVolumeFlowRate,
+	StateClass:   enum.StateClassMeasurement,
+	Precision:    3,
+}
+
+func temperatureMeasurement(metric string, kind string, name string) Measurement {
+	return Measurement{
+		Key:          kind + "Temperature",
+		Metric:       metric,
+		ID:           kind + "_temperature",
+		UniqueSuffix: "_" + kind + "_temperature",
+		Name:         name,
+		Unit:         "°C",
+		DeviceClass:  enum.DeviceClassTemperature,
+		StateClass:   enum.StateClassMeasurement,
+		Precision:    1,
+	}
+}
+
+// toSnakeCase converts a state key like activePower to active_power.
+func toSnakeCase(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package mercury_206
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	mercury_m "github.com/lan143/metrology-master/internal/protocol/mercury"
+	"strconv"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Mercury 206 single-phase electricity meter",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of Mercury 206 meters.
+type Options struct {
+	UID string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"serial number, required",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, _ string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := strconv.ParseUint(options.UID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("parse address \"%s\": %s", options.UID, err.Error())
+	}
+
+	return NewMercury206(
+		Config{
+			Address:           uint32(address),
+			TimeSyncThreshold: config.TimeSyncThreshold,
+		},
+		mercury_m.NewMercury206(port, policy, env.Log()),
+		env.Log(),
+	), nil
+}
@@ -0,0 +1,199 @@
+package mercury_206
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mercury_m "github.com/lan143/metrology-master/internal/protocol/mercury"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	Type         string = "mercury_206"
+	manufacturer string = "Инкотекс"
+	model        string = "Меркурий 206"
+)
+
+const (
+	tariffs = 4
+)
+
+type (
+	Config struct {
+		// Address is the serial number of the meter.
+		Address           uint32
+		TimeSyncThreshold time.Duration
+	}
+
+	mercury206 struct {
+		config  Config
+		service *mercury_m.Mercury206
+		log     *zap.Logger
+
+		params meter.Params
+	}
+)
+
+type Mercury206 interface {
+	meter.ElectricMeter
+	meter.TimeSyncer
+}
+
+func NewMercury206(config Config, service *mercury_m.Mercury206, log *zap.Logger) Mercury206 {
+	return &mercury206{
+		config:  config,
+		service: service,
+		log:     log,
+	}
+}
+
+func (m *mercury206) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *mercury206) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
+func (m *mercury206) Init(ctx context.Context) error {
+	_, err := m.service.ReadTime(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	m.buildParams()
+
+	return nil
+}
+
+func (m *mercury206) SyncTime(ctx context.Context) error {
+	deviceTime, err := m.service.ReadTime(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	drift := now.Sub(deviceTime)
+	if drift < 0 {
+		drift = -drift
+	}
+
+	m.log.Debug(
+		"check time drift",
+		zap.String("uid", m.params.UID),
+		zap.Time("device", deviceTime),
+		zap.Time("host", now),
+		zap.Duration("drift", drift),
+	)
+
+	if drift <= m.config.TimeSyncThreshold {
+		return nil
+	}
+
+	err = m.service.WriteTime(ctx, m.config.Address, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.log.Info(
+		"device time corrected",
+		zap.String("uid", m.params.UID),
+		zap.Duration("drift", drift),
+	)
+
+	return nil
+}
+
+func (m *mercury206) GetPowerConsumption(ctx context.Context) (float64, error) {
+	values, err := m.GetTariffConsumption(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+
+	return math.Round(total*100) / 100, nil
+}
+
+func (m *mercury206) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	return m.service.ReadEnergy(ctx, m.config.Address)
+}
+
+func (m *mercury206) GetFrequency(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("frequency is not supported by %s", model)
+}
+
+func (m *mercury206) GetVoltage(ctx context.Context) (float64, error) {
+	instant, err := m.service.ReadInstant(ctx, m.config.Address)
+	if err != nil {
+		return 0, err
+	}
+
+	return instant.Voltage, nil
+}
+
+func (m *mercury206) GetCurrent(ctx context.Context) (float64, error) {
+	instant, err := m.service.ReadInstant(ctx, m.config.Address)
+	if err != nil {
+		return 0, err
+	}
+
+	return instant.Current, nil
+}
+
+func (m *mercury206) GetActivePower(ctx context.Context) (float64, error) {
+	instant, err := m.service.ReadInstant(ctx, m.config.Address)
+	if err != nil {
+		return 0, err
+	}
+
+	return instant.ActivePower, nil
+}
+
+func (m *mercury206) GetReactivePower(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("reactive power is not supported by %s", model)
+}
+
+func (m *mercury206) GetFullPower(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("full power is not supported by %s", model)
+}
+
+func (m *mercury206) GetPowerFactor(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("power factor is not supported by %s", model)
+}
+
+func (m *mercury206) GetAngle(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("voltage/current angle is not supported by %s", model)
+}
+
+func (m *mercury206) GetPhases(_ context.Context) ([]meter.Phase, error) {
+	return nil, fmt.Errorf("per-phase values are not supported by %s", model)
+}
+
+func (m *mercury206) buildParams() {
+	uid := fmt.Sprintf("%08d", m.config.Address)
+
+	metrics := []string{
+		meter.MetricPowerConsumption,
+		meter.MetricVoltage,
+		meter.MetricCurrent,
+		meter.MetricActivePower,
+	}
+	for tariff := 1; tariff <= tariffs; tariff++ {
+		metrics = append(metrics, meter.TariffMetric(tariff))
+	}
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("power-meter/%s/state", uid),
+		Manufacturer: manufacturer,
+		Model:        model,
+		Name:         manufacturer + " " + model,
+		Measurements: meter.ElectricMeasurements(meter.Metrics(metrics...)),
+	}
+}
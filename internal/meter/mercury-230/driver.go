@@ -0,0 +1,96 @@
+package mercury_230
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	mercury_m "github.com/lan143/metrology-master/internal/protocol/mercury"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Mercury 230 three-phase electricity meter",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of Mercury 230 meters.
+type Options struct {
+	UID         string
+	AccessLevel int
+	Password    string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"network address 1..240, required",
+	)
+	flags.IntVar(
+		&o.AccessLevel,
+		"access-level",
+		1,
+		"session access level: 1 user, 2 admin",
+	)
+	flags.StringVar(
+		&o.Password,
+		"password",
+		"",
+		"password of the access level, 6 times the level digit by default",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	level := mercury_m.AccessLevel(o.AccessLevel)
+	if level != mercury_m.AccessLevelUser && level != mercury_m.AccessLevelAdmin {
+		return fmt.Errorf("invalid access level %d", o.AccessLevel)
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, _ string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := strconv.ParseUint(options.UID, 0, 8)
+	if err != nil {
+		return nil, fmt.Errorf("parse address \"%s\": %s", options.UID, err.Error())
+	}
+
+	password := options.Password
+	if password == "" {
+		password = strings.Repeat(strconv.Itoa(options.AccessLevel), 6)
+	}
+
+	return NewMercury230(
+		Config{
+			Address:           byte(address),
+			AccessLevel:       mercury_m.AccessLevel(options.AccessLevel),
+			Password:          password,
+			TimeSyncThreshold: config.TimeSyncThreshold,
+		},
+		mercury_m.NewMercury230(port, policy, env.Log()),
+		env.Log(),
+	), nil
+}
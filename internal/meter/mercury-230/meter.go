@@ -0,0 +1,290 @@
+package mercury_230
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mercury_m "github.com/lan143/metrology-master/internal/protocol/mercury"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	Type         string = "mercury_230"
+	manufacturer string = "Инкотекс"
+	model        string = "Меркурий 230"
+)
+
+const (
+	tariffs = 4
+	phases  = 3
+)
+
+type (
+	Config struct {
+		Address     byte
+		AccessLevel mercury_m.AccessLevel
+		Password    string
+		// TimeSyncThreshold requires the admin access level.
+		TimeSyncThreshold time.Duration
+	}
+
+	mercury230 struct {
+		config  Config
+		service *mercury_m.Mercury230
+		log     *zap.Logger
+
+		params meter.Params
+	}
+)
+
+type Mercury230 interface {
+	meter.ElectricMeter
+	meter.TimeSyncer
+}
+
+func NewMercury230(config Config, service *mercury_m.Mercury230, log *zap.Logger) Mercury230 {
+	return &mercury230{
+		config:  config,
+		service: service,
+		log:     log,
+	}
+}
+
+func (m *mercury230) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *mercury230) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
+func (m *mercury230) Init(ctx context.Context) error {
+	err := m.openChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.buildParams(ctx)
+}
+
+func (m *mercury230) SyncTime(ctx context.Context) error {
+	var deviceTime time.Time
+	err := m.call(ctx, func() error {
+		var err error
+		deviceTime, err = m.service.ReadTime(ctx, m.config.Address)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	drift := now.Sub(deviceTime)
+	if drift < 0 {
+		drift = -drift
+	}
+
+	m.log.Debug(
+		"check time drift",
+		zap.String("uid", m.params.UID),
+		zap.Time("device", deviceTime),
+		zap.Time("host", now),
+		zap.Duration("drift", drift),
+	)
+
+	if drift <= m.config.TimeSyncThreshold {
+		return nil
+	}
+
+	err = m.call(ctx, func() error {
+		return m.service.WriteTime(ctx, m.config.Address, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+
+	m.log.Info(
+		"device time corrected",
+		zap.String("uid", m.params.UID),
+		zap.Duration("drift", drift),
+	)
+
+	return nil
+}
+
+func (m *mercury230) GetPowerConsumption(ctx context.Context) (float64, error) {
+	energy, err := m.readEnergy(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return energy.ActiveImport, nil
+}
+
+func (m *mercury230) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	values := make([]float64, tariffs)
+	for i := range values {
+		energy, err := m.readEnergy(ctx, i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = energy.ActiveImport
+	}
+
+	return values, nil
+}
+
+func (m *mercury230) GetFrequency(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxFrequency)
+}
+
+// GetVoltage returns the voltage of L1, per-phase values are returned by GetPhases.
+func (m *mercury230) GetVoltage(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxVoltage+1)
+}
+
+// GetCurrent returns the current of L1, per-phase values are returned by GetPhases.
+func (m *mercury230) GetCurrent(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxCurrent+1)
+}
+
+func (m *mercury230) GetActivePower(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxActivePower)
+}
+
+func (m *mercury230) GetReactivePower(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxReactivePower)
+}
+
+func (m *mercury230) GetFullPower(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxFullPower)
+}
+
+func (m *mercury230) GetPowerFactor(ctx context.Context) (float64, error) {
+	return m.readAux(ctx, mercury_m.AuxPowerFactor)
+}
+
+func (m *mercury230) GetAngle(_ context.Context) (float64, error) {
+	return 0, fmt.Errorf("voltage/current angle is not supported by %s", model)
+}
+
+func (m *mercury230) GetPhases(ctx context.Context) ([]meter.Phase, error) {
+	result := make([]meter.Phase, phases)
+	for i := range result {
+		phase := byte(i + 1)
+
+		values := []struct {
+			param byte
+			value *float64
+		}{
+			{mercury_m.AuxVoltage + phase, &result[i].Voltage},
+			{mercury_m.AuxCurrent + phase, &result[i].Current},
+			{mercury_m.AuxActivePower + phase, &result[i].ActivePower},
+			{mercury_m.AuxReactivePower + phase, &result[i].ReactivePower},
+			{mercury_m.AuxFullPower + phase, &result[i].FullPower},
+			{mercury_m.AuxPowerFactor + phase, &result[i].PowerFactor},
+		}
+		for _, v := range values {
+			var err error
+
+			*v.value, err = m.readAux(ctx, v.param)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (m *mercury230) readEnergy(ctx context.Context, tariff int) (mercury_m.Energy, error) {
+	var energy mercury_m.Energy
+	err := m.call(ctx, func() error {
+		var err error
+		energy, err = m.service.ReadEnergy(ctx, m.config.Address, tariff)
+
+		return err
+	})
+
+	return energy, err
+}
+
+func (m *mercury230) readAux(ctx context.Context, param byte) (float64, error) {
+	var value float64
+	err := m.call(ctx, func() error {
+		var err error
+		value, err = m.service.ReadAux(ctx, m.config.Address, param)
+
+		return err
+	})
+
+	return value, err
+}
+
+// call executes fn and reopens the channel once if the device has closed it.
+func (m *mercury230) call(ctx context.Context, fn func() error) error {
+	err := fn()
+	if !errors.Is(err, mercury_m.ErrChannelNotOpen) {
+		return err
+	}
+
+	m.log.Debug("reopen channel", zap.String("uid", m.params.UID))
+
+	err = m.openChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+func (m *mercury230) openChannel(ctx context.Context) error {
+	err := m.service.OpenChannel(ctx, m.config.Address, m.config.AccessLevel, m.config.Password)
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mercury230) buildParams(ctx context.Context) error {
+	serial, _, err := m.service.ReadSerialNumber(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	metrics := []string{
+		meter.MetricPowerConsumption,
+		meter.MetricFrequency,
+		meter.MetricActivePower,
+		meter.MetricReactivePower,
+		meter.MetricFullPower,
+		meter.MetricPowerFactor,
+	}
+	for tariff := 1; tariff <= tariffs; tariff++ {
+		metrics = append(metrics, meter.TariffMetric(tariff))
+	}
+
+	for phase := 1; phase <= phases; phase++ {
+		for _, metric := range meter.PhaseMetrics {
+			if metric != meter.MetricAngle {
+				metrics = append(metrics, meter.PhaseMetric(phase, metric))
+			}
+		}
+	}
+
+	m.params = meter.Params{
+		UID:          serial,
+		StateTopic:   fmt.Sprintf("power-meter/%s/state", serial),
+		Manufacturer: manufacturer,
+		Model:        model,
+		Name:         manufacturer + " " + model,
+		Measurements: meter.ElectricMeasurements(meter.Metrics(metrics...)),
+	}
+
+	return nil
+}
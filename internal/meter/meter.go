@@ -5,4 +5,6 @@ import "context"
 type Meter interface {
 	Init(ctx context.Context) error
 	GetParams() Params
+	// Read returns the readings of the measurements declared in the params.
+	Read(ctx context.Context) (Readings, error)
 }
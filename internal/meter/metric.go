@@ -0,0 +1,93 @@
+package meter
+
+import (
+	"fmt"
+)
+
+// Metrics of an electricity meter used by drivers which map device values,
+// e.g. registers or OBIS codes, onto the ElectricMeter getters. Per-phase metrics
+// are prefixed with the phase, e.g. l1-voltage, tariff registers are power-consumption-t1..t4.
+const (
+	MetricPowerConsumption string = "power-consumption"
+	MetricFrequency               = "frequency"
+	MetricVoltage                 = "voltage"
+	MetricCurrent                 = "current"
+	MetricActivePower             = "active-power"
+	MetricReactivePower           = "reactive-power"
+	MetricFullPower               = "full-power"
+	MetricPowerFactor             = "power-factor"
+	MetricAngle                   = "angle"
+)
+
+const (
+	MaxTariffs = 4
+	MaxPhases  = 3
+)
+
+// PhaseMetrics are the metrics available per phase.
+var PhaseMetrics = []string{
+	MetricVoltage,
+	MetricCurrent,
+	MetricActivePower,
+	MetricReactivePower,
+	MetricFullPower,
+	MetricPowerFactor,
+	MetricAngle,
+}
+
+func TariffMetric(tariff int) string {
+	return fmt.Sprintf("%s-t%d", MetricPowerConsumption, tariff)
+}
+
+func PhaseMetric(phase int, metric string) string {
+	return fmt.Sprintf("l%d-%s", phase, metric)
+}
+
+func IsElectricMetric(metric string) bool {
+	switch metric {
+	case MetricPowerConsumption, MetricFrequency:
+		return true
+	}
+
+	for _, name := range PhaseMetrics {
+		if metric == name {
+			return true
+		}
+	}
+
+	for i := 1; i <= MaxTariffs; i++ {
+		if metric == TariffMetric(i) {
+			return true
+		}
+	}
+
+	for i := 1; i <= MaxPhases; i++ {
+		for _, name := range PhaseMetrics {
+			if metric == PhaseMetric(i, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ElectricMetricCounts returns the number of tariffs and phases of a meter
+// which provides the metrics has reports true for.
+func ElectricMetricCounts(has func(metric string) bool) (int, int) {
+	var tariffs int
+	for tariffs < MaxTariffs && has(TariffMetric(tariffs+1)) {
+		tariffs++
+	}
+
+	var phases int
+	for i := 1; i <= MaxPhases; i++ {
+		for _, metric := range PhaseMetrics {
+			if has(PhaseMetric(i, metric)) {
+				phases = i
+			}
+		}
+	}
+
+	return tariffs, phases
+}
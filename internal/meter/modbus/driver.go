@@ -0,0 +1,149 @@
+package modbus
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	modbus_m "github.com/lan143/metrology-master/internal/protocol/modbus"
+)
+
+const (
+	tcpPort = "502"
+
+	// ModelsSection is the config section of the register maps referenced by meters.
+	ModelsSection = "modbus-models"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Modbus RTU or TCP electricity meter described by a model",
+		Transport:   driver.TransportSerialOrTCP,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of Modbus meters.
+type Options struct {
+	Model  string
+	UnitID int
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.Model,
+		"model",
+		"",
+		"name of the register map in modbus-models, required",
+	)
+	flags.IntVar(
+		&o.UnitID,
+		"unit-id",
+		1,
+		"unit id 1..247, 0..255 behind a TCP gateway",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.Model == "" {
+		return driver.Required("model")
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, name string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	service, err := newService(env, config)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, _ := env.Resource(ModelsSection, options.Model)
+	model, ok := resource.(*meter.ModelConfig)
+	if !ok || model == nil {
+		return nil, fmt.Errorf("meter \"%s\": modbus model \"%s\" not found", name, options.Model)
+	}
+
+	maxUnitID := 247
+	if config.Host != "" {
+		// gateways pass 0 and 255 to the device itself
+		maxUnitID = 255
+	}
+
+	if options.UnitID < 0 || options.UnitID > maxUnitID || config.Host == "" && options.UnitID == 0 {
+		return nil, fmt.Errorf("meter \"%s\": invalid unit id %d", name, options.UnitID)
+	}
+
+	registers := make(map[string]Register, len(model.Registers))
+	keys := make(map[string]string, len(model.Registers))
+	for key, register := range model.Registers {
+		metric := register.Metric
+		if metric == "" {
+			metric = key
+		}
+
+		if other, ok := keys[metric]; ok {
+			return nil, fmt.Errorf(
+				"meter \"%s\": registers \"%s\" and \"%s\" map the same metric \"%s\"",
+				name, other, key, metric,
+			)
+		}
+		keys[metric] = key
+
+		if register.Address < 0 || register.Address > 0xFFFF {
+			return nil, fmt.Errorf("meter \"%s\": register \"%s\": invalid address %d", name, key, register.Address)
+		}
+
+		registers[metric] = Register{
+			Function:  register.Function,
+			Address:   uint16(register.Address),
+			Type:      modbus_m.DataType(register.Type),
+			WordOrder: modbus_m.WordOrder(register.WordOrder),
+			Scale:     register.Scale,
+		}
+	}
+
+	m, err := NewModbusMeter(
+		Config{
+			Name:         name,
+			Unit:         byte(options.UnitID),
+			Manufacturer: model.Manufacturer,
+			Model:        model.Model,
+			Registers:    registers,
+		},
+		service,
+		env.Log(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	return m, nil
+}
+
+// newService returns the Modbus TCP client of the host, or the RTU client of the port without one.
+func newService(env driver.Env, config *meter.Config) (*modbus_m.Modbus, error) {
+	if config.Host == "" {
+		port, policy, err := env.Port(config)
+		if err != nil {
+			return nil, err
+		}
+
+		return modbus_m.NewModbus(port, policy, env.Log()), nil
+	}
+
+	host, policy, err := env.Host(config, tcpPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return modbus_m.NewModbusTCP(host, policy, env.Log()), nil
+}
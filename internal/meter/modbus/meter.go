@@ -0,0 +1,252 @@
+package modbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	modbus_m "github.com/lan143/metrology-master/internal/protocol/modbus"
+	"go.uber.org/zap"
+	"math"
+)
+
+const (
+	Type string = "modbus"
+)
+
+const (
+	FunctionHolding string = "holding"
+	FunctionInput          = "input"
+)
+
+type (
+	Register struct {
+		Function  string
+		Address   uint16
+		Type      modbus_m.DataType
+		WordOrder modbus_m.WordOrder
+		Scale     float64
+	}
+
+	Config struct {
+		// Name is the meter name in the config, it identifies the meter as
+		// unit ids are not unique across ports.
+		Name         string
+		Unit         byte
+		Manufacturer string
+		Model        string
+		Registers    map[string]Register
+	}
+
+	modbusMeter struct {
+		config  Config
+		service *modbus_m.Modbus
+		log     *zap.Logger
+
+		params  meter.Params
+		tariffs int
+		phases  int
+	}
+)
+
+func NewModbusMeter(config Config, service *modbus_m.Modbus, log *zap.Logger) (meter.ElectricMeter, error) {
+	if len(config.Registers) == 0 {
+		return nil, fmt.Errorf("no registers")
+	}
+
+	for metric, register := range config.Registers {
+		if !meter.IsElectricMetric(metric) {
+			return nil, fmt.Errorf("unknown metric \"%s\"", metric)
+		}
+
+		if register.Function != FunctionHolding && register.Function != FunctionInput {
+			return nil, fmt.Errorf("metric \"%s\": unsupported function \"%s\"", metric, register.Function)
+		}
+
+		if register.WordOrder != modbus_m.WordOrderBig && register.WordOrder != modbus_m.WordOrderLittle {
+			return nil, fmt.Errorf("metric \"%s\": unsupported word order \"%s\"", metric, register.WordOrder)
+		}
+
+		_, err := register.Type.Registers()
+		if err != nil {
+			return nil, fmt.Errorf("metric \"%s\": %s", metric, err.Error())
+		}
+	}
+
+	return &modbusMeter{
+		config:  config,
+		service: service,
+		log:     log,
+	}, nil
+}
+
+func (m *modbusMeter) Init(_ context.Context) error {
+	m.buildParams()
+
+	m.log.Debug(
+		"init modbus meter",
+		zap.String("name", m.config.Name),
+		zap.Uint8("unit", m.config.Unit),
+		zap.Any("params", m.params),
+	)
+
+	return nil
+}
+
+func (m *modbusMeter) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *modbusMeter) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
+func (m *modbusMeter) GetPowerConsumption(ctx context.Context) (float64, error) {
+	if m.has(meter.MetricPowerConsumption) {
+		return m.read(ctx, meter.MetricPowerConsumption)
+	}
+
+	tariffs, err := m.GetTariffConsumption(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, value := range tariffs {
+		total += value
+	}
+
+	return total, nil
+}
+
+func (m *modbusMeter) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	tariffs := make([]float64, m.tariffs)
+	for i := range tariffs {
+		var err error
+
+		tariffs[i], err = m.read(ctx, meter.TariffMetric(i+1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tariffs, nil
+}
+
+func (m *modbusMeter) GetFrequency(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricFrequency)
+}
+
+func (m *modbusMeter) GetVoltage(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricVoltage)
+}
+
+func (m *modbusMeter) GetCurrent(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricCurrent)
+}
+
+func (m *modbusMeter) GetActivePower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricActivePower)
+}
+
+func (m *modbusMeter) GetReactivePower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricReactivePower)
+}
+
+func (m *modbusMeter) GetFullPower(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricFullPower)
+}
+
+func (m *modbusMeter) GetPowerFactor(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricPowerFactor)
+}
+
+func (m *modbusMeter) GetAngle(ctx context.Context) (float64, error) {
+	return m.read(ctx, meter.MetricAngle)
+}
+
+// GetPhases returns values of the mapped per-phase registers, the rest are zero.
+func (m *modbusMeter) GetPhases(ctx context.Context) ([]meter.Phase, error) {
+	phases := make([]meter.Phase, m.phases)
+	for i := range phases {
+		values := make(map[string]float64)
+
+		for _, metric := range meter.PhaseMetrics {
+			name := meter.PhaseMetric(i+1, metric)
+			if !m.has(name) {
+				continue
+			}
+
+			value, err := m.read(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+
+			values[metric] = value
+		}
+
+		phases[i] = meter.Phase{
+			Voltage:       values[meter.MetricVoltage],
+			Current:       values[meter.MetricCurrent],
+			ActivePower:   values[meter.MetricActivePower],
+			ReactivePower: values[meter.MetricReactivePower],
+			FullPower:     values[meter.MetricFullPower],
+			PowerFactor:   values[meter.MetricPowerFactor],
+			Angle:         values[meter.MetricAngle],
+		}
+	}
+
+	return phases, nil
+}
+
+func (m *modbusMeter) has(metric string) bool {
+	_, ok := m.config.Registers[metric]
+
+	return ok
+}
+
+func (m *modbusMeter) read(ctx context.Context, metric string) (float64, error) {
+	register, ok := m.config.Registers[metric]
+	if !ok {
+		return 0, fmt.Errorf("metric \"%s\" is not mapped to a register", metric)
+	}
+
+	count, err := register.Type.Registers()
+	if err != nil {
+		return 0, err
+	}
+
+	var registers []uint16
+	if register.Function == FunctionInput {
+		registers, err = m.service.ReadInputRegisters(ctx, m.config.Unit, register.Address, uint16(count))
+	} else {
+		registers, err = m.service.ReadHoldingRegisters(ctx, m.config.Unit, register.Address, uint16(count))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := modbus_m.Decode(registers, register.Type, register.WordOrder)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(value*register.Scale*1000) / 1000, nil
+}
+
+func (m *modbusMeter) buildParams() {
+	m.tariffs, m.phases = meter.ElectricMetricCounts(m.has)
+
+	name := m.config.Model
+	if m.config.Manufacturer != "" {
+		name = m.config.Manufacturer + " " + m.config.Model
+	}
+
+	m.params = meter.Params{
+		UID:          m.config.Name,
+		StateTopic:   fmt.Sprintf("power-meter/%s/state", m.config.Name),
+		Manufacturer: m.config.Manufacturer,
+		Model:        m.config.Model,
+		Name:         name,
+		Measurements: meter.ElectricMeasurements(m.has),
+	}
+}
@@ -0,0 +1,43 @@
+package meter
+
+import (
+	"flag"
+	"github.com/lan143/metrology-master/pkg/flag/flagutil"
+)
+
+// ModelConfig is a register map of a meter model shared by every meter of the model.
+type ModelConfig struct {
+	Manufacturer string
+	Model        string
+	Registers    map[string]*RegisterConfig
+}
+
+func ExportModel(flags *flag.FlagSet) *ModelConfig {
+	c := &ModelConfig{
+		Registers: make(map[string]*RegisterConfig),
+	}
+
+	flags.StringVar(
+		&c.Manufacturer,
+		"manufacturer",
+		"",
+		"",
+	)
+	flags.StringVar(
+		&c.Model,
+		"model",
+		"",
+		"",
+	)
+	flagutil.Subset(flags, "registers", func(sub *flag.FlagSet) {
+		flagutil.Func(sub, "include", "", func(name string) error {
+			flagutil.Subset(sub, name, func(sub *flag.FlagSet) {
+				c.Registers[name] = ExportRegister(sub)
+			})
+
+			return nil
+		})
+	})
+
+	return c
+}
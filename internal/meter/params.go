@@ -1,15 +1,9 @@
 package meter
 
-type Flags uint64
-
 const (
-	FlagHasPowerConsumption = 0x01
-	FlagHasFrequency        = 0x02
-	FlagHasVoltage          = 0x04
-	FlagHasCurrent          = 0x08
-	FlagHasActivePower      = 0x10
-	FlagHasReactivePower    = 0x20
-	FlagHasFullPower        = 0x040
+	ChannelKindWater       string = "water"
+	ChannelKindGas         string = "gas"
+	ChannelKindElectricity string = "electricity"
 )
 
 type Params struct {
@@ -20,33 +14,10 @@ type Params struct {
 	Name         string
 	HWVersion    string
 	SWVersion    string
-	Flags        Flags
-}
-
-func (f Flags) HasPowerConsumption() bool {
-	return f&FlagHasPowerConsumption > 0
-}
-
-func (f Flags) HasFrequency() bool {
-	return f&FlagHasFrequency > 0
-}
-
-func (f Flags) HasVoltage() bool {
-	return f&FlagHasVoltage > 0
-}
-
-func (f Flags) HasCurrent() bool {
-	return f&FlagHasCurrent > 0
-}
-
-func (f Flags) HasActivePower() bool {
-	return f&FlagHasActivePower > 0
-}
-
-func (f Flags) HasReactivePower() bool {
-	return f&FlagHasReactivePower > 0
-}
+	// AvailabilityTopic is set by meters which may not be heard for a while, e.g. wireless
+	// meters before their first telegram. The update job publishes online or offline to it.
+	AvailabilityTopic string
 
-func (f Flags) HasFullPower() bool {
-	return f&FlagHasFullPower > 0
+	// Measurements are the values the meter reports, they drive the state and the discovery.
+	Measurements []Measurement
 }
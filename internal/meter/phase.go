@@ -0,0 +1,13 @@
+package meter
+
+// Phase holds instantaneous values of one phase of an electricity meter.
+type Phase struct {
+	Voltage       float64
+	Current       float64
+	ActivePower   float64
+	ReactivePower float64
+	FullPower     float64
+	PowerFactor   float64
+	// Angle is the angle between voltage and current, degrees.
+	Angle float64
+}
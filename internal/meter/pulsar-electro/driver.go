@@ -0,0 +1,85 @@
+package pulsar_electro
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"strconv"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Pulsar electricity meter",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of Pulsar electricity meters.
+type Options struct {
+	UID      string
+	Password string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"network address in hex, required",
+	)
+	flags.StringVar(
+		&o.Password,
+		"password",
+		"",
+		"password of the time sync, digits",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, _ string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := pulsar_m.ParseAddress(options.UID)
+	if err != nil {
+		return nil, fmt.Errorf("parse address \"%s\": %s", options.UID, err.Error())
+	}
+
+	var password uint64
+	if options.Password != "" {
+		password, err = strconv.ParseUint(options.Password, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse password: %s", err.Error())
+		}
+	}
+
+	return NewPulsarElectro(
+		Config{
+			Address:           address,
+			Password:          uint32(password),
+			TimeSyncThreshold: config.TimeSyncThreshold,
+		},
+		pulsar_m.NewPulsar(port, policy, env.Log()),
+		env.Log(),
+	), nil
+}
@@ -2,12 +2,14 @@ package pulsar_electro
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/lan143/metrology-master/internal/meter"
 	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
 	"go.uber.org/zap"
 	"math"
 	"strings"
+	"time"
 )
 
 const (
@@ -16,11 +18,22 @@ const (
 	model        string = "Пульсар"
 )
 
+// Energy registers of the tariffs, T3 and T4 are present on multi-tariff models only.
 const (
 	channelT1 int = 0
-	channelT2     = 12
+	channelT2     = 3
+	channelT3     = 6
+	channelT4     = 9
 )
 
+// modelIDMultiTariffT1T is the model byte of the four-tariff "Т1Т" version.
+const modelIDMultiTariffT1T byte = 2
+
+var tariffChannels = []int{channelT1, channelT2, channelT3, channelT4}
+
+// channelsMaxAge lets the total and the tariffs read by one update share a response.
+const channelsMaxAge = 10 * time.Second
+
 const (
 	paramFrequency uint16 = 0x100
 	paramPhase            = 0x10A
@@ -33,13 +46,15 @@ const (
 	paramActivePowerOffset
 	paramReactivePowerOffset
 	paramFullPowerOffset
-	paramCoeffPower
-	paramAngle
+	paramPowerFactorOffset
+	paramAngleOffset
 )
 
 type (
 	Config struct {
-		Address [4]byte
+		Address           [4]byte
+		Password          uint32
+		TimeSyncThreshold time.Duration
 	}
 
 	pulsarT1 struct {
@@ -47,11 +62,20 @@ type (
 		service *pulsar_m.Pulsar
 		log     *zap.Logger
 
-		params meter.Params
+		params  meter.Params
+		tariffs int
+
+		channels     []uint32
+		channelsTime time.Time
 	}
 )
 
-func NewPulsarElectro(config Config, service *pulsar_m.Pulsar, log *zap.Logger) meter.ElectricMeter {
+type PulsarElectro interface {
+	meter.ElectricMeter
+	meter.TimeSyncer
+}
+
+func NewPulsarElectro(config Config, service *pulsar_m.Pulsar, log *zap.Logger) PulsarElectro {
 	return &pulsarT1{
 		config:  config,
 		service: service,
@@ -63,33 +87,128 @@ func (m *pulsarT1) GetParams() meter.Params {
 	return m.params
 }
 
+func (m *pulsarT1) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadElectric(ctx, m)
+}
+
 func (m *pulsarT1) Init(ctx context.Context) error {
 	return m.buildParams(ctx)
 }
 
+func (m *pulsarT1) SyncTime(ctx context.Context) error {
+	deviceTime, err := m.service.ReadTime(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	drift := now.Sub(deviceTime)
+	if drift < 0 {
+		drift = -drift
+	}
+
+	m.log.Debug(
+		"check time drift",
+		zap.String("uid", m.params.UID),
+		zap.Time("device", deviceTime),
+		zap.Time("host", now),
+		zap.Duration("drift", drift),
+	)
+
+	if drift <= m.config.TimeSyncThreshold {
+		return nil
+	}
+
+	err = m.service.WriteTime(ctx, m.config.Address, time.Now())
+	if errors.Is(err, pulsar_m.ErrNeedAuth) && m.config.Password != 0 {
+		err = m.service.Authorize(ctx, m.config.Address, m.config.Password)
+		if err != nil {
+			return err
+		}
+
+		err = m.service.WriteTime(ctx, m.config.Address, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	m.log.Info(
+		"device time corrected",
+		zap.String("uid", m.params.UID),
+		zap.Duration("drift", drift),
+	)
+
+	return nil
+}
+
 func (m *pulsarT1) GetPowerConsumption(ctx context.Context) (float64, error) {
+	tariffs, err := m.GetTariffConsumption(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, value := range tariffs {
+		total += value
+	}
+
+	return math.Round(total*100) / 100, nil
+}
+
+func (m *pulsarT1) GetTariffConsumption(ctx context.Context) ([]float64, error) {
+	resp, err := m.readChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tariffs := make([]float64, m.tariffs)
+	for i := range tariffs {
+		if tariffChannels[i] >= len(resp) {
+			return nil, fmt.Errorf("invalid channels count: %d", len(resp))
+		}
+
+		tariffs[i] = float64(resp[tariffChannels[i]]) / 100
+	}
+
+	return tariffs, nil
+}
+
+func (m *pulsarT1) readChannels(ctx context.Context) ([]uint32, error) {
+	if time.Since(m.channelsTime) <= channelsMaxAge {
+		return m.channels, nil
+	}
+
 	resp, err := m.service.ReadChannels(
 		ctx,
 		m.config.Address,
 		uint32(0xFFFF),
 	)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	m.channels = resp
+	m.channelsTime = time.Now()
+
+	return resp, nil
+}
+
+// readParam reads a parameter and checks that the reply holds its size bytes.
+func (m *pulsarT1) readParam(ctx context.Context, index uint16, size int) ([]byte, error) {
+	resp, err := m.service.ReadParam(ctx, m.config.Address, index)
+	if err != nil {
+		return nil, err
 	}
 
-	var t1, t2 float64
-	t1 = float64(resp[channelT1]) / 100
-	t2 = float64(resp[channelT2]) / 100
+	if len(resp) < size {
+		return nil, fmt.Errorf("invalid param 0x%X length: %d", index, len(resp))
+	}
 
-	return math.Round((t1+t2)*100) / 100, nil
+	return resp, nil
 }
 
 func (m *pulsarT1) GetFrequency(ctx context.Context) (float64, error) {
-	resp, err := m.service.ReadParam(
-		ctx,
-		m.config.Address,
-		paramFrequency,
-	)
+	resp, err := m.readParam(ctx, paramFrequency, 2)
 	if err != nil {
 		return 0, err
 	}
@@ -101,11 +220,7 @@ func (m *pulsarT1) GetFrequency(ctx context.Context) (float64, error) {
 }
 
 func (m *pulsarT1) GetVoltage(ctx context.Context) (float64, error) {
-	resp, err := m.service.ReadParam(
-		ctx,
-		m.config.Address,
-		paramPhase+paramVoltageOffset,
-	)
+	resp, err := m.readParam(ctx, paramPhase+paramVoltageOffset, 2)
 	if err != nil {
 		return 0, err
 	}
@@ -117,11 +232,7 @@ func (m *pulsarT1) GetVoltage(ctx context.Context) (float64, error) {
 }
 
 func (m *pulsarT1) GetCurrent(ctx context.Context) (float64, error) {
-	resp, err := m.service.ReadParam(
-		ctx,
-		m.config.Address,
-		paramPhase+paramCurrentOffset,
-	)
+	resp, err := m.readParam(ctx, paramPhase+paramCurrentOffset, 4)
 	if err != nil {
 		return 0, err
 	}
@@ -133,11 +244,7 @@ func (m *pulsarT1) GetCurrent(ctx context.Context) (float64, error) {
 }
 
 func (m *pulsarT1) GetActivePower(ctx context.Context) (float64, error) {
-	resp, err := m.service.ReadParam(
-		ctx,
-		m.config.Address,
-		paramPhase+paramActivePowerOffset,
-	)
+	resp, err := m.readParam(ctx, paramPhase+paramActivePowerOffset, 2)
 	if err != nil {
 		return 0, err
 	}
@@ -149,11 +256,7 @@ func (m *pulsarT1) GetActivePower(ctx context.Context) (float64, error) {
 }
 
 func (m *pulsarT1) GetReactivePower(ctx context.Context) (float64, error) {
-	resp, err := m.service.ReadParam(
-		ctx,
-		m.config.Address,
-		paramPhase+paramReactivePowerOffset,
-	)
+	resp, err := m.readParam(ctx, paramPhase+paramReactivePowerOffset, 2)
 	if err != nil {
 		return 0, err
 	}
@@ -165,11 +268,7 @@ func (m *pulsarT1) GetReactivePower(ctx context.Context) (float64, error) {
 }
 
 func (m *pulsarT1) GetFullPower(ctx context.Context) (float64, error) {
-	resp, err := m.service.ReadParam(
-		ctx,
-		m.config.Address,
-		paramPhase+paramFullPowerOffset,
-	)
+	resp, err := m.readParam(ctx, paramPhase+paramFullPowerOffset, 2)
 	if err != nil {
 		return 0, err
 	}
@@ -180,6 +279,37 @@ func (m *pulsarT1) GetFullPower(ctx context.Context) (float64, error) {
 	return power, nil
 }
 
+// GetPowerFactor returns cos φ, negative values mean the energy is exported.
+func (m *pulsarT1) GetPowerFactor(ctx context.Context) (float64, error) {
+	resp, err := m.readParam(ctx, paramPhase+paramPowerFactorOffset, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	var powerFactor float64
+	powerFactor = float64(int16(uint16(resp[0])|uint16(resp[1])<<8)) / 1000
+
+	return math.Round(powerFactor*1000) / 1000, nil
+}
+
+// GetAngle returns the angle between voltage and current in degrees.
+func (m *pulsarT1) GetAngle(ctx context.Context) (float64, error) {
+	resp, err := m.readParam(ctx, paramPhase+paramAngleOffset, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	var angle float64
+	angle = float64(int16(uint16(resp[0])|uint16(resp[1])<<8)) / 100
+
+	return math.Round(angle*100) / 100, nil
+}
+
+// GetPhases is not supported, the meter is single-phase and its getters report the only phase.
+func (m *pulsarT1) GetPhases(_ context.Context) ([]meter.Phase, error) {
+	return nil, fmt.Errorf("per-phase values are not supported by single-phase %s meters", model)
+}
+
 func (m *pulsarT1) buildParams(ctx context.Context) error {
 	uid := fmt.Sprintf("0x%X%X%X%X", m.config.Address[0], m.config.Address[1], m.config.Address[2], m.config.Address[3])
 
@@ -193,11 +323,31 @@ func (m *pulsarT1) buildParams(ctx context.Context) error {
 		return err
 	}
 
-	modelName, err := m.buildModelName(data)
+	modelName, err := buildModelName(data, m.log)
 	if err != nil {
 		return err
 	}
 
+	m.tariffs = 2
+	if data[7] == modelIDMultiTariffT1T {
+		m.tariffs = len(tariffChannels)
+	}
+
+	metrics := []string{
+		meter.MetricPowerConsumption,
+		meter.MetricFrequency,
+		meter.MetricVoltage,
+		meter.MetricCurrent,
+		meter.MetricActivePower,
+		meter.MetricReactivePower,
+		meter.MetricFullPower,
+		meter.MetricPowerFactor,
+		meter.MetricAngle,
+	}
+	for tariff := 1; tariff <= m.tariffs; tariff++ {
+		metrics = append(metrics, meter.TariffMetric(tariff))
+	}
+
 	m.params = meter.Params{
 		UID:          uid,
 		StateTopic:   fmt.Sprintf("power-meter/%s/state", uid),
@@ -206,19 +356,33 @@ func (m *pulsarT1) buildParams(ctx context.Context) error {
 		Name:         manufacturer + " " + modelName,
 		HWVersion:    version.HWVersion,
 		SWVersion:    version.SWVersion,
-		Flags: meter.FlagHasPowerConsumption | meter.FlagHasFrequency | meter.FlagHasVoltage | meter.FlagHasCurrent |
-			meter.FlagHasActivePower | meter.FlagHasReactivePower | meter.FlagHasFullPower,
+		Measurements: meter.ElectricMeasurements(meter.Metrics(metrics...)),
 	}
 
 	return nil
 }
 
-func (m *pulsarT1) buildModelName(data []byte) (string, error) {
+// ReadModelName reads and decodes the model of the device with the given address.
+func ReadModelName(ctx context.Context, service *pulsar_m.Pulsar, address [4]byte, log *zap.Logger) (string, error) {
+	data, err := service.ReadParam(ctx, address, paramModel)
+	if err != nil {
+		return "", err
+	}
+
+	modelName, err := buildModelName(data, log)
+	if err != nil {
+		return "", err
+	}
+
+	return manufacturer + " " + modelName, nil
+}
+
+func buildModelName(data []byte, log *zap.Logger) (string, error) {
 	if len(data) < 8 {
 		return "", fmt.Errorf("invalid data length: %d", len(data))
 	}
 
-	m.log.Debug(
+	log.Debug(
 		"build model name",
 		zap.Any("data", data),
 	)
@@ -231,7 +395,7 @@ func (m *pulsarT1) buildModelName(data []byte) (string, error) {
 	case 2:
 		modelName = append(modelName, "Т1Т")
 	default:
-		m.log.Error(
+		log.Error(
 			"build model name",
 			zap.Error(fmt.Errorf("unsupported model id %d", data[7])),
 		)
@@ -241,7 +405,7 @@ func (m *pulsarT1) buildModelName(data []byte) (string, error) {
 	case 0:
 		modelName = append(modelName, "1А")
 	default:
-		m.log.Error(
+		log.Error(
 			"build model name",
 			zap.Error(fmt.Errorf("unsupported accuracy class %d", data[7])),
 		)
@@ -257,7 +421,7 @@ func (m *pulsarT1) buildModelName(data []byte) (string, error) {
 	case 3:
 		modelName = append(modelName, "10_100")
 	default:
-		m.log.Error(
+		log.Error(
 			"build model name",
 			zap.Error(fmt.Errorf("unsupported current limits %d", data[7])),
 		)
@@ -278,7 +442,7 @@ func (m *pulsarT1) buildModelName(data []byte) (string, error) {
 	case 6:
 		modelName = append(modelName, "GSM")
 	default:
-		m.log.Error(
+		log.Error(
 			"build model name",
 			zap.Error(fmt.Errorf("unsupported connection type %d", data[7])),
 		)
@@ -294,7 +458,7 @@ func (m *pulsarT1) buildModelName(data []byte) (string, error) {
 	case 3:
 		modelName = append(modelName, "COM")
 	default:
-		m.log.Error(
+		log.Error(
 			"build model name",
 			zap.Error(fmt.Errorf("unsupported case type %d", data[7])),
 		)
@@ -0,0 +1,75 @@
+package pulsar_heat
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Pulsar heat meter",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of Pulsar heat meters.
+type Options struct {
+	UID        string
+	EnergyUnit string
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"network address in hex, required",
+	)
+	flags.StringVar(
+		&o.EnergyUnit,
+		"energy-unit",
+		"",
+		"unit of the heat energy: kWh or Gcal",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, _ string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := pulsar_m.ParseAddress(options.UID)
+	if err != nil {
+		return nil, fmt.Errorf("parse address \"%s\": %s", options.UID, err.Error())
+	}
+
+	return NewPulsarHeat(
+		Config{
+			Address:    address,
+			EnergyUnit: options.EnergyUnit,
+		},
+		pulsar_m.NewPulsar(port, policy, env.Log()),
+		env.Log(),
+	)
+}
@@ -0,0 +1,197 @@
+package pulsar_heat
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	Type         string = "pulsar_heat"
+	manufacturer string = "Тепловодохран"
+	model        string = "Пульсар теплосчётчик"
+)
+
+const (
+	EnergyUnitGcal string = "Gcal"
+	EnergyUnitKWh  string = "kWh"
+
+	kWhPerGcal float64 = 1163
+)
+
+// Channel numbers of the heat meter, values are kept as floats.
+const (
+	channelSupplyTemperature int = 2
+	channelReturnTemperature     = 3
+	channelHeatEnergy            = 5
+	channelFlow                  = 8
+	channelOperatingTime         = 10
+)
+
+// channels are read by one request in ascending order, as the values of the response follow the mask bits.
+var channels = []int{
+	channelSupplyTemperature,
+	channelReturnTemperature,
+	channelHeatEnergy,
+	channelFlow,
+	channelOperatingTime,
+}
+
+// channelsMaxAge lets the measurements read by one update share a response.
+const channelsMaxAge = 10 * time.Second
+
+type (
+	Config struct {
+		Address    [4]byte
+		EnergyUnit string
+	}
+
+	pulsarHeat struct {
+		config  Config
+		service *pulsar_m.Pulsar
+		log     *zap.Logger
+
+		params meter.Params
+
+		channels     map[int]float64
+		channelsTime time.Time
+	}
+)
+
+func NewPulsarHeat(config Config, service *pulsar_m.Pulsar, log *zap.Logger) (meter.HeatMeter, error) {
+	switch config.EnergyUnit {
+	case "":
+		config.EnergyUnit = EnergyUnitGcal
+	case EnergyUnitGcal, EnergyUnitKWh:
+	default:
+		return nil, fmt.Errorf("unsupported energy unit \"%s\"", config.EnergyUnit)
+	}
+
+	return &pulsarHeat{
+		config:  config,
+		service: service,
+		log:     log,
+	}, nil
+}
+
+func (m *pulsarHeat) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *pulsarHeat) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadHeat(ctx, m)
+}
+
+func (m *pulsarHeat) Init(ctx context.Context) error {
+	return m.buildParams(ctx)
+}
+
+func (m *pulsarHeat) GetSupplyTemperature(ctx context.Context) (float64, error) {
+	temperature, err := m.readChannel(ctx, channelSupplyTemperature)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(temperature*100) / 100, nil
+}
+
+func (m *pulsarHeat) GetReturnTemperature(ctx context.Context) (float64, error) {
+	temperature, err := m.readChannel(ctx, channelReturnTemperature)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(temperature*100) / 100, nil
+}
+
+func (m *pulsarHeat) GetFlow(ctx context.Context) (float64, error) {
+	flow, err := m.readChannel(ctx, channelFlow)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(flow*1000) / 1000, nil
+}
+
+func (m *pulsarHeat) GetHeatEnergy(ctx context.Context) (float64, error) {
+	energy, err := m.readChannel(ctx, channelHeatEnergy)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.config.EnergyUnit == EnergyUnitKWh {
+		return math.Round(energy*kWhPerGcal*100) / 100, nil
+	}
+
+	return math.Round(energy*10000) / 10000, nil
+}
+
+func (m *pulsarHeat) GetOperatingTime(ctx context.Context) (float64, error) {
+	hours, err := m.readChannel(ctx, channelOperatingTime)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(hours*100) / 100, nil
+}
+
+func (m *pulsarHeat) readChannel(ctx context.Context, channel int) (float64, error) {
+	if time.Since(m.channelsTime) > channelsMaxAge {
+		var mask uint32
+		for _, ch := range channels {
+			mask |= uint32(1) << ch
+		}
+
+		resp, err := m.service.ReadChannelsFloat32(ctx, m.config.Address, mask)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(resp) < len(channels) {
+			return 0, fmt.Errorf("invalid channels count: %d", len(resp))
+		}
+
+		m.channels = make(map[int]float64, len(channels))
+		for i, ch := range channels {
+			m.channels[ch] = float64(resp[i])
+		}
+		m.channelsTime = time.Now()
+	}
+
+	return m.channels[channel], nil
+}
+
+func (m *pulsarHeat) buildParams(ctx context.Context) error {
+	uid := fmt.Sprintf("0x%X%X%X%X", m.config.Address[0], m.config.Address[1], m.config.Address[2], m.config.Address[3])
+
+	version, err := m.service.GetVersion(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("heat-meter/%s/state", uid),
+		Manufacturer: manufacturer,
+		Model:        model,
+		Name:         manufacturer + " " + model,
+		HWVersion:    version.HWVersion,
+		SWVersion:    version.SWVersion,
+		Measurements: meter.HeatMeasurements(
+			meter.Metrics(
+				meter.MetricSupplyTemperature,
+				meter.MetricReturnTemperature,
+				meter.MetricFlow,
+				meter.MetricHeatEnergy,
+				meter.MetricOperatingTime,
+			),
+			m.config.EnergyUnit,
+		),
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package pulsar_pulse
+
+import (
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"github.com/lan143/metrology-master/pkg/flag/flagutil"
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Pulsar pulse counter",
+		Transport:   driver.TransportSerial,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+type (
+	// Options are the options of Pulsar pulse counters.
+	Options struct {
+		UID      string
+		Channels map[string]*ChannelConfig
+	}
+
+	ChannelConfig struct {
+		Channel int
+		Name    string
+		Weight  float64
+		Unit    string
+		Kind    string
+	}
+)
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{
+		Channels: make(map[string]*ChannelConfig),
+	}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"network address in hex, required",
+	)
+	flagutil.Subset(flags, "channels", func(sub *flag.FlagSet) {
+		flagutil.Func(sub, "include", "counters by key: channel, name, weight, unit and kind, required", func(name string) error {
+			flagutil.Subset(sub, name, func(sub *flag.FlagSet) {
+				o.Channels[name] = exportChannel(sub)
+			})
+
+			return nil
+		})
+	})
+
+	return o
+}
+
+func exportChannel(flags *flag.FlagSet) *ChannelConfig {
+	c := &ChannelConfig{}
+
+	flags.IntVar(
+		&c.Channel,
+		"channel",
+		0,
+		"",
+	)
+	flags.StringVar(
+		&c.Name,
+		"name",
+		"",
+		"",
+	)
+	flags.Float64Var(
+		&c.Weight,
+		"weight",
+		1,
+		"",
+	)
+	flags.StringVar(
+		&c.Unit,
+		"unit",
+		"",
+		"",
+	)
+	flags.StringVar(
+		&c.Kind,
+		"kind",
+		"",
+		"",
+	)
+
+	return c
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	if len(o.Channels) == 0 {
+		return driver.Required("channels")
+	}
+
+	for name, ch := range o.Channels {
+		// a weight of zero would publish zeros instead of the counter values
+		if ch.Weight <= 0 {
+			return fmt.Errorf("option \"channels.%s.weight\" must be positive", name)
+		}
+	}
+
+	return nil
+}
+
+func newMeter(env driver.Env, name string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	port, policy, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := pulsar_m.ParseAddress(options.UID)
+	if err != nil {
+		return nil, fmt.Errorf("parse address \"%s\": %s", options.UID, err.Error())
+	}
+
+	channels := make([]Channel, 0, len(options.Channels))
+	for key, ch := range options.Channels {
+		channels = append(channels, Channel{
+			Key:    key,
+			Number: ch.Channel,
+			Name:   ch.Name,
+			Weight: ch.Weight,
+			Unit:   ch.Unit,
+			Kind:   ch.Kind,
+		})
+	}
+
+	m, err := NewPulsarPulse(
+		Config{
+			Address:  address,
+			Channels: channels,
+		},
+		pulsar_m.NewPulsar(port, policy, env.Log()),
+		env.Log(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("meter \"%s\": %s", name, err.Error())
+	}
+
+	return m, nil
+}
@@ -0,0 +1,157 @@
+package pulsar_pulse
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+)
+
+const (
+	Type         string = "pulsar_pulse"
+	manufacturer string = "Тепловодохран"
+	model        string = "Пульсар счётчик импульсов"
+
+	maxChannels int = 32
+)
+
+type (
+	Config struct {
+		Address  [4]byte
+		Channels []Channel
+	}
+
+	Channel struct {
+		Key    string
+		Number int
+		Name   string
+		// Weight scales the value of the device, which already applies its own pulse weight.
+		Weight float64
+		Unit   string
+		Kind   string
+	}
+
+	pulsarPulse struct {
+		config  Config
+		service *pulsar_m.Pulsar
+		log     *zap.Logger
+
+		mask   uint32
+		params meter.Params
+	}
+)
+
+func NewPulsarPulse(config Config, service *pulsar_m.Pulsar, log *zap.Logger) (meter.Meter, error) {
+	if len(config.Channels) == 0 {
+		return nil, fmt.Errorf("no channels configured")
+	}
+
+	var mask uint32
+	for i := range config.Channels {
+		ch := &config.Channels[i]
+
+		if ch.Number < 1 || ch.Number > maxChannels {
+			return nil, fmt.Errorf("channel \"%s\": invalid number %d", ch.Key, ch.Number)
+		}
+
+		if mask&(1<<(ch.Number-1)) > 0 {
+			return nil, fmt.Errorf("channel \"%s\": number %d is already used", ch.Key, ch.Number)
+		}
+		mask |= 1 << (ch.Number - 1)
+
+		switch ch.Kind {
+		case meter.ChannelKindWater, meter.ChannelKindGas:
+			if ch.Unit == "" {
+				ch.Unit = "m³"
+			}
+		case meter.ChannelKindElectricity:
+			if ch.Unit == "" {
+				ch.Unit = "kWh"
+			}
+		default:
+			return nil, fmt.Errorf("channel \"%s\": unsupported kind \"%s\"", ch.Key, ch.Kind)
+		}
+
+		if ch.Name == "" {
+			ch.Name = ch.Key
+		}
+	}
+
+	// the device answers with values of the requested channels in ascending order
+	sort.Slice(config.Channels, func(i, j int) bool {
+		return config.Channels[i].Number < config.Channels[j].Number
+	})
+
+	return &pulsarPulse{
+		config:  config,
+		service: service,
+		log:     log,
+		mask:    mask,
+	}, nil
+}
+
+func (m *pulsarPulse) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *pulsarPulse) Init(ctx context.Context) error {
+	return m.buildParams(ctx)
+}
+
+// Read reads all the channels at once, the channel key is the key of its measurement.
+func (m *pulsarPulse) Read(ctx context.Context) (meter.Readings, error) {
+	resp, err := m.service.ReadChannelsFloat64(
+		ctx,
+		m.config.Address,
+		m.mask,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) < len(m.config.Channels) {
+		return nil, fmt.Errorf("invalid channels count: %d", len(resp))
+	}
+
+	readings := make(meter.Readings, len(m.config.Channels))
+	for i, ch := range m.config.Channels {
+		readings[ch.Key] = math.Round(resp[i]*ch.Weight*1000) / 1000
+	}
+
+	return readings, nil
+}
+
+func (m *pulsarPulse) buildParams(ctx context.Context) error {
+	uid := fmt.Sprintf("0x%X%X%X%X", m.config.Address[0], m.config.Address[1], m.config.Address[2], m.config.Address[3])
+
+	version, err := m.service.GetVersion(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	measurements := make([]meter.Measurement, 0, len(m.config.Channels))
+	for _, ch := range m.config.Channels {
+		measurement, err := meter.ChannelMeasurement(ch.Key, ch.Name, ch.Unit, ch.Kind)
+		if err != nil {
+			return err
+		}
+
+		measurements = append(measurements, measurement)
+	}
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("pulse-meter/%s/state", uid),
+		Manufacturer: manufacturer,
+		Model:        model,
+		Name:         manufacturer + " " + model,
+		HWVersion:    version.HWVersion,
+		SWVersion:    version.SWVersion,
+		Measurements: measurements,
+	}
+
+	return nil
+}
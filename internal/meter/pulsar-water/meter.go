@@ -0,0 +1,188 @@
+package pulsar_water
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	pulsar_m "github.com/lan143/metrology-master/internal/protocol/pulsar"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+const (
+	Type         string = "pulsar_water"
+	manufacturer string = "Тепловодохран"
+	model        string = "Пульсар счётчик воды"
+)
+
+const (
+	channelVolume int = 0
+)
+
+const (
+	paramStatus uint16 = 0x0003
+)
+
+// statusMaxAge lets the leak and the tamper read by one update share a response.
+const statusMaxAge = 10 * time.Second
+
+const (
+	statusLeak   byte = 0x01
+	statusMagnet      = 0x02
+	statusOpened      = 0x04
+)
+
+type (
+	Config struct {
+		Address [4]byte
+	}
+
+	sample struct {
+		volume float64
+		time   time.Time
+	}
+
+	pulsarWater struct {
+		config  Config
+		service *pulsar_m.Pulsar
+		log     *zap.Logger
+
+		params meter.Params
+		last   sample
+		flow   float64
+
+		status     byte
+		statusTime time.Time
+	}
+)
+
+func NewPulsarWater(config Config, service *pulsar_m.Pulsar, log *zap.Logger) meter.WaterMeter {
+	return &pulsarWater{
+		config:  config,
+		service: service,
+		log:     log,
+	}
+}
+
+func (m *pulsarWater) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *pulsarWater) Init(ctx context.Context) error {
+	return m.buildParams(ctx)
+}
+
+// Read reads the measurements in the order of the metric table, so the flow is the one
+// computed by the volume read of the same update.
+func (m *pulsarWater) Read(ctx context.Context) (meter.Readings, error) {
+	return meter.ReadWater(ctx, m)
+}
+
+// GetVolume reads the volume and updates the flow, the average in m³/h since the previous
+// volume reading: the meter has no instantaneous flow measurement.
+func (m *pulsarWater) GetVolume(ctx context.Context) (float64, error) {
+	resp, err := m.service.ReadChannelsFloat64(
+		ctx,
+		m.config.Address,
+		uint32(1)<<channelVolume,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp) < 1 {
+		return 0, fmt.Errorf("invalid channels count: %d", len(resp))
+	}
+
+	current := sample{
+		volume: math.Round(resp[0]*1000) / 1000,
+		time:   time.Now(),
+	}
+
+	m.flow = 0
+	if !m.last.time.IsZero() {
+		hours := current.time.Sub(m.last.time).Hours()
+		if hours > 0 && current.volume >= m.last.volume {
+			m.flow = math.Round((current.volume-m.last.volume)/hours*1000) / 1000
+		}
+	}
+
+	m.last = current
+
+	return current.volume, nil
+}
+
+// GetFlow returns the flow computed by the last volume reading.
+func (m *pulsarWater) GetFlow(_ context.Context) (float64, error) {
+	return m.flow, nil
+}
+
+func (m *pulsarWater) GetLeak(ctx context.Context) (bool, error) {
+	status, err := m.readStatus(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return status&statusLeak > 0, nil
+}
+
+func (m *pulsarWater) GetTamper(ctx context.Context) (bool, error) {
+	status, err := m.readStatus(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return status&(statusMagnet|statusOpened) > 0, nil
+}
+
+func (m *pulsarWater) readStatus(ctx context.Context) (byte, error) {
+	if time.Since(m.statusTime) <= statusMaxAge {
+		return m.status, nil
+	}
+
+	resp, err := m.service.ReadParam(
+		ctx,
+		m.config.Address,
+		paramStatus,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp) < 1 {
+		return 0, fmt.Errorf("invalid data length: %d", len(resp))
+	}
+
+	m.status = resp[0]
+	m.statusTime = time.Now()
+
+	return m.status, nil
+}
+
+func (m *pulsarWater) buildParams(ctx context.Context) error {
+	uid := fmt.Sprintf("0x%X%X%X%X", m.config.Address[0], m.config.Address[1], m.config.Address[2], m.config.Address[3])
+
+	version, err := m.service.GetVersion(ctx, m.config.Address)
+	if err != nil {
+		return err
+	}
+
+	m.params = meter.Params{
+		UID:          uid,
+		StateTopic:   fmt.Sprintf("water-meter/%s/state", uid),
+		Manufacturer: manufacturer,
+		Model:        model,
+		Name:         manufacturer + " " + model,
+		HWVersion:    version.HWVersion,
+		SWVersion:    version.SWVersion,
+		Measurements: meter.WaterMeasurements(meter.Metrics(
+			meter.MetricVolume,
+			meter.MetricFlow,
+			meter.MetricLeak,
+			meter.MetricTamper,
+		)),
+	}
+
+	return nil
+}
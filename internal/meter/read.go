@@ -0,0 +1,135 @@
+package meter
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReadElectric reads the measurements of an electricity meter by the getters of their metrics,
+// tariffs and phases are read once for all of their measurements.
+func ReadElectric(ctx context.Context, m ElectricMeter) (Readings, error) {
+	var (
+		tariffs []float64
+		phases  []Phase
+	)
+
+	readings := make(Readings)
+	for _, measurement := range m.GetParams().Measurements {
+		metric, ok := findElectricMetric(measurement.Metric)
+		if !ok {
+			return nil, fmt.Errorf("unsupported measurement \"%s\"", measurement.Key)
+		}
+
+		switch {
+		case measurement.Tariff > 0:
+			if tariffs == nil {
+				var err error
+
+				tariffs, err = m.GetTariffConsumption(ctx)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if measurement.Tariff <= len(tariffs) {
+				readings[measurement.Key] = tariffs[measurement.Tariff-1]
+			}
+		case measurement.Phase > 0:
+			if metric.phase == nil {
+				return nil, fmt.Errorf("unsupported measurement \"%s\"", measurement.Key)
+			}
+
+			if phases == nil {
+				var err error
+
+				phases, err = m.GetPhases(ctx)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if measurement.Phase <= len(phases) {
+				readings[measurement.Key] = metric.phase(phases[measurement.Phase-1])
+			}
+		default:
+			value, err := metric.get(m, ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			readings[measurement.Key] = value
+		}
+	}
+
+	return readings, nil
+}
+
+// ReadWater reads the measurements of a water meter by the getters of their metrics.
+func ReadWater(ctx context.Context, m WaterMeter) (Readings, error) {
+	readings := make(Readings)
+	for _, measurement := range m.GetParams().Measurements {
+		metric, ok := findWaterMetric(measurement.Metric)
+		if !ok {
+			return nil, fmt.Errorf("unsupported measurement \"%s\"", measurement.Key)
+		}
+
+		value, err := metric.get(m, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		readings[measurement.Key] = value
+	}
+
+	return readings, nil
+}
+
+// ReadHeat reads the measurements of a heat meter by the getters of their metrics.
+func ReadHeat(ctx context.Context, m HeatMeter) (Readings, error) {
+	readings := make(Readings)
+	for _, measurement := range m.GetParams().Measurements {
+		metric, ok := findHeatMetric(measurement.Metric)
+		if !ok {
+			return nil, fmt.Errorf("unsupported measurement \"%s\"", measurement.Key)
+		}
+
+		value, err := metric.get(m, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		readings[measurement.Key] = value
+	}
+
+	return readings, nil
+}
+
+func findElectricMetric(name string) (electricMetric, bool) {
+	for _, metric := range electricMetrics {
+		if metric.measurement.Metric == name {
+			return metric, true
+		}
+	}
+
+	return electricMetric{}, false
+}
+
+func findWaterMetric(name string) (waterMetric, bool) {
+	for _, metric := range waterMetrics {
+		if metric.measurement.Metric == name {
+			return metric, true
+		}
+	}
+
+	return waterMetric{}, false
+}
+
+func findHeatMetric(name string) (heatMetric, bool) {
+	for _, metric := range heatMetrics {
+		if metric.measurement.Metric == name {
+			return metric, true
+		}
+	}
+
+	return heatMetric{}, false
+}
@@ -0,0 +1,58 @@
+package meter
+
+import (
+	"flag"
+)
+
+type RegisterConfig struct {
+	Address   int
+	Function  string
+	Type      string
+	WordOrder string
+	Scale     float64
+	// Metric is the meter value the register maps to, the register name is used when empty.
+	Metric string
+}
+
+func ExportRegister(flags *flag.FlagSet) *RegisterConfig {
+	c := &RegisterConfig{}
+
+	flags.IntVar(
+		&c.Address,
+		"address",
+		0,
+		"",
+	)
+	flags.StringVar(
+		&c.Function,
+		"function",
+		"holding",
+		"",
+	)
+	flags.StringVar(
+		&c.Type,
+		"type",
+		"uint16",
+		"",
+	)
+	flags.StringVar(
+		&c.WordOrder,
+		"word-order",
+		"big",
+		"",
+	)
+	flags.Float64Var(
+		&c.Scale,
+		"scale",
+		1,
+		"",
+	)
+	flags.StringVar(
+		&c.Metric,
+		"metric",
+		"",
+		"",
+	)
+
+	return c
+}
@@ -0,0 +1,9 @@
+package meter
+
+import "context"
+
+type TimeSyncer interface {
+	SyncTime(ctx context.Context) error
+
+	Meter
+}
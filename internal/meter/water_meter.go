@@ -0,0 +1,12 @@
+package meter
+
+import "context"
+
+type WaterMeter interface {
+	GetVolume(ctx context.Context) (float64, error)
+	GetFlow(ctx context.Context) (float64, error)
+	GetLeak(ctx context.Context) (bool, error)
+	GetTamper(ctx context.Context) (bool, error)
+
+	Meter
+}
@@ -0,0 +1,162 @@
+package wmbus
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"github.com/lan143/metrology-master/internal/meter"
+	"github.com/lan143/metrology-master/internal/meter/driver"
+	mbus_meter "github.com/lan143/metrology-master/internal/meter/mbus"
+	wmbus_m "github.com/lan143/metrology-master/internal/protocol/wmbus"
+	"go.uber.org/zap"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxAge is the age of the last telegram after which the meter is unavailable, meters
+// usually send telegrams every few seconds or minutes.
+const defaultMaxAge = time.Hour
+
+var (
+	receiversMu sync.Mutex
+	// receivers listen to the buses of the ports, they are shared by the meters of a port.
+	receivers = make(map[*bus.Bus]*wmbus_m.Receiver)
+)
+
+func init() {
+	driver.Register(driver.Driver{
+		Type:        Type,
+		Description: "Wireless M-Bus meter, the device type selects the kind of meter unless the kind is set, the meter is unavailable until its first telegram",
+		Transport:   driver.TransportReceiver,
+		Options:     exportOptions,
+		New:         newMeter,
+	})
+}
+
+// Options are the options of wireless M-Bus meters.
+type Options struct {
+	UID        string
+	Key        string
+	Kind       string
+	EnergyUnit string
+	MaxAge     time.Duration
+}
+
+func exportOptions(flags *flag.FlagSet) meter.Options {
+	o := &Options{}
+
+	flags.StringVar(
+		&o.UID,
+		"uid",
+		"",
+		"identification number of 8 digits, required",
+	)
+	flags.StringVar(
+		&o.Key,
+		"key",
+		"",
+		"AES-128 key of encrypted telegrams, 32 hex digits",
+	)
+	flags.StringVar(
+		&o.Kind,
+		"kind",
+		"",
+		"kind of meter: electricity, water, gas or heat, the device type of the first telegram by default",
+	)
+	flags.StringVar(
+		&o.EnergyUnit,
+		"energy-unit",
+		"",
+		"unit of the heat energy: kWh or Gcal",
+	)
+	flags.DurationVar(
+		&o.MaxAge,
+		"max-age",
+		defaultMaxAge,
+		"age of the last telegram after which the meter is unavailable, 0 disables it",
+	)
+
+	return o
+}
+
+func (o *Options) Validate() error {
+	if o.UID == "" {
+		return driver.Required("uid")
+	}
+
+	if len(o.UID) != 8 || strings.Trim(o.UID, "0123456789") != "" {
+		return fmt.Errorf("uid must be the identification number of 8 digits")
+	}
+
+	if o.MaxAge < 0 {
+		return fmt.Errorf("max-age must not be negative")
+	}
+
+	switch o.Kind {
+	case "", mbus_meter.KindElectricity, mbus_meter.KindWater, mbus_meter.KindGas, mbus_meter.KindHeat:
+	default:
+		return fmt.Errorf("unsupported kind \"%s\"", o.Kind)
+	}
+
+	switch o.EnergyUnit {
+	case "", mbus_meter.EnergyUnitKWh, mbus_meter.EnergyUnitGcal:
+	default:
+		return fmt.Errorf("unsupported energy unit \"%s\"", o.EnergyUnit)
+	}
+
+	return nil
+}
+
+// newMeter does not wait for the meter, the kind of meter is selected by the device type
+// of its first telegram the way wired M-Bus meters are, unless the kind is set.
+func newMeter(env driver.Env, name string, config *meter.Config) (meter.Meter, error) {
+	options := config.Options.(*Options)
+
+	var key []byte
+	if options.Key != "" {
+		var err error
+
+		key, err = hex.DecodeString(options.Key)
+		if err != nil || len(key) != 16 {
+			return nil, fmt.Errorf("meter \"%s\": key must be 32 hex digits", name)
+		}
+	}
+
+	receiver, err := receiverOf(env, config)
+	if err != nil {
+		return nil, err
+	}
+
+	source := NewSource(
+		Config{
+			ID:     options.UID,
+			Key:    key,
+			MaxAge: options.MaxAge,
+		},
+		receiver,
+	)
+
+	return newWirelessMeter(options.Kind, mbus_meter.Config{EnergyUnit: options.EnergyUnit}, source, env.Log()), nil
+}
+
+// receiverOf returns the receiver listening to the port of the meter, the first meter
+// of the port starts it.
+func receiverOf(env driver.Env, config *meter.Config) (*wmbus_m.Receiver, error) {
+	port, _, err := env.Port(config)
+	if err != nil {
+		return nil, err
+	}
+
+	receiversMu.Lock()
+	defer receiversMu.Unlock()
+
+	receiver, ok := receivers[port]
+	if !ok {
+		receiver = wmbus_m.NewReceiver(port, env.Log().With(zap.String("port", config.Port)))
+		receivers[port] = receiver
+	}
+
+	return receiver, nil
+}
@@ -0,0 +1,95 @@
+package wmbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/meter"
+	mbus_meter "github.com/lan143/metrology-master/internal/meter/mbus"
+	"go.uber.org/zap"
+)
+
+// wirelessMeter is the M-Bus meter of the kind, built from the first telegram of the meter
+// as its records select the measurements. Until then the meter is published as unavailable
+// and its reads fail. The kind is probed by the device type when it is not configured.
+type wirelessMeter struct {
+	kind   string
+	config mbus_meter.Config
+	source *Source
+	log    *zap.Logger
+
+	// meter is nil until the first telegram.
+	meter  meter.Meter
+	params meter.Params
+}
+
+func newWirelessMeter(kind string, config mbus_meter.Config, source *Source, log *zap.Logger) *wirelessMeter {
+	id := source.config.ID
+
+	return &wirelessMeter{
+		kind:   kind,
+		config: config,
+		source: source,
+		log:    log,
+		params: meter.Params{
+			UID:               id,
+			AvailabilityTopic: availabilityTopic(id),
+		},
+	}
+}
+
+// Init does not wait for the meter, a meter which is not heard yet is built by the first read.
+func (m *wirelessMeter) Init(ctx context.Context) error {
+	err := m.build(ctx)
+	if err != nil {
+		m.log.Warn("meter is unavailable", zap.String("id", m.params.UID), zap.Error(err))
+	}
+
+	return nil
+}
+
+func (m *wirelessMeter) GetParams() meter.Params {
+	return m.params
+}
+
+func (m *wirelessMeter) Read(ctx context.Context) (meter.Readings, error) {
+	if m.meter == nil {
+		err := m.build(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m.meter.Read(ctx)
+}
+
+func (m *wirelessMeter) build(ctx context.Context) error {
+	kind := m.kind
+	if kind == "" {
+		var err error
+
+		kind, err = mbus_meter.Probe(ctx, m.source)
+		if err != nil {
+			return err
+		}
+	}
+
+	mtr, err := mbus_meter.New(kind, m.config, m.source, m.log)
+	if err != nil {
+		return err
+	}
+
+	err = mtr.Init(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.meter = mtr
+	m.params = mtr.GetParams()
+	m.params.AvailabilityTopic = availabilityTopic(m.params.UID)
+
+	return nil
+}
+
+func availabilityTopic(id string) string {
+	return fmt.Sprintf("wmbus/%s/availability", id)
+}
@@ -0,0 +1,62 @@
+package wmbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	wmbus_m "github.com/lan143/metrology-master/internal/protocol/wmbus"
+	"time"
+)
+
+const (
+	Type string = "wmbus"
+)
+
+var (
+	ErrNoTelegram       = errors.New("no telegram received from the meter")
+	ErrOutdatedTelegram = errors.New("the last telegram of the meter is outdated")
+)
+
+type (
+	Config struct {
+		// ID is the identification number of the meter, 8 digits.
+		ID string
+		// Key is the AES-128 key of encrypted telegrams.
+		Key []byte
+		// MaxAge is the age of the last telegram after which the meter is not heard, 0 disables it.
+		MaxAge time.Duration
+	}
+
+	// Source decodes the last telegram received from the meter, the kinds of meters
+	// are the ones of wired M-Bus.
+	Source struct {
+		config   Config
+		receiver *wmbus_m.Receiver
+	}
+)
+
+func NewSource(config Config, receiver *wmbus_m.Receiver) *Source {
+	return &Source{
+		config:   config,
+		receiver: receiver,
+	}
+}
+
+func (s *Source) ReadData(_ context.Context) (mbus_m.Data, error) {
+	t, ok := s.receiver.Last(s.config.ID)
+	if !ok {
+		return mbus_m.Data{}, ErrNoTelegram
+	}
+
+	if age := time.Since(t.Received); s.config.MaxAge > 0 && age > s.config.MaxAge {
+		return mbus_m.Data{}, fmt.Errorf("%w: received %s ago", ErrOutdatedTelegram, age.Round(time.Second))
+	}
+
+	data, err := t.Decode(s.config.Key)
+	if err != nil {
+		return mbus_m.Data{}, fmt.Errorf("telegram of %s: %w", t.Address.String(), err)
+	}
+
+	return data, nil
+}
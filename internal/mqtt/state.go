@@ -1,11 +1,36 @@
 package mqtt
 
-type State struct {
-	PowerConsumption float64 `json:"powerConsumption"`
-	Frequency        float64 `json:"frequency"`
-	Voltage          float64 `json:"voltage"`
-	Current          float64 `json:"current"`
-	ActivePower      float64 `json:"activePower"`
-	ReactivePower    float64 `json:"reactivePower"`
-	FullPower        float64 `json:"fullPower"`
+import (
+	"github.com/lan143/metrology-master/internal/meter"
+	"strings"
+)
+
+// State is the payload of the state topic, values of a phase are nested, e.g. {"l1": {"voltage": 230.1}}.
+type State map[string]interface{}
+
+// NewState builds the state of the measurements, the ones without a reading are left out.
+func NewState(measurements []meter.Measurement, readings meter.Readings) State {
+	state := make(State, len(measurements))
+	for _, measurement := range measurements {
+		value, ok := readings[measurement.Key]
+		if !ok {
+			continue
+		}
+
+		node := state
+		path := strings.Split(measurement.Key, ".")
+		for _, key := range path[:len(path)-1] {
+			child, ok := node[key].(State)
+			if !ok {
+				child = make(State)
+				node[key] = child
+			}
+
+			node = child
+		}
+
+		node[path[len(path)-1]] = value
+	}
+
+	return state
 }
@@ -0,0 +1,249 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// APDU tags.
+const (
+	tagAARQ                  byte = 0x60
+	tagAARE                       = 0x61
+	tagRLRQ                       = 0x62
+	tagRLRE                       = 0x63
+	tagGetRequest                 = 0xC0
+	tagGetResponse                = 0xC4
+	tagExceptionResponse          = 0xD8
+	tagConfirmedServiceError      = 0x0E
+)
+
+const (
+	getNormal        byte = 1
+	getNext               = 2
+	getWithDatablock      = 2
+)
+
+// invokeIDAndPriority is a confirmed request of high priority with invoke id 1.
+const invokeIDAndPriority byte = 0xC1
+
+const (
+	dlmsVersion = 6
+	// maxReceivePDU is the APDU size proposed to the device, 0xFFFF means no limit.
+	maxReceivePDU = 0xFFFF
+	// authenticationFailure is the result source diagnostic of a wrong password.
+	authenticationFailure = 13
+)
+
+// conformance proposes block transfer with get, multiple references, get, set, selective access and action.
+var conformance = []byte{0x00, 0x1E, 0x1D}
+
+var (
+	// logical name referencing without ciphering
+	applicationContextLN = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x01, 0x01}
+	mechanismLow         = []byte{0x60, 0x85, 0x74, 0x05, 0x08, 0x02, 0x01}
+)
+
+// Security is the authentication mechanism of the association.
+type Security byte
+
+const (
+	// SecurityLowest associates without authentication.
+	SecurityLowest Security = iota
+	// SecurityLow authenticates the client by a password.
+	SecurityLow
+)
+
+func appendBER(b []byte, tag byte, content []byte) []byte {
+	b = append(b, tag)
+	b = appendLength(b, len(content))
+
+	return append(b, content...)
+}
+
+func encodeAARQ(security Security, password string) []byte {
+	var content []byte
+
+	content = appendBER(content, 0xA1, appendBER(nil, 0x06, applicationContextLN))
+
+	if security == SecurityLow {
+		// sender-acse-requirements: authentication
+		content = appendBER(content, 0x8A, []byte{0x07, 0x80})
+		content = appendBER(content, 0x8B, mechanismLow)
+		content = appendBER(content, 0xAC, appendBER(nil, 0x80, []byte(password)))
+	}
+
+	initiate := []byte{0x01, 0x00, 0x00, 0x00, dlmsVersion, 0x5F, 0x1F, 0x04, 0x00}
+	initiate = append(initiate, conformance...)
+	initiate = binary.BigEndian.AppendUint16(initiate, maxReceivePDU)
+
+	content = appendBER(content, 0xBE, appendBER(nil, 0x04, initiate))
+
+	return appendBER(nil, tagAARQ, content)
+}
+
+// parseBER returns the elements of a BER encoded sequence by tag.
+func parseBER(data []byte) (map[byte][]byte, error) {
+	elements := make(map[byte][]byte)
+
+	for len(data) > 0 {
+		tag := data[0]
+
+		length, rest, err := decodeLength(data[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rest) < length {
+			return nil, fmt.Errorf("%w: BER element 0x%02X is truncated", ErrInvalidResponse, tag)
+		}
+
+		elements[tag] = rest[:length]
+		data = rest[length:]
+	}
+
+	return elements, nil
+}
+
+// parseAARE checks the association result and returns the maximum APDU size of the device.
+func parseAARE(apdu []byte) (int, error) {
+	if len(apdu) < 2 || apdu[0] != tagAARE {
+		return 0, fmt.Errorf("%w: AARE expected", ErrInvalidResponse)
+	}
+
+	length, rest, err := decodeLength(apdu[1:])
+	if err != nil || len(rest) < length {
+		return 0, fmt.Errorf("%w: AARE is truncated", ErrInvalidResponse)
+	}
+
+	elements, err := parseBER(rest[:length])
+	if err != nil {
+		return 0, err
+	}
+
+	result := elements[0xA2]
+	if len(result) != 3 || result[0] != 0x02 {
+		return 0, fmt.Errorf("%w: association result is missing", ErrInvalidResponse)
+	}
+
+	if result[2] != 0 {
+		var diagnostic byte
+		if source := elements[0xA3]; len(source) == 5 {
+			diagnostic = source[4]
+		}
+
+		if diagnostic == authenticationFailure {
+			return 0, ErrAuthenticationFailed
+		}
+
+		return 0, fmt.Errorf("%w: result %d, diagnostic %d", ErrAssociationRejected, result[2], diagnostic)
+	}
+
+	info := elements[0xBE]
+	if len(info) < 2 || info[0] != 0x04 {
+		return 0, fmt.Errorf("%w: user information is missing", ErrInvalidResponse)
+	}
+
+	initiate := info[2:]
+	if len(initiate) > 0 && initiate[0] == tagConfirmedServiceError {
+		return 0, fmt.Errorf("%w: initiate error", ErrAssociationRejected)
+	}
+
+	// InitiateResponse: tag, quality of service, version, conformance, max PDU size and VAA name
+	if len(initiate) < 14 || initiate[0] != 0x08 {
+		return 0, fmt.Errorf("%w: initiate response expected", ErrInvalidResponse)
+	}
+
+	return int(binary.BigEndian.Uint16(initiate[len(initiate)-4:])), nil
+}
+
+func encodeRLRQ() []byte {
+	return []byte{tagRLRQ, 0x00}
+}
+
+func encodeGetRequest(attr AttributeDescriptor, access []byte) []byte {
+	b := []byte{tagGetRequest, getNormal, invokeIDAndPriority}
+	b = binary.BigEndian.AppendUint16(b, attr.Class)
+	b = append(b, attr.OBIS[:]...)
+	b = append(b, byte(attr.Attribute))
+
+	if access == nil {
+		return append(b, 0x00)
+	}
+
+	b = append(b, 0x01)
+
+	return append(b, access...)
+}
+
+func encodeGetRequestNext(block uint32) []byte {
+	b := []byte{tagGetRequest, getNext, invokeIDAndPriority}
+
+	return binary.BigEndian.AppendUint32(b, block)
+}
+
+// getResponse is a Get-Response-Normal or a block of a Get-Response-With-Datablock.
+type getResponse struct {
+	data []byte
+	// block is zero for normal responses.
+	block uint32
+	last  bool
+}
+
+func parseGetResponse(apdu []byte) (getResponse, error) {
+	if len(apdu) < 1 {
+		return getResponse{}, fmt.Errorf("%w: empty APDU", ErrInvalidResponse)
+	}
+
+	switch apdu[0] {
+	case tagGetResponse:
+	case tagExceptionResponse, tagConfirmedServiceError:
+		return getResponse{}, fmt.Errorf("%w: % X", ErrServiceError, apdu)
+	default:
+		return getResponse{}, fmt.Errorf("%w: get response expected", ErrInvalidResponse)
+	}
+
+	if len(apdu) < 4 {
+		return getResponse{}, fmt.Errorf("%w: get response is truncated", ErrInvalidResponse)
+	}
+
+	switch apdu[1] {
+	case getNormal:
+		if apdu[3] != 0 {
+			if len(apdu) < 5 {
+				return getResponse{}, fmt.Errorf("%w: get response is truncated", ErrInvalidResponse)
+			}
+
+			return getResponse{}, newDataAccessError(apdu[4])
+		}
+
+		return getResponse{data: apdu[4:], last: true}, nil
+	case getWithDatablock:
+		if len(apdu) < 9 {
+			return getResponse{}, fmt.Errorf("%w: get response is truncated", ErrInvalidResponse)
+		}
+
+		resp := getResponse{
+			last:  apdu[3] != 0,
+			block: binary.BigEndian.Uint32(apdu[4:8]),
+		}
+
+		if apdu[8] != 0 {
+			if len(apdu) < 10 {
+				return getResponse{}, fmt.Errorf("%w: get response is truncated", ErrInvalidResponse)
+			}
+
+			return getResponse{}, newDataAccessError(apdu[9])
+		}
+
+		length, rest, err := decodeLength(apdu[9:])
+		if err != nil || len(rest) < length {
+			return getResponse{}, fmt.Errorf("%w: data block is truncated", ErrInvalidResponse)
+		}
+
+		resp.data = rest[:length]
+
+		return resp, nil
+	default:
+		return getResponse{}, fmt.Errorf("%w: unsupported get response %d", ErrInvalidResponse, apdu[1])
+	}
+}
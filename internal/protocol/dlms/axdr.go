@@ -0,0 +1,395 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// A-XDR data type tags.
+const (
+	tagNull               byte = 0
+	tagArray                   = 1
+	tagStructure               = 2
+	tagBoolean                 = 3
+	tagBitString               = 4
+	tagDoubleLong              = 5
+	tagDoubleLongUnsigned      = 6
+	tagOctetString             = 9
+	tagVisibleString           = 10
+	tagUTF8String              = 12
+	tagBCD                     = 13
+	tagInteger                 = 15
+	tagLong                    = 16
+	tagUnsigned                = 17
+	tagLongUnsigned            = 18
+	tagLong64                  = 20
+	tagLong64Unsigned          = 21
+	tagEnum                    = 22
+	tagFloat32                 = 23
+	tagFloat64                 = 24
+	tagDateTime                = 25
+	tagDate                    = 26
+	tagTime                    = 27
+)
+
+const dateTimeLen = 12
+
+// Data values are decoded into Go types keeping the width of integers: nil, Array, Structure,
+// bool, int8..int64, uint8..uint64, Enum, float32, float64, []byte, string and time.Time.
+// A time.Time is encoded as a date-time octet string, the way COSEM objects hold it.
+type (
+	Array     []interface{}
+	Structure []interface{}
+	Enum      uint8
+)
+
+func encodeData(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, tagNull), nil
+	case Array:
+		b = append(b, tagArray)
+		b = appendLength(b, len(v))
+
+		return encodeItems(b, v)
+	case Structure:
+		b = append(b, tagStructure)
+		b = appendLength(b, len(v))
+
+		return encodeItems(b, v)
+	case bool:
+		if v {
+			return append(b, tagBoolean, 1), nil
+		}
+
+		return append(b, tagBoolean, 0), nil
+	case int8:
+		return append(b, tagInteger, byte(v)), nil
+	case int16:
+		return binary.BigEndian.AppendUint16(append(b, tagLong), uint16(v)), nil
+	case int32:
+		return binary.BigEndian.AppendUint32(append(b, tagDoubleLong), uint32(v)), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(append(b, tagLong64), uint64(v)), nil
+	case uint8:
+		return append(b, tagUnsigned, v), nil
+	case uint16:
+		return binary.BigEndian.AppendUint16(append(b, tagLongUnsigned), v), nil
+	case uint32:
+		return binary.BigEndian.AppendUint32(append(b, tagDoubleLongUnsigned), v), nil
+	case uint64:
+		return binary.BigEndian.AppendUint64(append(b, tagLong64Unsigned), v), nil
+	case Enum:
+		return append(b, tagEnum, byte(v)), nil
+	case float32:
+		return binary.BigEndian.AppendUint32(append(b, tagFloat32), math.Float32bits(v)), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, tagFloat64), math.Float64bits(v)), nil
+	case []byte:
+		b = append(b, tagOctetString)
+		b = appendLength(b, len(v))
+
+		return append(b, v...), nil
+	case string:
+		b = append(b, tagVisibleString)
+		b = appendLength(b, len(v))
+
+		return append(b, v...), nil
+	case time.Time:
+		b = append(b, tagOctetString, dateTimeLen)
+
+		return append(b, EncodeDateTime(v)...), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func encodeItems(b []byte, items []interface{}) ([]byte, error) {
+	var err error
+	for _, item := range items {
+		b, err = encodeData(b, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// decodeData decodes one value and returns the rest of the data.
+func decodeData(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: no data", ErrInvalidResponse)
+	}
+
+	tag, data := data[0], data[1:]
+
+	fixed := func(n int) ([]byte, []byte, error) {
+		if len(data) < n {
+			return nil, nil, fmt.Errorf("%w: data type %d is truncated", ErrInvalidResponse, tag)
+		}
+
+		return data[:n], data[n:], nil
+	}
+
+	switch tag {
+	case tagNull:
+		return nil, data, nil
+	case tagArray, tagStructure:
+		count, data, err := decodeLength(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			var item interface{}
+
+			item, data, err = decodeData(data)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		if tag == tagArray {
+			return Array(items), data, nil
+		}
+
+		return Structure(items), data, nil
+	case tagOctetString, tagVisibleString, tagUTF8String:
+		length, data, err := decodeLength(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(data) < length {
+			return nil, nil, fmt.Errorf("%w: data type %d is truncated", ErrInvalidResponse, tag)
+		}
+
+		if tag == tagOctetString {
+			return append([]byte(nil), data[:length]...), data[length:], nil
+		}
+
+		return string(data[:length]), data[length:], nil
+	case tagBitString:
+		bits, rest, err := decodeLength(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data = rest
+
+		value, rest, err := fixed((bits + 7) / 8)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return append([]byte(nil), value...), rest, nil
+	case tagBoolean:
+		value, rest, err := fixed(1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return value[0] != 0, rest, nil
+	case tagInteger, tagBCD:
+		value, rest, err := fixed(1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return int8(value[0]), rest, nil
+	case tagUnsigned:
+		value, rest, err := fixed(1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return value[0], rest, nil
+	case tagEnum:
+		value, rest, err := fixed(1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return Enum(value[0]), rest, nil
+	case tagLong, tagLongUnsigned:
+		value, rest, err := fixed(2)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if tag == tagLong {
+			return int16(binary.BigEndian.Uint16(value)), rest, nil
+		}
+
+		return binary.BigEndian.Uint16(value), rest, nil
+	case tagDoubleLong, tagDoubleLongUnsigned, tagFloat32:
+		value, rest, err := fixed(4)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch tag {
+		case tagDoubleLong:
+			return int32(binary.BigEndian.Uint32(value)), rest, nil
+		case tagFloat32:
+			return math.Float32frombits(binary.BigEndian.Uint32(value)), rest, nil
+		default:
+			return binary.BigEndian.Uint32(value), rest, nil
+		}
+	case tagLong64, tagLong64Unsigned, tagFloat64:
+		value, rest, err := fixed(8)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch tag {
+		case tagLong64:
+			return int64(binary.BigEndian.Uint64(value)), rest, nil
+		case tagFloat64:
+			return math.Float64frombits(binary.BigEndian.Uint64(value)), rest, nil
+		default:
+			return binary.BigEndian.Uint64(value), rest, nil
+		}
+	case tagDateTime:
+		value, rest, err := fixed(dateTimeLen)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		t, err := DecodeDateTime(value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return t, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported data type %d", ErrInvalidResponse, tag)
+	}
+}
+
+// appendLength appends the A-XDR length: one byte below 0x80, otherwise 0x8N and N bytes.
+func appendLength(b []byte, length int) []byte {
+	switch {
+	case length < 0x80:
+		return append(b, byte(length))
+	case length <= 0xFF:
+		return append(b, 0x81, byte(length))
+	case length <= 0xFFFF:
+		return append(b, 0x82, byte(length>>8), byte(length))
+	default:
+		return append(b, 0x84, byte(length>>24), byte(length>>16), byte(length>>8), byte(length))
+	}
+}
+
+func decodeLength(data []byte) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, fmt.Errorf("%w: length is truncated", ErrInvalidResponse)
+	}
+
+	if data[0] < 0x80 {
+		return int(data[0]), data[1:], nil
+	}
+
+	n := int(data[0] & 0x7F)
+	if n > 4 || len(data) < n+1 {
+		return 0, nil, fmt.Errorf("%w: invalid length", ErrInvalidResponse)
+	}
+
+	var length int
+	for _, b := range data[1 : n+1] {
+		length = length<<8 | int(b)
+	}
+
+	return length, data[n+1:], nil
+}
+
+// Float converts a numeric value into float64.
+func Float(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// DecodeDateTime decodes the COSEM date-time: year, month, day of month, day of week, hour,
+// minute, second, hundredths, deviation in minutes from local time to UTC and clock status.
+// The time is in the local time zone when the deviation is not specified.
+func DecodeDateTime(data []byte) (time.Time, error) {
+	if len(data) != dateTimeLen {
+		return time.Time{}, fmt.Errorf("%w: date-time length %d", ErrInvalidResponse, len(data))
+	}
+
+	year := int(binary.BigEndian.Uint16(data[0:2]))
+	month, day := data[2], data[3]
+	hour, minute, second, hundredths := data[5], data[6], data[7], data[8]
+
+	if year == 0xFFFF || month == 0xFF || day == 0xFF || hour == 0xFF || minute == 0xFF {
+		return time.Time{}, fmt.Errorf("%w: date-time is not specified", ErrInvalidResponse)
+	}
+
+	if second == 0xFF {
+		second = 0
+	}
+
+	if hundredths == 0xFF {
+		hundredths = 0
+	}
+
+	loc := time.Local
+	if deviation := int16(binary.BigEndian.Uint16(data[9:11])); deviation != -0x8000 {
+		loc = time.FixedZone("", -int(deviation)*60)
+	}
+
+	return time.Date(
+		year, time.Month(month), int(day),
+		int(hour), int(minute), int(second), int(hundredths)*int(10*time.Millisecond),
+		loc,
+	), nil
+}
+
+// EncodeDateTime encodes the time as the COSEM date-time with the deviation of its time zone.
+func EncodeDateTime(t time.Time) []byte {
+	_, offset := t.Zone()
+
+	weekday := byte(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+
+	b := make([]byte, 0, dateTimeLen)
+	b = binary.BigEndian.AppendUint16(b, uint16(t.Year()))
+	b = append(
+		b,
+		byte(t.Month()), byte(t.Day()), weekday,
+		byte(t.Hour()), byte(t.Minute()), byte(t.Second()), byte(t.Nanosecond()/int(10*time.Millisecond)),
+	)
+	b = binary.BigEndian.AppendUint16(b, uint16(int16(-offset/60)))
+
+	return append(b, 0x00)
+}
@@ -0,0 +1,90 @@
+package dlms
+
+import (
+	"fmt"
+	"math"
+)
+
+// Interface classes of the COSEM objects read by the client.
+const (
+	ClassData             uint16 = 1
+	ClassRegister                = 3
+	ClassExtendedRegister        = 4
+	ClassProfileGeneric          = 7
+	ClassClock                   = 8
+)
+
+// AttributeDescriptor addresses an attribute of a COSEM object.
+type AttributeDescriptor struct {
+	Class     uint16
+	OBIS      OBIS
+	Attribute int8
+}
+
+// Unit is the physical unit enumeration of the scaler_unit attribute.
+type Unit uint8
+
+const (
+	UnitDegree         Unit = 8
+	UnitWatt                = 27
+	UnitVoltAmpere          = 28
+	UnitVar                 = 29
+	UnitWattHour            = 30
+	UnitVoltAmpereHour      = 31
+	UnitVarHour             = 32
+	UnitAmpere              = 33
+	UnitVolt                = 35
+	UnitHertz               = 44
+	UnitNone                = 255
+)
+
+var unitNames = map[Unit]string{
+	UnitDegree:         "°",
+	UnitWatt:           "W",
+	UnitVoltAmpere:     "VA",
+	UnitVar:            "var",
+	UnitWattHour:       "Wh",
+	UnitVoltAmpereHour: "VAh",
+	UnitVarHour:        "varh",
+	UnitAmpere:         "A",
+	UnitVolt:           "V",
+	UnitHertz:          "Hz",
+	UnitNone:           "",
+}
+
+func (u Unit) String() string {
+	if name, ok := unitNames[u]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unit %d", u)
+}
+
+// ScalerUnit is the scaler_unit attribute of registers: value = raw * 10^scaler.
+type ScalerUnit struct {
+	Scaler int8
+	Unit   Unit
+}
+
+func (s ScalerUnit) Apply(raw float64) float64 {
+	return raw * math.Pow10(int(s.Scaler))
+}
+
+// CaptureObject is a column of a profile.
+type CaptureObject struct {
+	AttributeDescriptor
+	DataIndex uint16
+}
+
+// Profile is the buffer of a profile generic object. Values of register columns are
+// scaled float64, values of clock columns are time.Time.
+type Profile struct {
+	Columns []ProfileColumn
+	Rows    [][]interface{}
+}
+
+type ProfileColumn struct {
+	CaptureObject
+	// ScalerUnit is set for register columns.
+	ScalerUnit *ScalerUnit
+}
@@ -0,0 +1,64 @@
+package dlms
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDeviceNotResponding     = errors.New("the device is not responding")
+	ErrConnectionRefused       = errors.New("the device refused the HDLC connection")
+	ErrAssociationRejected     = errors.New("the device rejected the association")
+	ErrAuthenticationFailed    = errors.New("the device rejected the password")
+	ErrHardwareFault           = errors.New("hardware fault")
+	ErrTemporaryFailure        = errors.New("temporary failure")
+	ErrReadWriteDenied         = errors.New("access to the attribute is denied")
+	ErrObjectUndefined         = errors.New("the object is not defined in the device")
+	ErrObjectClassInconsistent = errors.New("the object is of another class")
+	ErrObjectUnavailable       = errors.New("the object is unavailable")
+	ErrDataAccess              = errors.New("data access error")
+	ErrServiceError            = errors.New("the device could not process the request")
+	ErrInvalidResponse         = errors.New("invalid response")
+)
+
+// DataAccessError is returned when the device answers a request with a data access result.
+// The underlying error is one of the Err* values and can be checked with errors.Is.
+type DataAccessError struct {
+	Code byte
+	Err  error
+}
+
+func (e *DataAccessError) Error() string {
+	if e.Err == ErrDataAccess {
+		return fmt.Sprintf("%s, code: %d", e.Err.Error(), e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *DataAccessError) Unwrap() error {
+	return e.Err
+}
+
+func newDataAccessError(code byte) *DataAccessError {
+	var err error
+
+	switch code {
+	case 1:
+		err = ErrHardwareFault
+	case 2:
+		err = ErrTemporaryFailure
+	case 3:
+		err = ErrReadWriteDenied
+	case 4:
+		err = ErrObjectUndefined
+	case 9:
+		err = ErrObjectClassInconsistent
+	case 11:
+		err = ErrObjectUnavailable
+	default:
+		err = ErrDataAccess
+	}
+
+	return &DataAccessError{Code: code, Err: err}
+}
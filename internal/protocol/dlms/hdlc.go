@@ -0,0 +1,312 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	hdlcFlag         byte = 0x7E
+	hdlcFormatType        = 0xA0
+	hdlcSegmentation      = 0x08
+	hdlcMaxFrameLen       = 0x7FF
+)
+
+// HDLC control field values with the poll/final bit set.
+const (
+	ctrlSNRM byte = 0x93
+	ctrlUA        = 0x73
+	ctrlDISC      = 0x53
+	ctrlDM        = 0x1F
+	ctrlFRMR      = 0x97
+)
+
+const (
+	// defaultMaxInfoLen is the information field length of the HDLC default parameters.
+	defaultMaxInfoLen = 128
+	// AllStation is the physical address every device answers to.
+	AllStation uint16 = 0x3FFF
+)
+
+// LLC headers of the information field.
+var (
+	llcRequest  = []byte{0xE6, 0xE6, 0x00}
+	llcResponse = []byte{0xE6, 0xE7, 0x00}
+)
+
+var errNeedMoreBytes = errors.New("need more bytes")
+
+// ServerAddress is the HDLC address of a logical device: the upper address selects
+// the logical device, the lower address selects the physical device on the line.
+type ServerAddress struct {
+	Logical  uint16
+	Physical uint16
+}
+
+// encode uses the two byte form when both addresses fit in 7 bits and the four byte form otherwise.
+func (a ServerAddress) encode() []byte {
+	if a.Logical < 0x80 && a.Physical < 0x80 {
+		return []byte{byte(a.Logical << 1), byte(a.Physical<<1) | 1}
+	}
+
+	return []byte{
+		byte(a.Logical>>7) << 1,
+		byte(a.Logical) << 1,
+		byte(a.Physical>>7) << 1,
+		byte(a.Physical)<<1 | 1,
+	}
+}
+
+func decodeServerAddress(address []byte) ServerAddress {
+	switch len(address) {
+	case 1:
+		return ServerAddress{Logical: uint16(address[0] >> 1)}
+	case 2:
+		return ServerAddress{Logical: uint16(address[0] >> 1), Physical: uint16(address[1] >> 1)}
+	default:
+		return ServerAddress{
+			Logical:  uint16(address[0]>>1)<<7 | uint16(address[1]>>1),
+			Physical: uint16(address[2]>>1)<<7 | uint16(address[3]>>1),
+		}
+	}
+}
+
+func encodeClientAddress(client byte) []byte {
+	return []byte{client<<1 | 1}
+}
+
+type frame struct {
+	segmented bool
+	dest      []byte
+	src       []byte
+	control   byte
+	info      []byte
+}
+
+func iControl(sendSeq, recvSeq byte) byte {
+	return (recvSeq&0x07)<<5 | 0x10 | (sendSeq&0x07)<<1
+}
+
+func rrControl(recvSeq byte) byte {
+	return (recvSeq&0x07)<<5 | 0x11
+}
+
+func isIFrame(control byte) bool {
+	return control&0x01 == 0
+}
+
+func isRRFrame(control byte) bool {
+	return control&0x0F == 0x01
+}
+
+func encodeFrame(f frame) []byte {
+	length := 2 + len(f.dest) + len(f.src) + 1 + 2
+	if len(f.info) > 0 {
+		length += len(f.info) + 2
+	}
+
+	format := uint16(hdlcFormatType)<<8 | uint16(length)
+	if f.segmented {
+		format |= hdlcSegmentation << 8
+	}
+
+	b := make([]byte, 0, length+2)
+	b = append(b, hdlcFlag)
+	b = binary.BigEndian.AppendUint16(b, format)
+	b = append(b, f.dest...)
+	b = append(b, f.src...)
+	b = append(b, f.control)
+
+	if len(f.info) > 0 {
+		b = binary.LittleEndian.AppendUint16(b, calculateFcs(b[1:]))
+		b = append(b, f.info...)
+	}
+
+	b = binary.LittleEndian.AppendUint16(b, calculateFcs(b[1:]))
+
+	return append(b, hdlcFlag)
+}
+
+// hdlcDecoder extracts HDLC frames from a byte stream, frames to other destinations are skipped.
+type hdlcDecoder struct {
+	// dest is the expected destination address, any when nil.
+	dest   []byte
+	buffer []byte
+
+	droppedBytes uint64
+	crcErrors    uint64
+}
+
+func (d *hdlcDecoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+// next returns the next valid frame or errNeedMoreBytes if the buffer holds none.
+func (d *hdlcDecoder) next() (frame, error) {
+	for {
+		// repeated flags fill the line between frames
+		for len(d.buffer) > 1 && d.buffer[0] == hdlcFlag && d.buffer[1] == hdlcFlag {
+			d.buffer = d.buffer[1:]
+		}
+
+		if len(d.buffer) < 3 {
+			return frame{}, errNeedMoreBytes
+		}
+
+		if d.buffer[0] != hdlcFlag || d.buffer[1]&0xF0 != hdlcFormatType {
+			d.skip()
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(d.buffer[1:3]) & hdlcMaxFrameLen)
+		if len(d.buffer) < length+2 {
+			return frame{}, errNeedMoreBytes
+		}
+
+		content := d.buffer[1 : length+1]
+		if d.buffer[length+1] != hdlcFlag {
+			d.skip()
+			continue
+		}
+
+		f, ok := d.parse(content)
+		if !ok {
+			d.skip()
+			continue
+		}
+
+		// the closing flag may be the opening flag of the next frame
+		d.buffer = d.buffer[length+1:]
+
+		if d.dest != nil && string(f.dest) != string(d.dest) {
+			d.droppedBytes += uint64(length + 1)
+			continue
+		}
+
+		return f, nil
+	}
+}
+
+func (d *hdlcDecoder) parse(content []byte) (frame, bool) {
+	if len(content) < 7 || calculateFcs(content[:len(content)-2]) != binary.LittleEndian.Uint16(content[len(content)-2:]) {
+		d.crcErrors++
+		return frame{}, false
+	}
+
+	f := frame{segmented: content[0]&hdlcSegmentation > 0}
+
+	pos := 2
+
+	var ok bool
+	f.dest, pos, ok = readAddress(content, pos)
+	if !ok {
+		return frame{}, false
+	}
+
+	f.src, pos, ok = readAddress(content, pos)
+	if !ok || pos+1 > len(content)-2 {
+		return frame{}, false
+	}
+
+	f.control = content[pos]
+	pos++
+
+	if pos == len(content)-2 {
+		return f, true
+	}
+
+	if pos+2 > len(content)-2 || calculateFcs(content[:pos]) != binary.LittleEndian.Uint16(content[pos:pos+2]) {
+		d.crcErrors++
+		return frame{}, false
+	}
+
+	f.info = append([]byte(nil), content[pos+2:len(content)-2]...)
+
+	return f, true
+}
+
+// readAddress reads an address of 1 to 4 bytes, the last byte has the low bit set.
+func readAddress(content []byte, pos int) ([]byte, int, bool) {
+	for i := pos; i < len(content) && i < pos+4; i++ {
+		if content[i]&0x01 > 0 {
+			return content[pos : i+1], i + 1, true
+		}
+	}
+
+	return nil, pos, false
+}
+
+func (d *hdlcDecoder) skip() {
+	d.buffer = d.buffer[1:]
+	d.droppedBytes++
+}
+
+// reset drops the rest of the buffer, e.g. when the transaction is over.
+func (d *hdlcDecoder) reset() {
+	// the closing flag of the last frame is not a line error
+	if len(d.buffer) != 1 || d.buffer[0] != hdlcFlag {
+		d.droppedBytes += uint64(len(d.buffer))
+	}
+	d.buffer = nil
+}
+
+// parseMaxInfoLen returns the maximum information field length the peer receives,
+// negotiated by the information field of SNRM and UA frames.
+func parseMaxInfoLen(info []byte, receiveParam byte) int {
+	maxLen := defaultMaxInfoLen
+
+	if len(info) < 3 || info[0] != 0x81 || info[1] != 0x80 {
+		return maxLen
+	}
+
+	params := info[3:]
+	for len(params) >= 2 {
+		id, length := params[0], int(params[1])
+		if len(params) < 2+length {
+			break
+		}
+
+		if id == receiveParam {
+			var value int
+			for _, b := range params[2 : 2+length] {
+				value = value<<8 | int(b)
+			}
+
+			maxLen = value
+		}
+
+		params = params[2+length:]
+	}
+
+	return maxLen
+}
+
+// encodeLinkParams encodes the HDLC parameters: maximum information field lengths and window sizes.
+func encodeLinkParams(maxInfoTx, maxInfoRx int) []byte {
+	return []byte{
+		0x81, 0x80, 0x12,
+		0x05, 0x02, byte(maxInfoTx >> 8), byte(maxInfoTx),
+		0x06, 0x02, byte(maxInfoRx >> 8), byte(maxInfoRx),
+		0x07, 0x04, 0x00, 0x00, 0x00, 0x01,
+		0x08, 0x04, 0x00, 0x00, 0x00, 0x01,
+	}
+}
+
+// calculateFcs returns the CRC-16/X.25 frame check sequence.
+func calculateFcs(data []byte) uint16 {
+	var result uint16 = 0xFFFF
+
+	for i := 0; i < len(data); i++ {
+		result ^= uint16(data[i])
+
+		for j := 0; j < 8; j++ {
+			if result&0x1 > 0 {
+				result = (result >> 1) ^ 0x8408
+			} else {
+				result = result >> 1
+			}
+		}
+	}
+
+	return ^result
+}
@@ -0,0 +1,141 @@
+package dlms
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testFrame(dest byte, info ...byte) frame {
+	return frame{
+		dest:    encodeClientAddress(dest),
+		src:     ServerAddress{Logical: 1, Physical: 0x10}.encode(),
+		control: iControl(0, 1),
+		info:    info,
+	}
+}
+
+func corruptFcs(data []byte) []byte {
+	data = append([]byte(nil), data...)
+	// the last byte is the closing flag
+	data[len(data)-2] ^= 0xFF
+
+	return data
+}
+
+func TestHDLCDecoder(t *testing.T) {
+	first := testFrame(ClientPublic, 0xE6, 0xE7, 0x00, 0x01, 0x02)
+	second := testFrame(ClientPublic, 0xE6, 0xE7, 0x00, 0x03)
+	segmented := testFrame(ClientPublic, 0xE6, 0xE7, 0x00, 0x04)
+	segmented.segmented = true
+	other := testFrame(ClientReader, 0xE6, 0xE7, 0x00, 0x05)
+
+	valid := encodeFrame(first)
+
+	tests := []struct {
+		name    string
+		chunks  [][]byte
+		frames  []frame
+		dropped uint64
+		crc     uint64
+	}{
+		{
+			name:   "one frame",
+			chunks: [][]byte{valid},
+			frames: []frame{first},
+		},
+		{
+			name:    "junk prefix",
+			chunks:  [][]byte{concat([]byte{0x00, 0x13, 0xA0}, valid)},
+			frames:  []frame{first},
+			dropped: 3,
+		},
+		{
+			name:   "split reads",
+			chunks: [][]byte{valid[:2], valid[2:9], valid[9:]},
+			frames: []frame{first},
+		},
+		{
+			name:   "two frames in one read",
+			chunks: [][]byte{concat(valid, encodeFrame(second))},
+			frames: []frame{first, second},
+		},
+		{
+			name:   "shared flag",
+			chunks: [][]byte{concat(valid, encodeFrame(second)[1:])},
+			frames: []frame{first, second},
+		},
+		{
+			name:   "repeated flags",
+			chunks: [][]byte{concat([]byte{hdlcFlag, hdlcFlag}, valid, []byte{hdlcFlag}, encodeFrame(second))},
+			frames: []frame{first, second},
+		},
+		{
+			name:   "bad fcs",
+			chunks: [][]byte{concat(corruptFcs(valid), encodeFrame(second))},
+			frames: []frame{second},
+			// the closing flag of the broken frame is taken for a fill flag
+			dropped: uint64(len(valid) - 1),
+			crc:     1,
+		},
+		{
+			name:    "frame of another client",
+			chunks:  [][]byte{concat(encodeFrame(other), valid)},
+			frames:  []frame{first},
+			dropped: uint64(len(encodeFrame(other)) - 1),
+		},
+		{
+			name:   "segmented frame",
+			chunks: [][]byte{encodeFrame(segmented)},
+			frames: []frame{segmented},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &hdlcDecoder{dest: encodeClientAddress(ClientPublic)}
+
+			var frames []frame
+			for _, chunk := range tt.chunks {
+				d.write(chunk)
+
+				for {
+					f, err := d.next()
+					if errors.Is(err, errNeedMoreBytes) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("next: %v", err)
+					}
+
+					frames = append(frames, f)
+				}
+			}
+
+			if len(frames) != len(tt.frames) {
+				t.Fatalf("got %d frames, want %d", len(frames), len(tt.frames))
+			}
+
+			for i := range frames {
+				got, want := encodeFrame(frames[i]), encodeFrame(tt.frames[i])
+				if !bytes.Equal(got, want) {
+					t.Errorf("frame %d: got % X, want % X", i, got, want)
+				}
+			}
+
+			d.reset()
+
+			if d.droppedBytes != tt.dropped {
+				t.Errorf("dropped bytes: got %d, want %d", d.droppedBytes, tt.dropped)
+			}
+
+			if d.crcErrors != tt.crc {
+				t.Errorf("crc errors: got %d, want %d", d.crcErrors, tt.crc)
+			}
+		})
+	}
+}
+
+func concat(chunks ...[]byte) []byte {
+	return bytes.Join(chunks, nil)
+}
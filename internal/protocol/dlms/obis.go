@@ -0,0 +1,52 @@
+package dlms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OBIS is the logical name of a COSEM object: A-B:C.D.E.F.
+type OBIS [6]byte
+
+var (
+	ClockOBIS             = OBIS{0, 0, 1, 0, 0, 255}
+	LogicalDeviceNameOBIS = OBIS{0, 0, 42, 0, 0, 255}
+	SerialNumberOBIS      = OBIS{0, 0, 96, 1, 0, 255}
+	LoadProfileOBIS       = OBIS{1, 0, 99, 1, 0, 255}
+)
+
+// ParseOBIS parses a logical name written as A.B.C.D.E.F or A-B:C.D.E*F, the F group
+// defaults to 255. The short form C.D.E stands for the electricity object 1-0:C.D.E*255.
+func ParseOBIS(s string) (OBIS, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '.' || r == '-' || r == ':' || r == '*' || r == '&'
+	})
+
+	switch len(parts) {
+	case 3:
+		parts = append([]string{"1", "0"}, parts...)
+		parts = append(parts, "255")
+	case 5:
+		parts = append(parts, "255")
+	case 6:
+	default:
+		return OBIS{}, fmt.Errorf("invalid OBIS code \"%s\"", s)
+	}
+
+	var obis OBIS
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return OBIS{}, fmt.Errorf("invalid OBIS code \"%s\"", s)
+		}
+
+		obis[i] = byte(v)
+	}
+
+	return obis, nil
+}
+
+func (o OBIS) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d.%d.%d", o[0], o[1], o[2], o[3], o[4], o[5])
+}
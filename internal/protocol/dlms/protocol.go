@@ -0,0 +1,513 @@
+package dlms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Client addresses of the association types.
+const (
+	// ClientPublic is the public client associated with the lowest security.
+	ClientPublic byte = 0x10
+	// ClientReader is the reading client associated with the low security in the SPODES profile.
+	ClientReader byte = 0x20
+)
+
+type Settings struct {
+	Client   byte
+	Server   ServerAddress
+	Security Security
+	Password string
+}
+
+type DLMS struct {
+	bus    *bus.Bus
+	policy bus.Policy
+
+	log *zap.Logger
+}
+
+func NewDLMS(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *DLMS {
+	return &DLMS{
+		bus:    bus,
+		policy: policy,
+		log:    log,
+	}
+}
+
+// Session connects to the device, associates and calls fn, the association is released
+// when fn returns. The whole session runs in one bus transaction, so the policy timeout
+// limits the session rather than a single request.
+func (s *DLMS) Session(ctx context.Context, settings Settings, fn func(ctx context.Context, session *Session) error) error {
+	err := s.bus.Request(ctx, s.policy, func(ctx context.Context, tx *bus.Tx) error {
+		session := &Session{
+			tx:       tx,
+			settings: settings,
+			dest:     settings.Server.encode(),
+			src:      encodeClientAddress(settings.Client),
+			decoder:  &hdlcDecoder{dest: encodeClientAddress(settings.Client)},
+			log:      s.log,
+		}
+		defer session.reportLineErrors()
+
+		err := session.connect(ctx)
+		if err != nil {
+			return err
+		}
+		defer session.disconnect(ctx)
+
+		err = session.associate(ctx)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, session)
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrDeviceNotResponding
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Session is an association with a logical device over an HDLC connection.
+type Session struct {
+	tx       *bus.Tx
+	settings Settings
+	dest     []byte
+	src      []byte
+	decoder  *hdlcDecoder
+
+	sendSeq   byte
+	recvSeq   byte
+	maxInfoTx int
+	maxPDU    int
+
+	log *zap.Logger
+}
+
+// Get returns the value of the attribute.
+func (s *Session) Get(ctx context.Context, attr AttributeDescriptor) (interface{}, error) {
+	return s.get(ctx, attr, nil)
+}
+
+// GetWithAccess returns the value of the attribute selected by the access selector and its parameters.
+func (s *Session) GetWithAccess(ctx context.Context, attr AttributeDescriptor, selector byte, params interface{}) (interface{}, error) {
+	access, err := encodeData([]byte{selector}, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.get(ctx, attr, access)
+}
+
+// ReadScalerUnit returns the scaler_unit attribute of a register.
+func (s *Session) ReadScalerUnit(ctx context.Context, obis OBIS) (ScalerUnit, error) {
+	value, err := s.Get(ctx, AttributeDescriptor{Class: ClassRegister, OBIS: obis, Attribute: 3})
+	if err != nil {
+		return ScalerUnit{}, err
+	}
+
+	scalerUnit, ok := value.(Structure)
+	if !ok || len(scalerUnit) != 2 {
+		return ScalerUnit{}, fmt.Errorf("%w: scaler_unit of %s", ErrInvalidResponse, obis)
+	}
+
+	scaler, ok := scalerUnit[0].(int8)
+	if !ok {
+		return ScalerUnit{}, fmt.Errorf("%w: scaler of %s", ErrInvalidResponse, obis)
+	}
+
+	unit, ok := scalerUnit[1].(Enum)
+	if !ok {
+		return ScalerUnit{}, fmt.Errorf("%w: unit of %s", ErrInvalidResponse, obis)
+	}
+
+	return ScalerUnit{Scaler: scaler, Unit: Unit(unit)}, nil
+}
+
+// ReadRegisterValue returns the raw numeric value of a register.
+func (s *Session) ReadRegisterValue(ctx context.Context, obis OBIS) (float64, error) {
+	value, err := s.Get(ctx, AttributeDescriptor{Class: ClassRegister, OBIS: obis, Attribute: 2})
+	if err != nil {
+		return 0, err
+	}
+
+	raw, ok := Float(value)
+	if !ok {
+		return 0, fmt.Errorf("%w: value of %s is not numeric", ErrInvalidResponse, obis)
+	}
+
+	return raw, nil
+}
+
+// ReadRegister returns the scaled value of a register and its unit.
+func (s *Session) ReadRegister(ctx context.Context, obis OBIS) (float64, Unit, error) {
+	scalerUnit, err := s.ReadScalerUnit(ctx, obis)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	raw, err := s.ReadRegisterValue(ctx, obis)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return scalerUnit.Apply(raw), scalerUnit.Unit, nil
+}
+
+// ReadString returns the value of a data object holding a string, e.g. the serial number.
+func (s *Session) ReadString(ctx context.Context, obis OBIS) (string, error) {
+	value, err := s.Get(ctx, AttributeDescriptor{Class: ClassData, OBIS: obis, Attribute: 2})
+	if err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		return "", fmt.Errorf("%w: value of %s is not a string", ErrInvalidResponse, obis)
+	}
+}
+
+// ReadClock returns the time of the device clock.
+func (s *Session) ReadClock(ctx context.Context) (time.Time, error) {
+	value, err := s.Get(ctx, AttributeDescriptor{Class: ClassClock, OBIS: ClockOBIS, Attribute: 2})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return toTime(value)
+}
+
+// ReadProfile returns the entries of the profile captured from the time up to the time.
+func (s *Session) ReadProfile(ctx context.Context, obis OBIS, from time.Time, to time.Time) (Profile, error) {
+	columns, err := s.readCaptureObjects(ctx, obis)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	clock := -1
+	for i := range columns {
+		switch {
+		case columns[i].Class == ClassClock && columns[i].Attribute == 2:
+			if clock < 0 {
+				clock = i
+			}
+		case (columns[i].Class == ClassRegister || columns[i].Class == ClassExtendedRegister) && columns[i].Attribute == 2:
+			scalerUnit, err := s.ReadScalerUnit(ctx, columns[i].OBIS)
+			if err != nil {
+				return Profile{}, err
+			}
+
+			columns[i].ScalerUnit = &scalerUnit
+		}
+	}
+
+	if clock < 0 {
+		return Profile{}, fmt.Errorf("profile %s has no clock column", obis)
+	}
+
+	period, err := s.Get(ctx, AttributeDescriptor{Class: ClassProfileGeneric, OBIS: obis, Attribute: 4})
+	if err != nil {
+		return Profile{}, err
+	}
+
+	capturePeriod, _ := Float(period)
+
+	// range descriptor: restricting object, from, to and all columns
+	rangeDescriptor := Structure{
+		Structure{uint16(ClassClock), columns[clock].OBIS[:], int8(2), uint16(0)},
+		from,
+		to,
+		Array{},
+	}
+
+	value, err := s.GetWithAccess(ctx, AttributeDescriptor{Class: ClassProfileGeneric, OBIS: obis, Attribute: 2}, 1, rangeDescriptor)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	buffer, ok := value.(Array)
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: buffer of %s is not an array", ErrInvalidResponse, obis)
+	}
+
+	profile := Profile{Columns: columns, Rows: make([][]interface{}, 0, len(buffer))}
+
+	var last time.Time
+	for _, entry := range buffer {
+		fields, ok := entry.(Structure)
+		if !ok || len(fields) != len(columns) {
+			return Profile{}, fmt.Errorf("%w: entry of %s does not match the capture objects", ErrInvalidResponse, obis)
+		}
+
+		row := make([]interface{}, len(fields))
+		for i, field := range fields {
+			row[i] = field
+
+			if i == clock {
+				// the clock of entries following at the capture period may be omitted
+				if field == nil && !last.IsZero() {
+					row[i] = last.Add(time.Duration(capturePeriod) * time.Second)
+				} else if t, err := toTime(field); err == nil {
+					row[i] = t
+				}
+
+				last, _ = row[i].(time.Time)
+			} else if columns[i].ScalerUnit != nil {
+				if raw, ok := Float(field); ok {
+					row[i] = columns[i].ScalerUnit.Apply(raw)
+				}
+			}
+		}
+
+		profile.Rows = append(profile.Rows, row)
+	}
+
+	return profile, nil
+}
+
+func (s *Session) readCaptureObjects(ctx context.Context, obis OBIS) ([]ProfileColumn, error) {
+	value, err := s.Get(ctx, AttributeDescriptor{Class: ClassProfileGeneric, OBIS: obis, Attribute: 3})
+	if err != nil {
+		return nil, err
+	}
+
+	objects, ok := value.(Array)
+	if !ok {
+		return nil, fmt.Errorf("%w: capture objects of %s is not an array", ErrInvalidResponse, obis)
+	}
+
+	columns := make([]ProfileColumn, 0, len(objects))
+	for _, object := range objects {
+		fields, ok := object.(Structure)
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("%w: capture object of %s", ErrInvalidResponse, obis)
+		}
+
+		class, ok1 := fields[0].(uint16)
+		name, ok2 := fields[1].([]byte)
+		attribute, ok3 := fields[2].(int8)
+		index, ok4 := fields[3].(uint16)
+		if !ok1 || !ok2 || !ok3 || !ok4 || len(name) != len(OBIS{}) {
+			return nil, fmt.Errorf("%w: capture object of %s", ErrInvalidResponse, obis)
+		}
+
+		column := ProfileColumn{
+			CaptureObject: CaptureObject{
+				AttributeDescriptor: AttributeDescriptor{Class: class, Attribute: attribute},
+				DataIndex:           index,
+			},
+		}
+		copy(column.OBIS[:], name)
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+func (s *Session) get(ctx context.Context, attr AttributeDescriptor, access []byte) (interface{}, error) {
+	apdu, err := s.exchange(ctx, encodeGetRequest(attr, access))
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for {
+		resp, err := parseGetResponse(apdu)
+		if err != nil {
+			return nil, fmt.Errorf("get %d/%s/%d: %w", attr.Class, attr.OBIS, attr.Attribute, err)
+		}
+
+		data = append(data, resp.data...)
+
+		if resp.last {
+			break
+		}
+
+		apdu, err = s.exchange(ctx, encodeGetRequestNext(resp.block))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	value, rest, err := decodeData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: %d bytes after the data", ErrInvalidResponse, len(rest))
+	}
+
+	s.log.Debug(
+		"get",
+		zap.Uint16("class", attr.Class),
+		zap.Stringer("obis", attr.OBIS),
+		zap.Int8("attribute", attr.Attribute),
+		zap.Any("value", value),
+	)
+
+	return value, nil
+}
+
+func (s *Session) connect(ctx context.Context) error {
+	resp, err := s.request(ctx, frame{control: ctrlSNRM})
+	if err != nil {
+		return err
+	}
+
+	if resp.control != ctrlUA {
+		return ErrConnectionRefused
+	}
+
+	s.maxInfoTx = parseMaxInfoLen(resp.info, 0x06)
+
+	return nil
+}
+
+func (s *Session) associate(ctx context.Context) error {
+	apdu, err := s.exchange(ctx, encodeAARQ(s.settings.Security, s.settings.Password))
+	if err != nil {
+		return err
+	}
+
+	s.maxPDU, err = parseAARE(apdu)
+
+	return err
+}
+
+// disconnect releases the association and the connection, the device may drop
+// the association on disconnect only, so the release response is optional.
+func (s *Session) disconnect(ctx context.Context) {
+	_, err := s.request(ctx, frame{control: ctrlDISC})
+	if err != nil {
+		s.log.Warn("disconnect", zap.Error(err))
+	}
+}
+
+// exchange sends the APDU in I-frames and returns the response APDU.
+func (s *Session) exchange(ctx context.Context, apdu []byte) ([]byte, error) {
+	info := append(append([]byte(nil), llcRequest...), apdu...)
+
+	for {
+		segment := info
+		if len(segment) > s.maxInfoTx {
+			segment = segment[:s.maxInfoTx]
+		}
+		info = info[len(segment):]
+
+		resp, err := s.request(ctx, frame{segmented: len(info) > 0, control: iControl(s.sendSeq, s.recvSeq), info: segment})
+		if err != nil {
+			return nil, err
+		}
+
+		s.sendSeq++
+
+		if len(info) == 0 {
+			return s.receive(ctx, resp)
+		}
+
+		if !isRRFrame(resp.control) {
+			return nil, fmt.Errorf("%w: control 0x%02X, RR expected", ErrInvalidResponse, resp.control)
+		}
+	}
+}
+
+// receive collects the segments of the response started by the frame.
+func (s *Session) receive(ctx context.Context, resp frame) ([]byte, error) {
+	var info []byte
+
+	for {
+		if !isIFrame(resp.control) {
+			return nil, fmt.Errorf("%w: control 0x%02X, I-frame expected", ErrInvalidResponse, resp.control)
+		}
+
+		s.recvSeq++
+		info = append(info, resp.info...)
+
+		if !resp.segmented {
+			break
+		}
+
+		var err error
+		resp, err = s.request(ctx, frame{control: rrControl(s.recvSeq)})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(info) < len(llcResponse) || string(info[:len(llcResponse)]) != string(llcResponse) {
+		return nil, fmt.Errorf("%w: LLC header", ErrInvalidResponse)
+	}
+
+	return info[len(llcResponse):], nil
+}
+
+func (s *Session) request(ctx context.Context, f frame) (frame, error) {
+	f.dest = s.dest
+	f.src = s.src
+
+	err := s.tx.Write(encodeFrame(f))
+	if err != nil {
+		return frame{}, err
+	}
+
+	for {
+		resp, err := s.decoder.next()
+		if err == nil {
+			if resp.control == ctrlFRMR {
+				return frame{}, fmt.Errorf("%w: frame rejected", ErrInvalidResponse)
+			}
+
+			return resp, nil
+		}
+
+		data, err := s.tx.Read(ctx)
+		if err != nil {
+			return frame{}, err
+		}
+
+		s.decoder.write(data)
+	}
+}
+
+func (s *Session) reportLineErrors() {
+	s.decoder.reset()
+
+	if s.decoder.droppedBytes == 0 && s.decoder.crcErrors == 0 {
+		return
+	}
+
+	s.tx.ReportLineErrors(s.decoder.droppedBytes, s.decoder.crcErrors)
+
+	s.log.Warn(
+		"line errors",
+		zap.Uint64("droppedBytes", s.decoder.droppedBytes),
+		zap.Uint64("crcErrors", s.decoder.crcErrors),
+	)
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return DecodeDateTime(v)
+	default:
+		return time.Time{}, fmt.Errorf("%w: %T is not a date-time", ErrInvalidResponse, value)
+	}
+}
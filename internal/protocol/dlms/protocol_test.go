@@ -0,0 +1,245 @@
+package dlms
+
+import (
+	"context"
+	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPassword = "12345678"
+
+var (
+	testServer = ServerAddress{Logical: 1, Physical: 0x10}
+	energyOBIS = OBIS{1, 0, 1, 8, 0, 255}
+	testZone   = time.FixedZone("", 3*60*60)
+)
+
+// runSession runs fn in a session with a simulator served over a pipe.
+func runSession(t *testing.T, sim *Simulator, settings Settings, fn func(ctx context.Context, session *Session) error) error {
+	t.Helper()
+
+	client, device := net.Pipe()
+	defer device.Close()
+
+	go func() {
+		_ = sim.Serve(device)
+	}()
+
+	policy := bus.Policy{Timeout: 2 * time.Second}
+	b := bus.NewBus(client, 0, policy, zap.NewNop())
+	defer client.Close()
+
+	return NewDLMS(b, policy, zap.NewNop()).Session(context.Background(), settings, fn)
+}
+
+func publicSettings() Settings {
+	return Settings{Client: ClientPublic, Server: testServer, Security: SecurityLowest}
+}
+
+func TestAssociation(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		settings Settings
+		err      error
+	}{
+		{
+			name:     "lowest security of the public client",
+			password: testPassword,
+			settings: publicSettings(),
+		},
+		{
+			name:     "low security",
+			password: testPassword,
+			settings: Settings{Client: ClientReader, Server: testServer, Security: SecurityLow, Password: testPassword},
+		},
+		{
+			name:     "low security with a wrong password",
+			password: testPassword,
+			settings: Settings{Client: ClientReader, Server: testServer, Security: SecurityLow, Password: "87654321"},
+			err:      ErrAuthenticationFailed,
+		},
+		{
+			name:     "lowest security of the reading client",
+			password: testPassword,
+			settings: Settings{Client: ClientReader, Server: testServer, Security: SecurityLowest},
+			err:      ErrAuthenticationFailed,
+		},
+		{
+			// the request does not fit in the information field of the simulator
+			name:     "segmented request",
+			password: strings.Repeat("p", 2*simulatorMaxInfoLen),
+			settings: Settings{
+				Client:   ClientReader,
+				Server:   testServer,
+				Security: SecurityLow,
+				Password: strings.Repeat("p", 2*simulatorMaxInfoLen),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := NewSimulator(testServer, tt.password)
+			sim.SetData(SerialNumberOBIS, "00123456")
+
+			var serial string
+			err := runSession(t, sim, tt.settings, func(ctx context.Context, session *Session) error {
+				var err error
+				serial, err = session.ReadString(ctx, SerialNumberOBIS)
+
+				return err
+			})
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if serial != "00123456" {
+				t.Fatalf("serial number: got %q", serial)
+			}
+		})
+	}
+}
+
+func TestGetByOBIS(t *testing.T) {
+	clock := time.Date(2026, 10, 18, 12, 30, 15, 0, testZone)
+	scalerUnit := ScalerUnit{Scaler: -2, Unit: UnitWattHour}
+	name := strings.Repeat("n", 3*simulatorMaxInfoLen)
+
+	sim := NewSimulator(testServer, testPassword)
+	sim.SetRegister(energyOBIS, uint32(1234567), scalerUnit)
+	sim.SetData(LogicalDeviceNameOBIS, name)
+	sim.SetClock(clock)
+
+	err := runSession(t, sim, publicSettings(), func(ctx context.Context, session *Session) error {
+		value, unit, err := session.ReadRegister(ctx, energyOBIS)
+		if err != nil {
+			return err
+		}
+
+		if value != scalerUnit.Apply(1234567) || unit != UnitWattHour {
+			t.Errorf("register: got %v %s", value, unit)
+		}
+
+		// the response does not fit in the information field of the simulator
+		got, err := session.ReadString(ctx, LogicalDeviceNameOBIS)
+		if err != nil {
+			return err
+		}
+
+		if got != name {
+			t.Errorf("logical device name: got %q", got)
+		}
+
+		now, err := session.ReadClock(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !now.Equal(clock) {
+			t.Errorf("clock: got %s, want %s", now, clock)
+		}
+
+		_, err = session.ReadRegisterValue(ctx, OBIS{1, 0, 2, 8, 0, 255})
+		if !errors.Is(err, ErrObjectUndefined) {
+			t.Errorf("undefined object: got %v, want %v", err, ErrObjectUndefined)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBlockTransfer(t *testing.T) {
+	name := strings.Repeat("0123456789", 10)
+
+	sim := NewSimulator(testServer, testPassword)
+	sim.SetBlockSize(16)
+	sim.SetData(LogicalDeviceNameOBIS, name)
+
+	err := runSession(t, sim, publicSettings(), func(ctx context.Context, session *Session) error {
+		got, err := session.ReadString(ctx, LogicalDeviceNameOBIS)
+		if err != nil {
+			return err
+		}
+
+		if got != name {
+			t.Errorf("logical device name: got %q, want %q", got, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadProfile(t *testing.T) {
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, testZone)
+	scalerUnit := ScalerUnit{Scaler: 1, Unit: UnitWattHour}
+
+	objects := []CaptureObject{
+		{AttributeDescriptor: AttributeDescriptor{Class: ClassClock, OBIS: ClockOBIS, Attribute: 2}},
+		{AttributeDescriptor: AttributeDescriptor{Class: ClassRegister, OBIS: energyOBIS, Attribute: 2}},
+	}
+
+	var entries []Structure
+	for i := 0; i < 6; i++ {
+		entries = append(entries, Structure{start.Add(time.Duration(i) * time.Hour), uint32(100 + i)})
+	}
+
+	sim := NewSimulator(testServer, testPassword)
+	sim.SetBlockSize(32)
+	sim.SetRegister(energyOBIS, uint32(0), scalerUnit)
+	sim.SetProfile(LoadProfileOBIS, time.Hour, objects, entries)
+
+	var profile Profile
+	err := runSession(t, sim, publicSettings(), func(ctx context.Context, session *Session) error {
+		var err error
+		profile, err = session.ReadProfile(ctx, LoadProfileOBIS, start.Add(2*time.Hour), start.Add(4*time.Hour))
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profile.Columns) != len(objects) {
+		t.Fatalf("got %d columns, want %d", len(profile.Columns), len(objects))
+	}
+
+	if su := profile.Columns[1].ScalerUnit; su == nil || *su != scalerUnit {
+		t.Errorf("scaler_unit of the register column: got %v", su)
+	}
+
+	if len(profile.Rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(profile.Rows))
+	}
+
+	for i, row := range profile.Rows {
+		at, ok := row[0].(time.Time)
+		if want := start.Add(time.Duration(i+2) * time.Hour); !ok || !at.Equal(want) {
+			t.Errorf("row %d: time %v, want %s", i, row[0], want)
+		}
+
+		if want := scalerUnit.Apply(float64(102 + i)); row[1] != want {
+			t.Errorf("row %d: value %v, want %v", i, row[1], want)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package dlms
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// Simulator is a stand-in for a DLMS/COSEM meter with the HDLC transport, it serves
+// the objects set by the Set* methods. Associations of the public client need no
+// password, other clients authenticate with the low security password.
+type Simulator struct {
+	address  ServerAddress
+	password string
+	// blockSize limits the data of a get response, larger data is sent in blocks.
+	blockSize int
+
+	mu       sync.Mutex
+	values   map[AttributeDescriptor]interface{}
+	profiles map[OBIS]int
+}
+
+// simulatorConn is the state of one HDLC connection.
+type simulatorConn struct {
+	w io.Writer
+
+	client     []byte
+	connected  bool
+	associated bool
+	sendSeq    byte
+	recvSeq    byte
+	request    []byte
+	segments   [][]byte
+	blocks     [][]byte
+	block      uint32
+}
+
+const (
+	simulatorBlockSize = 1024
+	// simulatorMaxInfoLen is the information field length of the simulator, set below
+	// the default to exercise segmentation.
+	simulatorMaxInfoLen = 64
+)
+
+func NewSimulator(address ServerAddress, password string) *Simulator {
+	return &Simulator{
+		address:   address,
+		password:  password,
+		blockSize: simulatorBlockSize,
+		values:    make(map[AttributeDescriptor]interface{}),
+		profiles:  make(map[OBIS]int),
+	}
+}
+
+// SetBlockSize limits the data of a get response, larger data is sent in blocks.
+func (s *Simulator) SetBlockSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blockSize = size
+}
+
+// SetData sets the value of a data object.
+func (s *Simulator) SetData(obis OBIS, value interface{}) {
+	s.set(AttributeDescriptor{Class: ClassData, OBIS: obis, Attribute: 2}, value)
+}
+
+// SetRegister sets the raw value and the scaler_unit of a register.
+func (s *Simulator) SetRegister(obis OBIS, value interface{}, scalerUnit ScalerUnit) {
+	s.set(AttributeDescriptor{Class: ClassRegister, OBIS: obis, Attribute: 2}, value)
+	s.set(AttributeDescriptor{Class: ClassRegister, OBIS: obis, Attribute: 3}, Structure{scalerUnit.Scaler, Enum(scalerUnit.Unit)})
+}
+
+func (s *Simulator) SetClock(t time.Time) {
+	s.set(AttributeDescriptor{Class: ClassClock, OBIS: ClockOBIS, Attribute: 2}, t)
+}
+
+// SetProfile sets the capture objects and the buffer of a profile, the clock column
+// of the entries is a time.Time, entries are selected by range of the clock column.
+func (s *Simulator) SetProfile(obis OBIS, capturePeriod time.Duration, objects []CaptureObject, entries []Structure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clock := -1
+	columns := make(Array, 0, len(objects))
+	for i, object := range objects {
+		if object.Class == ClassClock && clock < 0 {
+			clock = i
+		}
+
+		obis := object.OBIS
+		columns = append(columns, Structure{object.Class, obis[:], object.Attribute, object.DataIndex})
+	}
+
+	buffer := make(Array, 0, len(entries))
+	for _, entry := range entries {
+		buffer = append(buffer, entry)
+	}
+
+	s.profiles[obis] = clock
+	s.values[AttributeDescriptor{Class: ClassProfileGeneric, OBIS: obis, Attribute: 2}] = buffer
+	s.values[AttributeDescriptor{Class: ClassProfileGeneric, OBIS: obis, Attribute: 3}] = columns
+	s.values[AttributeDescriptor{Class: ClassProfileGeneric, OBIS: obis, Attribute: 4}] = uint32(capturePeriod / time.Second)
+}
+
+func (s *Simulator) set(attr AttributeDescriptor, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[attr] = value
+}
+
+// Serve answers the requests received from the connection until it fails or is closed.
+func (s *Simulator) Serve(rw io.ReadWriter) error {
+	conn := &simulatorConn{w: rw}
+	decoder := &hdlcDecoder{}
+	buff := make([]byte, 256)
+
+	for {
+		n, err := rw.Read(buff)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		decoder.write(buff[:n])
+
+		for {
+			f, err := decoder.next()
+			if err != nil {
+				break
+			}
+
+			err = s.handle(conn, f)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Simulator) handle(conn *simulatorConn, f frame) error {
+	address := decodeServerAddress(f.dest)
+	if address.Logical != s.address.Logical || (address.Physical != s.address.Physical && address.Physical != AllStation) {
+		return nil
+	}
+
+	conn.client = f.src
+
+	switch {
+	case f.control == ctrlSNRM:
+		conn.connected = true
+		conn.associated = false
+		conn.sendSeq, conn.recvSeq = 0, 0
+		conn.request, conn.segments = nil, nil
+
+		return s.send(conn, frame{control: ctrlUA, info: encodeLinkParams(simulatorMaxInfoLen, simulatorMaxInfoLen)})
+	case f.control == ctrlDISC:
+		if !conn.connected {
+			return s.send(conn, frame{control: ctrlDM})
+		}
+
+		conn.connected = false
+		conn.associated = false
+
+		return s.send(conn, frame{control: ctrlUA})
+	case !conn.connected:
+		return s.send(conn, frame{control: ctrlDM})
+	case isIFrame(f.control):
+		conn.recvSeq++
+		conn.request = append(conn.request, f.info...)
+
+		if f.segmented {
+			return s.send(conn, frame{control: rrControl(conn.recvSeq)})
+		}
+
+		request := conn.request
+		conn.request = nil
+
+		if len(request) < len(llcRequest) || string(request[:len(llcRequest)]) != string(llcRequest) {
+			return s.send(conn, frame{control: ctrlFRMR})
+		}
+
+		resp := append(append([]byte(nil), llcResponse...), s.process(conn, request[len(llcRequest):])...)
+		for len(resp) > simulatorMaxInfoLen {
+			conn.segments = append(conn.segments, resp[:simulatorMaxInfoLen])
+			resp = resp[simulatorMaxInfoLen:]
+		}
+		conn.segments = append(conn.segments, resp)
+
+		return s.sendSegment(conn)
+	case isRRFrame(f.control) && len(conn.segments) > 0:
+		return s.sendSegment(conn)
+	default:
+		return s.send(conn, frame{control: ctrlFRMR})
+	}
+}
+
+func (s *Simulator) sendSegment(conn *simulatorConn) error {
+	segment := conn.segments[0]
+	conn.segments = conn.segments[1:]
+
+	f := frame{
+		segmented: len(conn.segments) > 0,
+		control:   iControl(conn.sendSeq, conn.recvSeq),
+		info:      segment,
+	}
+	conn.sendSeq++
+
+	return s.send(conn, f)
+}
+
+func (s *Simulator) send(conn *simulatorConn, f frame) error {
+	f.dest = conn.client
+	f.src = s.address.encode()
+
+	_, err := conn.w.Write(encodeFrame(f))
+
+	return err
+}
+
+func (s *Simulator) process(conn *simulatorConn, apdu []byte) []byte {
+	switch {
+	case len(apdu) > 0 && apdu[0] == tagAARQ:
+		return s.associate(conn, apdu)
+	case len(apdu) > 0 && apdu[0] == tagRLRQ:
+		conn.associated = false
+
+		return []byte{tagRLRE, 0x03, 0x80, 0x01, 0x00}
+	case len(apdu) > 2 && apdu[0] == tagGetRequest && conn.associated:
+		return s.get(conn, apdu)
+	default:
+		// service not supported
+		return []byte{tagExceptionResponse, 0x01, 0x02}
+	}
+}
+
+func (s *Simulator) associate(conn *simulatorConn, apdu []byte) []byte {
+	var password []byte
+
+	length, rest, err := decodeLength(apdu[1:])
+	if err == nil && len(rest) >= length {
+		elements, err := parseBER(rest[:length])
+		if err != nil {
+			return encodeAARE(1, 0)
+		}
+
+		if value := elements[0xAC]; len(value) >= 2 && value[0] == 0x80 {
+			length, rest, err := decodeLength(value[1:])
+			if err == nil && len(rest) == length {
+				password = rest
+			}
+		}
+	}
+
+	public := len(conn.client) == 1 && conn.client[0] == encodeClientAddress(ClientPublic)[0]
+	if !public && string(password) != s.password {
+		return encodeAARE(1, authenticationFailure)
+	}
+
+	conn.associated = true
+
+	return encodeAARE(0, 0)
+}
+
+func encodeAARE(result byte, diagnostic byte) []byte {
+	var content []byte
+
+	content = appendBER(content, 0xA1, appendBER(nil, 0x06, applicationContextLN))
+	content = appendBER(content, 0xA2, []byte{0x02, 0x01, result})
+	content = appendBER(content, 0xA3, appendBER(nil, 0xA1, []byte{0x02, 0x01, diagnostic}))
+
+	if result == 0 {
+		initiate := []byte{0x08, 0x00, dlmsVersion, 0x5F, 0x1F, 0x04, 0x00}
+		initiate = append(initiate, conformance...)
+		initiate = binary.BigEndian.AppendUint16(initiate, simulatorBlockSize)
+		initiate = append(initiate, 0x00, 0x07)
+
+		content = appendBER(content, 0xBE, appendBER(nil, 0x04, initiate))
+	}
+
+	return appendBER(nil, tagAARE, content)
+}
+
+func (s *Simulator) get(conn *simulatorConn, apdu []byte) []byte {
+	invoke := apdu[2]
+
+	switch apdu[1] {
+	case getNormal:
+		if len(apdu) < 13 {
+			return getResponseError(invoke, 250)
+		}
+
+		var attr AttributeDescriptor
+		attr.Class = binary.BigEndian.Uint16(apdu[3:5])
+		copy(attr.OBIS[:], apdu[5:11])
+		attr.Attribute = int8(apdu[11])
+
+		data, code := s.read(attr, apdu[12:])
+		if code != 0 {
+			return getResponseError(invoke, code)
+		}
+
+		s.mu.Lock()
+		blockSize := s.blockSize
+		s.mu.Unlock()
+
+		if len(data) <= blockSize {
+			return append([]byte{tagGetResponse, getNormal, invoke, 0x00}, data...)
+		}
+
+		conn.blocks = nil
+		for len(data) > 0 {
+			n := blockSize
+			if n > len(data) {
+				n = len(data)
+			}
+
+			conn.blocks = append(conn.blocks, data[:n])
+			data = data[n:]
+		}
+		conn.block = 0
+
+		return s.nextBlock(conn, invoke)
+	case getNext:
+		if len(apdu) < 7 || binary.BigEndian.Uint32(apdu[3:7]) != conn.block || len(conn.blocks) == 0 {
+			// data block number invalid
+			return getBlockError(invoke, conn.block, 19)
+		}
+
+		return s.nextBlock(conn, invoke)
+	default:
+		return []byte{tagExceptionResponse, 0x01, 0x02}
+	}
+}
+
+func (s *Simulator) nextBlock(conn *simulatorConn, invoke byte) []byte {
+	block := conn.blocks[0]
+	conn.blocks = conn.blocks[1:]
+	conn.block++
+
+	last := byte(0)
+	if len(conn.blocks) == 0 {
+		last = 1
+	}
+
+	resp := []byte{tagGetResponse, getWithDatablock, invoke, last}
+	resp = binary.BigEndian.AppendUint32(resp, conn.block)
+	resp = append(resp, 0x00)
+	resp = appendLength(resp, len(block))
+
+	return append(resp, block...)
+}
+
+// read returns the encoded value of the attribute or a data access result code.
+func (s *Simulator) read(attr AttributeDescriptor, access []byte) ([]byte, byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[attr]
+	if !ok {
+		// object undefined
+		return nil, 4
+	}
+
+	if attr.Class == ClassProfileGeneric && attr.Attribute == 2 && len(access) > 1 && access[0] == 0x01 {
+		var err error
+
+		value, err = s.selectEntries(attr.OBIS, value.(Array), access[1:])
+		if err != nil {
+			// other reason
+			return nil, 250
+		}
+	}
+
+	data, err := encodeData(nil, value)
+	if err != nil {
+		return nil, 250
+	}
+
+	return data, 0
+}
+
+// selectEntries filters the profile buffer by the range descriptor of the selective access.
+func (s *Simulator) selectEntries(obis OBIS, buffer Array, access []byte) (Array, error) {
+	clock := s.profiles[obis]
+
+	params, _, err := decodeData(access[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, ok := params.(Structure)
+	if !ok || len(descriptor) < 3 || clock < 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	from, err := toTime(descriptor[1])
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := toTime(descriptor[2])
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(Array, 0, len(buffer))
+	for _, entry := range buffer {
+		t, ok := entry.(Structure)[clock].(time.Time)
+		if ok && !t.Before(from) && !t.After(to) {
+			selected = append(selected, entry)
+		}
+	}
+
+	return selected, nil
+}
+
+func getResponseError(invoke byte, code byte) []byte {
+	return []byte{tagGetResponse, getNormal, invoke, 0x01, code}
+}
+
+func getBlockError(invoke byte, block uint32, code byte) []byte {
+	resp := []byte{tagGetResponse, getWithDatablock, invoke, 0x01}
+	resp = binary.BigEndian.AppendUint32(resp, block)
+
+	return append(resp, 0x01, code)
+}
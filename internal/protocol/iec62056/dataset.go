@@ -0,0 +1,65 @@
+package iec62056
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DataSet is a line of the data readout: address(value*unit). Only the first
+// value of lines with several values is kept.
+type DataSet struct {
+	Address string
+	Value   string
+	Unit    string
+}
+
+func (d DataSet) Float() (float64, error) {
+	return strconv.ParseFloat(d.Value, 64)
+}
+
+// parseDataBlock parses the lines of a data block up to the end mark "!".
+func parseDataBlock(data string) ([]DataSet, error) {
+	var result []DataSet
+
+	for _, line := range strings.Split(data, "\r\n") {
+		if line == "" {
+			continue
+		}
+
+		if line == "!" {
+			return result, nil
+		}
+
+		// a line can hold several data sets: 1.8.1(1.0*kWh)1.8.2(2.0*kWh)
+		for line != "" {
+			open := strings.IndexByte(line, '(')
+			end := strings.IndexByte(line, ')')
+			if open < 0 || end < open {
+				return nil, fmt.Errorf("%w: data set \"%s\"", ErrInvalidResponse, line)
+			}
+
+			set := DataSet{Address: line[:open]}
+			set.Value, set.Unit, _ = strings.Cut(line[open+1:end], "*")
+
+			line = line[end+1:]
+			for strings.HasPrefix(line, "(") {
+				end = strings.IndexByte(line, ')')
+				if end < 0 {
+					return nil, fmt.Errorf("%w: data set \"%s\"", ErrInvalidResponse, line)
+				}
+
+				line = line[end+1:]
+			}
+
+			if set.Address == "" && len(result) > 0 {
+				// a value without address continues the previous data set
+				continue
+			}
+
+			result = append(result, set)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package iec62056
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDataBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		sets []DataSet
+		err  error
+	}{
+		{
+			name: "readout",
+			data: "0.0.0(12345678)\r\n1.8.0(001234.5*kWh)\r\n!\r\n",
+			sets: []DataSet{
+				{Address: "0.0.0", Value: "12345678"},
+				{Address: "1.8.0", Value: "001234.5", Unit: "kWh"},
+			},
+		},
+		{
+			name: "several data sets in a line",
+			data: "1.8.1(000100.0*kWh)1.8.2(000200.0*kWh)\r\n!\r\n",
+			sets: []DataSet{
+				{Address: "1.8.1", Value: "000100.0", Unit: "kWh"},
+				{Address: "1.8.2", Value: "000200.0", Unit: "kWh"},
+			},
+		},
+		{
+			name: "several values keep the first one",
+			data: "1.6.0(0001.25*kW)(26-10-18 12:00)\r\n!\r\n",
+			sets: []DataSet{
+				{Address: "1.6.0", Value: "0001.25", Unit: "kW"},
+			},
+		},
+		{
+			name: "value without address continues the data set",
+			data: "32.7.0(230.1*V)\r\n(231.0*V)\r\n!\r\n",
+			sets: []DataSet{
+				{Address: "32.7.0", Value: "230.1", Unit: "V"},
+			},
+		},
+		{
+			name: "lines after the end mark",
+			data: "1.8.0(1*kWh)\r\n!\r\n2.8.0(2*kWh)\r\n",
+			sets: []DataSet{
+				{Address: "1.8.0", Value: "1", Unit: "kWh"},
+			},
+		},
+		{
+			name: "missing end mark",
+			data: "1.8.0(1*kWh)\r\n",
+			sets: []DataSet{
+				{Address: "1.8.0", Value: "1", Unit: "kWh"},
+			},
+		},
+		{
+			name: "unclosed value",
+			data: "1.8.0(1*kWh\r\n!\r\n",
+			err:  ErrInvalidResponse,
+		},
+		{
+			name: "unclosed second value",
+			data: "1.6.0(1*kW)(26-10-18\r\n!\r\n",
+			err:  ErrInvalidResponse,
+		},
+		{
+			name: "line without value",
+			data: "1.8.0\r\n!\r\n",
+			err:  ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets, err := parseDataBlock(tt.data)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(sets) != len(tt.sets) {
+				t.Fatalf("got %+v, want %+v", sets, tt.sets)
+			}
+
+			for i := range sets {
+				if sets[i] != tt.sets[i] {
+					t.Errorf("data set %d: got %+v, want %+v", i, sets[i], tt.sets[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package iec62056
+
+import (
+	"errors"
+)
+
+var (
+	ErrDeviceNotResponding = errors.New("the device is not responding")
+	ErrUnsupportedMode     = errors.New("the device does not support protocol mode C")
+	ErrBCC                 = errors.New("block check character mismatch")
+	ErrInvalidResponse     = errors.New("invalid response")
+)
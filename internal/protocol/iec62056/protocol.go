@@ -0,0 +1,250 @@
+package iec62056
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	stx byte = 0x02
+	etx      = 0x03
+	ack      = 0x06
+)
+
+// SignOnBaudRate is the baud rate of the sign-on sequence in mode C.
+const SignOnBaudRate = 300
+
+// charBits is the length of a 7E1 character on the line: start, 7 data, parity and stop bits.
+const charBits = 10
+
+// modeCBaudRates maps the baud rate identification character to the baud rate.
+var modeCBaudRates = map[byte]int{
+	'0': 300,
+	'1': 600,
+	'2': 1200,
+	'3': 2400,
+	'4': 4800,
+	'5': 9600,
+	'6': 19200,
+}
+
+// Identification is the answer to the sign-on request: /XXXZIdent.
+type Identification struct {
+	Manufacturer string
+	BaudRate     int
+	Model        string
+}
+
+type IEC62056 struct {
+	bus    *bus.Bus
+	policy bus.Policy
+
+	log *zap.Logger
+}
+
+func NewIEC62056(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *IEC62056 {
+	return &IEC62056{
+		bus:    bus,
+		policy: policy,
+		log:    log,
+	}
+}
+
+// Readout signs on the device in mode C, switches to the baud rate proposed by
+// the device and reads the data block. An empty address selects any device,
+// so it is only usable with a single device on the line.
+func (s *IEC62056) Readout(ctx context.Context, address string) (Identification, []DataSet, error) {
+	var (
+		ident Identification
+		data  []DataSet
+	)
+
+	err := s.bus.Request(ctx, s.policy, func(ctx context.Context, tx *bus.Tx) error {
+		var err error
+		ident, data, err = s.readout(ctx, tx, address)
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Identification{}, nil, ErrDeviceNotResponding
+		}
+
+		return Identification{}, nil, err
+	}
+
+	s.log.Debug(
+		"readout",
+		zap.Any("identification", ident),
+		zap.Int("dataSets", len(data)),
+	)
+
+	return ident, data, nil
+}
+
+func (s *IEC62056) readout(ctx context.Context, tx *bus.Tx, address string) (Identification, []DataSet, error) {
+	err := tx.SetBaudRate(SignOnBaudRate)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	err = tx.Write([]byte("/?" + address + "!\r\n"))
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	r := reader{tx: tx}
+
+	line, err := r.readIdentification(ctx)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	ident, baudChar, err := parseIdentification(line)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	// protocol control 0: normal procedure, mode control 0: data readout
+	ackMessage := []byte{ack, '0', baudChar, '0', '\r', '\n'}
+	err = tx.Write(ackMessage)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	// a remote port, e.g. RFC 2217, may still be sending the acknowledgement when
+	// the write returns, switching the baud rate earlier would garble its tail
+	err = waitTransmit(ctx, len(ackMessage), SignOnBaudRate)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	err = tx.SetBaudRate(ident.BaudRate)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	block, err := r.readDataBlock(ctx)
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	data, err := parseDataBlock(string(block))
+	if err != nil {
+		return Identification{}, nil, err
+	}
+
+	return ident, data, nil
+}
+
+// waitTransmit waits until the given number of characters is transmitted at the baud rate.
+func waitTransmit(ctx context.Context, chars int, baudRate int) error {
+	t := time.NewTimer(time.Duration(chars*charBits) * time.Second / time.Duration(baudRate))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func parseIdentification(line []byte) (Identification, byte, error) {
+	if len(line) < 5 || line[0] != '/' {
+		return Identification{}, 0, fmt.Errorf("%w: identification \"%s\"", ErrInvalidResponse, line)
+	}
+
+	baudChar := line[4]
+
+	baudRate, ok := modeCBaudRates[baudChar]
+	if !ok {
+		return Identification{}, 0, ErrUnsupportedMode
+	}
+
+	model := line[5:]
+	if len(model) >= 2 && model[0] == '\\' {
+		// enhanced capability mark \W
+		model = model[2:]
+	}
+
+	return Identification{
+		Manufacturer: string(line[1:4]),
+		BaudRate:     baudRate,
+		Model:        string(model),
+	}, baudChar, nil
+}
+
+type reader struct {
+	tx     *bus.Tx
+	buffer []byte
+}
+
+func (r *reader) fill(ctx context.Context) error {
+	data, err := r.tx.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.buffer = append(r.buffer, data...)
+
+	return nil
+}
+
+// readIdentification returns the identification line without CR LF, bytes before "/" are skipped.
+func (r *reader) readIdentification(ctx context.Context) ([]byte, error) {
+	for {
+		if start := bytes.IndexByte(r.buffer, '/'); start >= 0 {
+			r.buffer = r.buffer[start:]
+
+			if end := bytes.Index(r.buffer, []byte("\r\n")); end >= 0 {
+				line := r.buffer[:end]
+				r.buffer = r.buffer[end+2:]
+
+				return line, nil
+			}
+		} else {
+			r.buffer = r.buffer[:0]
+		}
+
+		err := r.fill(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readDataBlock returns the content of the STX data ETX BCC block and verifies the BCC.
+func (r *reader) readDataBlock(ctx context.Context) ([]byte, error) {
+	for {
+		start := bytes.IndexByte(r.buffer, stx)
+		if start >= 0 {
+			end := bytes.IndexByte(r.buffer[start:], etx)
+			if end >= 0 && start+end+1 < len(r.buffer) {
+				block := r.buffer[start+1 : start+end]
+
+				bcc := byte(etx)
+				for _, b := range block {
+					bcc ^= b
+				}
+
+				// the parity bit is not a part of the data in 7E1 mode
+				if bcc&0x7F != r.buffer[start+end+1]&0x7F {
+					return nil, ErrBCC
+				}
+
+				return block, nil
+			}
+		}
+
+		err := r.fill(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package iec62056
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseIdentification(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		ident    Identification
+		baudChar byte
+		err      error
+	}{
+		{
+			name:     "identification",
+			line:     "/ISk5MT174-0001",
+			ident:    Identification{Manufacturer: "ISk", BaudRate: 9600, Model: "MT174-0001"},
+			baudChar: '5',
+		},
+		{
+			name:     "enhanced capability",
+			line:     "/LGZ4\\2ZMD3104107.B32",
+			ident:    Identification{Manufacturer: "LGZ", BaudRate: 4800, Model: "ZMD3104107.B32"},
+			baudChar: '4',
+		},
+		{
+			name:     "sign-on baud rate",
+			line:     "/EKT0",
+			ident:    Identification{Manufacturer: "EKT", BaudRate: 300},
+			baudChar: '0',
+		},
+		{
+			name: "mode B baud rate",
+			line: "/ABCEmodel",
+			err:  ErrUnsupportedMode,
+		},
+		{
+			name: "short line",
+			line: "/ABC",
+			err:  ErrInvalidResponse,
+		},
+		{
+			name: "missing start character",
+			line: "ISk5MT174",
+			err:  ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ident, baudChar, err := parseIdentification([]byte(tt.line))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ident != tt.ident || baudChar != tt.baudChar {
+				t.Fatalf("got %+v and %c, want %+v and %c", ident, baudChar, tt.ident, tt.baudChar)
+			}
+		})
+	}
+}
+
+// baudRatePort is a pipe end which accepts any baud rate.
+type baudRatePort struct {
+	net.Conn
+}
+
+func (p *baudRatePort) SetBaudRate(int) error {
+	return nil
+}
+
+func (p *baudRatePort) ResetBaudRate() error {
+	return nil
+}
+
+// dataBlock frames the data as STX data ETX BCC.
+func dataBlock(data string) []byte {
+	block := append([]byte{stx}, data...)
+	block = append(block, etx)
+
+	var bcc byte
+	for _, b := range block[1:] {
+		bcc ^= b
+	}
+
+	return append(block, bcc)
+}
+
+// serveReadout answers the sign-on request with the identification and the
+// acknowledgement with the data block.
+func serveReadout(conn net.Conn, ident string, block []byte) {
+	buf := make([]byte, 256)
+
+	for _, answer := range [][]byte{[]byte(ident + "\r\n"), block} {
+		_, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		_, err = conn.Write(answer)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestReadout(t *testing.T) {
+	const data = "0.0.0(12345678)\r\n1.8.0(001234.5*kWh)\r\n!\r\n"
+
+	valid := dataBlock(data)
+
+	parity := append([]byte(nil), valid...)
+	parity[len(parity)-1] |= 0x80
+
+	corrupted := append([]byte(nil), valid...)
+	corrupted[len(corrupted)-1] ^= 0x01
+
+	tests := []struct {
+		name  string
+		block []byte
+		err   error
+	}{
+		{"valid BCC", valid, nil},
+		{"BCC with parity bit", parity, nil},
+		{"junk before the block", append([]byte{0x00, 0x7F}, valid...), nil},
+		{"BCC mismatch", corrupted, ErrBCC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, device := net.Pipe()
+			defer device.Close()
+
+			go serveReadout(device, "/ISk5MT174-0001", tt.block)
+
+			policy := bus.Policy{Timeout: 2 * time.Second}
+			b := bus.NewBus(&baudRatePort{Conn: client}, 0, policy, zap.NewNop())
+			defer client.Close()
+
+			ident, sets, err := NewIEC62056(b, policy, zap.NewNop()).Readout(context.Background(), "")
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ident.BaudRate != 9600 || ident.Model != "MT174-0001" {
+				t.Errorf("got identification %+v", ident)
+			}
+
+			if len(sets) != 2 || sets[1].Address != "1.8.0" || sets[1].Value != "001234.5" {
+				t.Errorf("got data sets %+v", sets)
+			}
+		})
+	}
+}
+
+func TestReadoutAcknowledgesBaudRate(t *testing.T) {
+	client, device := net.Pipe()
+	defer device.Close()
+
+	acked := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 256)
+
+		_, _ = device.Read(buf)
+		_, _ = device.Write([]byte("/ISk3MT174\r\n"))
+
+		n, _ := device.Read(buf)
+		acked <- append([]byte(nil), buf[:n]...)
+
+		_, _ = device.Write(dataBlock("!\r\n"))
+	}()
+
+	policy := bus.Policy{Timeout: 2 * time.Second}
+	b := bus.NewBus(&baudRatePort{Conn: client}, 0, policy, zap.NewNop())
+	defer client.Close()
+
+	_, _, err := NewIEC62056(b, policy, zap.NewNop()).Readout(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message := <-acked; !bytes.Equal(message, []byte{ack, '0', '3', '0', '\r', '\n'}) {
+		t.Fatalf("got acknowledgement % X", message)
+	}
+}
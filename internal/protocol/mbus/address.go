@@ -0,0 +1,128 @@
+package mbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	idDigits        = 8
+	wildcardDigit   = 'F'
+	wildcardID      = "FFFFFFFF"
+	wildcardVersion = 0xFF
+	wildcardMedium  = 0xFF
+	wildcardVendor  = 0xFFFF
+)
+
+// Medium codes of the device identification.
+const (
+	MediumOther         byte = 0x00
+	MediumElectricity        = 0x02
+	MediumGas                = 0x03
+	MediumHeatOutlet         = 0x04
+	MediumWarmWater          = 0x06
+	MediumWater              = 0x07
+	MediumHeatCostAlloc      = 0x08
+	MediumHeatInlet          = 0x0C
+	MediumHeatCooling        = 0x0D
+	MediumHotWater           = 0x15
+	MediumColdWater          = 0x16
+)
+
+// SecondaryAddress identifies a device on the bus regardless of its primary address.
+type SecondaryAddress struct {
+	// ID is the identification number of 8 digits, F digits match any digit in selections.
+	ID           string
+	Manufacturer uint16
+	Version      byte
+	Medium       byte
+}
+
+// ParseSecondaryAddress parses the identification number optionally followed by the manufacturer,
+// version and medium in hex: 12345678 or 12345678A5110107, omitted fields match any device.
+func ParseSecondaryAddress(s string) (SecondaryAddress, error) {
+	s = strings.ToUpper(s)
+
+	if len(s) != idDigits && len(s) != idDigits+8 {
+		return SecondaryAddress{}, fmt.Errorf("invalid secondary address \"%s\"", s)
+	}
+
+	for _, c := range s[:idDigits] {
+		if (c < '0' || c > '9') && c != wildcardDigit {
+			return SecondaryAddress{}, fmt.Errorf("invalid secondary address \"%s\"", s)
+		}
+	}
+
+	address := SecondaryAddress{
+		ID:           s[:idDigits],
+		Manufacturer: wildcardVendor,
+		Version:      wildcardVersion,
+		Medium:       wildcardMedium,
+	}
+
+	if len(s) == idDigits {
+		return address, nil
+	}
+
+	rest, err := strconv.ParseUint(s[idDigits:], 16, 32)
+	if err != nil {
+		return SecondaryAddress{}, fmt.Errorf("invalid secondary address \"%s\"", s)
+	}
+
+	address.Manufacturer = uint16(rest >> 16)
+	address.Version = byte(rest >> 8)
+	address.Medium = byte(rest)
+
+	return address, nil
+}
+
+func (a SecondaryAddress) String() string {
+	return fmt.Sprintf("%s%04X%02X%02X", a.ID, a.Manufacturer, a.Version, a.Medium)
+}
+
+// ManufacturerCode returns the three letter manufacturer code, e.g. ZRI.
+func (a SecondaryAddress) ManufacturerCode() string {
+	return string([]byte{
+		byte(a.Manufacturer>>10&0x1F) + 64,
+		byte(a.Manufacturer>>5&0x1F) + 64,
+		byte(a.Manufacturer&0x1F) + 64,
+	})
+}
+
+// encode returns the address as it is sent: BCD identification, manufacturer, version and medium.
+func (a SecondaryAddress) encode() []byte {
+	b := make([]byte, 0, 8)
+	for i := idDigits - 2; i >= 0; i -= 2 {
+		b = append(b, digitValue(a.ID[i])<<4|digitValue(a.ID[i+1]))
+	}
+
+	b = binary.LittleEndian.AppendUint16(b, a.Manufacturer)
+
+	return append(b, a.Version, a.Medium)
+}
+
+// DecodeSecondaryAddress decodes the identification of the variable data header:
+// identification number, manufacturer, version and medium.
+func DecodeSecondaryAddress(data []byte) SecondaryAddress {
+	var id strings.Builder
+	for i := 3; i >= 0; i-- {
+		fmt.Fprintf(&id, "%02X", data[i])
+	}
+
+	return SecondaryAddress{
+		ID:           id.String(),
+		Manufacturer: binary.LittleEndian.Uint16(data[4:6]),
+		Version:      data[6],
+		Medium:       data[7],
+	}
+}
+
+func digitValue(c byte) byte {
+	if c == wildcardDigit {
+		return 0x0F
+	}
+
+	return c - '0'
+}
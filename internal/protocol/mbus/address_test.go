@@ -0,0 +1,121 @@
+package mbus
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseSecondaryAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		address SecondaryAddress
+		err     bool
+	}{
+		{
+			name:    "identification number",
+			s:       "12345678",
+			address: SecondaryAddress{ID: "12345678", Manufacturer: wildcardVendor, Version: wildcardVersion, Medium: wildcardMedium},
+		},
+		{
+			name:    "full address",
+			s:       "12345678a5110107",
+			address: SecondaryAddress{ID: "12345678", Manufacturer: 0xA511, Version: 0x01, Medium: MediumWater},
+		},
+		{
+			name:    "wildcard digits",
+			s:       "1234ffff",
+			address: SecondaryAddress{ID: "1234FFFF", Manufacturer: wildcardVendor, Version: wildcardVersion, Medium: wildcardMedium},
+		},
+		{name: "short", s: "1234567", err: true},
+		{name: "hex digit in the identification number", s: "1234567A", err: true},
+		{name: "invalid manufacturer", s: "12345678XY110107", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := ParseSecondaryAddress(tt.s)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", address)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if address != tt.address {
+				t.Fatalf("got %+v, want %+v", address, tt.address)
+			}
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		s         string
+		primary   byte
+		secondary string
+		err       bool
+	}{
+		{s: "0", primary: 0},
+		{s: " 250 ", primary: 250},
+		{s: "251", err: true},
+		{s: "-1", err: true},
+		{s: "12345678", secondary: "12345678FFFFFFFF"},
+		{s: "1234567", err: true},
+	}
+
+	for _, tt := range tests {
+		primary, secondary, err := ParseAddress(tt.s)
+		if tt.err {
+			if err == nil {
+				t.Errorf("\"%s\": want an error", tt.s)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("\"%s\": %v", tt.s, err)
+			continue
+		}
+
+		if tt.secondary == "" {
+			if secondary != nil || primary != tt.primary {
+				t.Errorf("\"%s\": got %d and %v, want primary %d", tt.s, primary, secondary, tt.primary)
+			}
+
+			continue
+		}
+
+		if secondary == nil || secondary.String() != tt.secondary {
+			t.Errorf("\"%s\": got %v, want secondary %s", tt.s, secondary, tt.secondary)
+		}
+	}
+}
+
+func TestSecondaryAddressEncoding(t *testing.T) {
+	address := SecondaryAddress{ID: "12345678", Manufacturer: 0x6A49, Version: 0x01, Medium: MediumWater}
+	encoded := []byte{0x78, 0x56, 0x34, 0x12, 0x49, 0x6A, 0x01, 0x07}
+
+	if b := address.encode(); !bytes.Equal(b, encoded) {
+		t.Fatalf("encode: got % X, want % X", b, encoded)
+	}
+
+	if decoded := DecodeSecondaryAddress(encoded); decoded != address {
+		t.Fatalf("decode: got %+v, want %+v", decoded, address)
+	}
+
+	if code := address.ManufacturerCode(); code != "ZRI" {
+		t.Fatalf("manufacturer: got %s, want ZRI", code)
+	}
+
+	wildcard := SecondaryAddress{ID: "12FFFFFF", Manufacturer: wildcardVendor, Version: wildcardVersion, Medium: wildcardMedium}
+	if b := wildcard.encode(); !bytes.Equal(b, []byte{0xFF, 0xFF, 0xFF, 0x12, 0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Fatalf("encode wildcards: got % X", b)
+	}
+}
@@ -0,0 +1,490 @@
+package mbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+const headerLen = 12
+
+// Function is the function field of the DIF.
+type Function byte
+
+const (
+	FunctionInstantaneous Function = iota
+	FunctionMaximum
+	FunctionMinimum
+	FunctionError
+)
+
+// Quantity is the physical quantity of a record decoded from the VIF.
+type Quantity string
+
+// Values are normalized to the units noted.
+const (
+	QuantityUnknown               Quantity = ""
+	QuantityEnergy                         = "energy"                 // kWh
+	QuantityVolume                         = "volume"                 // m³
+	QuantityMass                           = "mass"                   // kg
+	QuantityOnTime                         = "on-time"                // h
+	QuantityOperatingTime                  = "operating-time"         // h
+	QuantityPower                          = "power"                  // W
+	QuantityVolumeFlow                     = "volume-flow"            // m³/h
+	QuantityMassFlow                       = "mass-flow"              // kg/h
+	QuantityFlowTemperature                = "flow-temperature"       // °C
+	QuantityReturnTemperature              = "return-temperature"     // °C
+	QuantityTemperatureDifference          = "temperature-difference" // K
+	QuantityExternalTemperature            = "external-temperature"   // °C
+	QuantityPressure                       = "pressure"               // bar
+	QuantityTimePoint                      = "time-point"
+	QuantityFabricationNumber              = "fabrication-number"
+	QuantityVoltage                        = "voltage"     // V
+	QuantityCurrent                        = "current"     // A
+	QuantityErrorFlags                     = "error-flags" // bits
+)
+
+const (
+	kWhPerJoule = 1 / 3.6e6
+	kWhPerGJ    = 1e9 * kWhPerJoule
+)
+
+// Data is the response to REQ_UD2 with the variable data structure.
+type Data struct {
+	Address      SecondaryAddress
+	AccessNumber byte
+	// Status holds application errors, power low, permanent and temporary error bits.
+	Status  byte
+	Records []Record
+	// MoreRecords is set when the device has more records for the next request.
+	MoreRecords bool
+}
+
+// Record is a variable data record. Value holds numeric values normalized to the unit of the quantity,
+// Time holds time points of types F, G and I, Text holds strings and Data holds the raw value.
+type Record struct {
+	Function Function
+	Storage  uint64
+	Tariff   uint64
+	Subunit  uint64
+	Quantity Quantity
+	Value    float64
+	Time     time.Time
+	Text     string
+	Data     []byte
+}
+
+type vifInfo struct {
+	quantity Quantity
+	// scale converts the raw value to the unit of the quantity.
+	scale float64
+}
+
+func parseData(ci byte, data []byte) (Data, error) {
+	if ci != ciVariableData {
+		return Data{}, fmt.Errorf("%w: 0x%02X", ErrUnsupportedCI, ci)
+	}
+
+	if len(data) < headerLen {
+		return Data{}, fmt.Errorf("%w: header is truncated", ErrInvalidResponse)
+	}
+
+	records, more, err := ParseRecords(data[headerLen:])
+	if err != nil {
+		return Data{}, err
+	}
+
+	return Data{
+		Address:      DecodeSecondaryAddress(data[:8]),
+		AccessNumber: data[8],
+		Status:       data[9],
+		Records:      records,
+		MoreRecords:  more,
+	}, nil
+}
+
+// ParseRecords parses the data records following the header, wireless M-Bus telegrams carry them too.
+// It reports whether the device has more records to send.
+func ParseRecords(data []byte) ([]Record, bool, error) {
+	var records []Record
+
+	for len(data) > 0 {
+		dif := data[0]
+
+		switch dif {
+		case 0x0F, 0x1F:
+			// manufacturer specific data up to the end
+			return records, dif == 0x1F, nil
+		case 0x2F:
+			// idle filler
+			data = data[1:]
+			continue
+		}
+
+		record, rest, err := parseRecord(data)
+		if err != nil {
+			return nil, false, err
+		}
+
+		records = append(records, record)
+		data = rest
+	}
+
+	return records, false, nil
+}
+
+func parseRecord(data []byte) (Record, []byte, error) {
+	dif := data[0]
+	data = data[1:]
+
+	record := Record{
+		Function: Function(dif >> 4 & 0x03),
+		Storage:  uint64(dif >> 6 & 0x01),
+	}
+
+	// DIFEs extend storage number, tariff and subunit
+	ext := dif&0x80 > 0
+	for i := 0; ext; i++ {
+		if len(data) == 0 || i >= 10 {
+			return Record{}, nil, fmt.Errorf("%w: DIFE is truncated", ErrInvalidResponse)
+		}
+
+		dife := data[0]
+		data = data[1:]
+
+		record.Storage |= uint64(dife&0x0F) << (1 + 4*i)
+		record.Tariff |= uint64(dife>>4&0x03) << (2 * i)
+		record.Subunit |= uint64(dife>>6&0x01) << i
+		ext = dife&0x80 > 0
+	}
+
+	info, data, err := parseVIF(data)
+	if err != nil {
+		return Record{}, nil, err
+	}
+
+	record.Quantity = info.quantity
+
+	var lvar byte
+
+	length := dataLengths[dif&0x0F]
+	if dif&0x0F == 0x0D {
+		if len(data) == 0 {
+			return Record{}, nil, fmt.Errorf("%w: LVAR is truncated", ErrInvalidResponse)
+		}
+
+		lvar = data[0]
+		length = variableLength(lvar)
+		data = data[1:]
+	}
+
+	if len(data) < length {
+		return Record{}, nil, fmt.Errorf("%w: record is truncated", ErrInvalidResponse)
+	}
+
+	record.Data = append([]byte(nil), data[:length]...)
+	value := data[:length]
+	data = data[length:]
+
+	if info.quantity == QuantityTimePoint {
+		// time points of other types keep the raw value in Data only
+		record.Time, _ = decodeTimePoint(value)
+
+		return record, data, nil
+	}
+
+	raw, text, err := decodeValue(dif&0x0F, lvar, value)
+	if err != nil {
+		return Record{}, nil, err
+	}
+
+	record.Text = text
+	record.Value = raw * info.scale
+
+	return record, data, nil
+}
+
+// dataLengths are the value lengths by the data field of the DIF, variable length is 0.
+var dataLengths = [16]int{0, 1, 2, 3, 4, 4, 6, 8, 0, 1, 2, 3, 4, 0, 6, 0}
+
+// variableLength returns the value length of the LVAR: text below 0xC0, otherwise the low nibble
+// is the length of a positive or negative BCD, a binary number or a floating point number.
+func variableLength(lvar byte) int {
+	if lvar < 0xC0 {
+		return int(lvar)
+	}
+
+	return int(lvar & 0x0F)
+}
+
+// decodeValue decodes integers, reals and BCD into a number, variable length text into text.
+func decodeValue(field byte, lvar byte, value []byte) (float64, string, error) {
+	switch field {
+	case 0x00, 0x08:
+		return 0, "", nil
+	case 0x01, 0x02, 0x03, 0x04, 0x06, 0x07:
+		return float64(decodeInt(value)), "", nil
+	case 0x05:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(value))), "", nil
+	case 0x09, 0x0A, 0x0B, 0x0C, 0x0E:
+		v, err := decodeBCD(value)
+
+		return v, "", err
+	case 0x0D:
+		switch {
+		case lvar >= 0xC0 && lvar <= 0xDF:
+			v, err := decodeBCD(value)
+			if lvar >= 0xD0 {
+				v = -v
+			}
+
+			return v, "", err
+		case lvar >= 0xE0 && lvar <= 0xEF && len(value) <= 8:
+			return float64(decodeInt(value)), "", nil
+		case lvar >= 0xF0:
+			return 0, "", nil
+		}
+
+		text := make([]byte, len(value))
+		for i, b := range value {
+			text[len(value)-1-i] = b
+		}
+
+		return 0, string(text), nil
+	default:
+		return 0, "", fmt.Errorf("%w: data field 0x%X", ErrInvalidResponse, field)
+	}
+}
+
+// decodeInt decodes a little-endian two's complement integer.
+func decodeInt(value []byte) int64 {
+	var v uint64
+	for i := len(value) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(value[i])
+	}
+
+	shift := 64 - 8*len(value)
+
+	return int64(v<<shift) >> shift
+}
+
+// decodeBCD decodes little-endian BCD, an F in the most significant digit makes it negative.
+func decodeBCD(value []byte) (float64, error) {
+	var (
+		v        float64
+		negative bool
+	)
+
+	for i := len(value) - 1; i >= 0; i-- {
+		for _, digit := range []byte{value[i] >> 4, value[i] & 0x0F} {
+			if digit == 0x0F && i == len(value)-1 && v == 0 && !negative {
+				negative = true
+				continue
+			}
+
+			if digit > 9 {
+				return 0, fmt.Errorf("%w: invalid BCD % X", ErrInvalidResponse, value)
+			}
+
+			v = v*10 + float64(digit)
+		}
+	}
+
+	if negative {
+		return -v, nil
+	}
+
+	return v, nil
+}
+
+// decodeTimePoint decodes the date of type G, the date and time of type F and
+// the date and time with seconds of type I. It reports false for other types.
+func decodeTimePoint(value []byte) (time.Time, bool) {
+	switch len(value) {
+	case 2:
+		day := int(value[0] & 0x1F)
+		month := time.Month(value[1] & 0x0F)
+		year := 2000 + int(value[0]>>5|value[1]>>4<<3)
+
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local), true
+	case 4:
+		minute := int(value[0] & 0x3F)
+		hour := int(value[1] & 0x1F)
+		day := int(value[2] & 0x1F)
+		month := time.Month(value[3] & 0x0F)
+		year := 2000 + int(value[2]>>5|value[3]>>4<<3)
+
+		return time.Date(year, month, day, hour, minute, 0, 0, time.Local), true
+	case 6:
+		// the day of week in the hour byte and the week number in the last one are not needed
+		second := int(value[0] & 0x3F)
+		minute := int(value[1] & 0x3F)
+		hour := int(value[2] & 0x1F)
+		day := int(value[3] & 0x1F)
+		month := time.Month(value[4] & 0x0F)
+		year := 2000 + int(value[3]>>5|value[4]>>4<<3)
+
+		return time.Date(year, month, day, hour, minute, second, 0, time.Local), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// parseVIF decodes the VIF and skips its VIFEs, the combinable VIFEs are not applied.
+func parseVIF(data []byte) (vifInfo, []byte, error) {
+	if len(data) == 0 {
+		return vifInfo{}, nil, fmt.Errorf("%w: VIF is truncated", ErrInvalidResponse)
+	}
+
+	vif := data[0]
+	data = data[1:]
+
+	var (
+		info      vifInfo
+		plainText bool
+	)
+
+	switch vif & 0x7F {
+	case 0x7B, 0x7D:
+		if vif&0x80 == 0 || len(data) == 0 {
+			return vifInfo{}, nil, fmt.Errorf("%w: VIFE is truncated", ErrInvalidResponse)
+		}
+
+		vife := data[0]
+		data = data[1:]
+
+		if vif&0x7F == 0x7B {
+			info = decodeVIFExtensionFB(vife & 0x7F)
+		} else {
+			info = decodeVIFExtensionFD(vife & 0x7F)
+		}
+
+		vif = vife
+	case 0x7C:
+		info = vifInfo{QuantityUnknown, 1}
+		plainText = true
+	default:
+		info = decodePrimaryVIF(vif & 0x7F)
+	}
+
+	for vif&0x80 > 0 {
+		if len(data) == 0 {
+			return vifInfo{}, nil, fmt.Errorf("%w: VIFE is truncated", ErrInvalidResponse)
+		}
+
+		vif = data[0]
+		data = data[1:]
+	}
+
+	if plainText {
+		// the unit is the ASCII string following the VIFEs
+		if len(data) == 0 || len(data) < int(data[0])+1 {
+			return vifInfo{}, nil, fmt.Errorf("%w: plain text VIF is truncated", ErrInvalidResponse)
+		}
+
+		data = data[data[0]+1:]
+	}
+
+	return info, data, nil
+}
+
+func decodePrimaryVIF(vif byte) vifInfo {
+	n := int(vif & 0x07)
+	nn := int(vif & 0x03)
+
+	switch {
+	case vif <= 0x07:
+		return vifInfo{QuantityEnergy, pow10(n-3) / 1000}
+	case vif <= 0x0F:
+		return vifInfo{QuantityEnergy, pow10(n) * kWhPerJoule}
+	case vif <= 0x17:
+		return vifInfo{QuantityVolume, pow10(n - 6)}
+	case vif <= 0x1F:
+		return vifInfo{QuantityMass, pow10(n - 3)}
+	case vif <= 0x23:
+		return vifInfo{QuantityOnTime, timeScale(nn)}
+	case vif <= 0x27:
+		return vifInfo{QuantityOperatingTime, timeScale(nn)}
+	case vif <= 0x2F:
+		return vifInfo{QuantityPower, pow10(n - 3)}
+	case vif <= 0x37:
+		return vifInfo{QuantityPower, pow10(n) * kWhPerJoule * 1000}
+	case vif <= 0x3F:
+		return vifInfo{QuantityVolumeFlow, pow10(n - 6)}
+	case vif <= 0x47:
+		return vifInfo{QuantityVolumeFlow, pow10(n-7) * 60}
+	case vif <= 0x4F:
+		return vifInfo{QuantityVolumeFlow, pow10(n-9) * 3600}
+	case vif <= 0x57:
+		return vifInfo{QuantityMassFlow, pow10(n - 3)}
+	case vif <= 0x5B:
+		return vifInfo{QuantityFlowTemperature, pow10(nn - 3)}
+	case vif <= 0x5F:
+		return vifInfo{QuantityReturnTemperature, pow10(nn - 3)}
+	case vif <= 0x63:
+		return vifInfo{QuantityTemperatureDifference, pow10(nn - 3)}
+	case vif <= 0x67:
+		return vifInfo{QuantityExternalTemperature, pow10(nn - 3)}
+	case vif <= 0x6B:
+		return vifInfo{QuantityPressure, pow10(nn - 3)}
+	case vif <= 0x6D:
+		return vifInfo{QuantityTimePoint, 1}
+	case vif == 0x78:
+		return vifInfo{QuantityFabricationNumber, 1}
+	default:
+		return vifInfo{QuantityUnknown, 1}
+	}
+}
+
+func decodeVIFExtensionFB(vife byte) vifInfo {
+	n := int(vife & 0x01)
+
+	switch {
+	case vife <= 0x01:
+		return vifInfo{QuantityEnergy, pow10(n-1) * 1000}
+	case vife >= 0x08 && vife <= 0x09:
+		return vifInfo{QuantityEnergy, pow10(n-1) * kWhPerGJ}
+	case vife >= 0x10 && vife <= 0x11:
+		return vifInfo{QuantityVolume, pow10(n + 2)}
+	case vife >= 0x28 && vife <= 0x29:
+		return vifInfo{QuantityPower, pow10(n-1) * 1e6}
+	case vife >= 0x30 && vife <= 0x31:
+		return vifInfo{QuantityPower, pow10(n-1) * kWhPerGJ * 1000}
+	default:
+		return vifInfo{QuantityUnknown, 1}
+	}
+}
+
+func decodeVIFExtensionFD(vife byte) vifInfo {
+	n := int(vife & 0x0F)
+
+	switch {
+	case vife == 0x17:
+		return vifInfo{QuantityErrorFlags, 1}
+	case vife >= 0x40 && vife <= 0x4F:
+		return vifInfo{QuantityVoltage, pow10(n - 9)}
+	case vife >= 0x50 && vife <= 0x5F:
+		return vifInfo{QuantityCurrent, pow10(n - 12)}
+	default:
+		return vifInfo{QuantityUnknown, 1}
+	}
+}
+
+// timeScale converts seconds, minutes, hours or days to hours.
+func timeScale(unit int) float64 {
+	switch unit {
+	case 0:
+		return 1.0 / 3600
+	case 1:
+		return 1.0 / 60
+	case 2:
+		return 1
+	default:
+		return 24
+	}
+}
+
+func pow10(n int) float64 {
+	return math.Pow10(n)
+}
@@ -0,0 +1,263 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		record Record
+		more   bool
+		err    error
+	}{
+		{
+			name:   "energy in Wh",
+			data:   []byte{0x04, 0x03, 0x10, 0x27, 0x00, 0x00},
+			record: Record{Quantity: QuantityEnergy, Value: 10},
+		},
+		{
+			name:   "volume in BCD",
+			data:   []byte{0x0C, 0x13, 0x78, 0x56, 0x34, 0x12},
+			record: Record{Quantity: QuantityVolume, Value: 12345.678},
+		},
+		{
+			name:   "negative BCD",
+			data:   []byte{0x0A, 0x5A, 0x45, 0xF1},
+			record: Record{Quantity: QuantityFlowTemperature, Value: -14.5},
+		},
+		{
+			name:   "real",
+			data:   []byte{0x05, 0x2B, 0x00, 0x00, 0xC0, 0x3F},
+			record: Record{Quantity: QuantityPower, Value: 1.5},
+		},
+		{
+			name:   "negative integer",
+			data:   []byte{0x02, 0x61, 0xFE, 0xFF},
+			record: Record{Quantity: QuantityTemperatureDifference, Value: -0.02},
+		},
+		{
+			name:   "function and storage of the DIF",
+			data:   []byte{0x54, 0x13, 0x01, 0x00, 0x00, 0x00},
+			record: Record{Function: FunctionMaximum, Storage: 1, Quantity: QuantityVolume, Value: 0.001},
+		},
+		{
+			name:   "tariff of the DIFE",
+			data:   []byte{0x84, 0x10, 0x03, 0x01, 0x00, 0x00, 0x00},
+			record: Record{Tariff: 1, Quantity: QuantityEnergy, Value: 0.001},
+		},
+		{
+			name:   "storage and subunit of two DIFEs",
+			data:   []byte{0xC4, 0x81, 0x41, 0x03, 0x01, 0x00, 0x00, 0x00},
+			record: Record{Storage: 1 | 1<<1 | 1<<5, Subunit: 2, Quantity: QuantityEnergy, Value: 0.001},
+		},
+		{
+			name:   "energy in MWh of the FB extension",
+			data:   []byte{0x04, 0xFB, 0x00, 0x05, 0x00, 0x00, 0x00},
+			record: Record{Quantity: QuantityEnergy, Value: 500},
+		},
+		{
+			name:   "voltage of the FD extension",
+			data:   []byte{0x02, 0xFD, 0x48, 0xFD, 0x08},
+			record: Record{Quantity: QuantityVoltage, Value: 230.1},
+		},
+		{
+			name:   "combinable VIFE is skipped",
+			data:   []byte{0x04, 0x93, 0x3C, 0x01, 0x00, 0x00, 0x00},
+			record: Record{Quantity: QuantityVolume, Value: 0.001},
+		},
+		{
+			name:   "variable length text",
+			data:   []byte{0x0D, 0xFD, 0x0C, 0x03, 'C', 'B', 'A'},
+			record: Record{Text: "ABC"},
+		},
+		{
+			name:   "variable length negative BCD",
+			data:   []byte{0x0D, 0x13, 0xD2, 0x34, 0x12},
+			record: Record{Quantity: QuantityVolume, Value: -1.234},
+		},
+		{
+			name:   "fillers and more records",
+			data:   []byte{0x2F, 0x2F, 0x04, 0x13, 0x01, 0x00, 0x00, 0x00, 0x1F, 0x01},
+			record: Record{Quantity: QuantityVolume, Value: 0.001},
+			more:   true,
+		},
+		{
+			name: "truncated value",
+			data: []byte{0x04, 0x13, 0x01, 0x02},
+			err:  ErrInvalidResponse,
+		},
+		{
+			name: "truncated DIFE",
+			data: []byte{0x84},
+			err:  ErrInvalidResponse,
+		},
+		{
+			name: "truncated VIFE",
+			data: []byte{0x04, 0x93},
+			err:  ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, more, err := ParseRecords(tt.data)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+
+			got, want := records[0], tt.record
+			if got.Function != want.Function || got.Storage != want.Storage || got.Tariff != want.Tariff ||
+				got.Subunit != want.Subunit || got.Quantity != want.Quantity || got.Text != want.Text {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+
+			if math.Abs(got.Value-want.Value) > 1e-9 {
+				t.Errorf("value: got %v, want %v", got.Value, want.Value)
+			}
+
+			if more != tt.more {
+				t.Errorf("more records: got %t, want %t", more, tt.more)
+			}
+		})
+	}
+}
+
+func TestTimePoints(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		time time.Time
+	}{
+		{
+			name: "type G",
+			data: []byte{0x02, 0x6C, 0x52, 0x3A},
+			time: time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name: "type F",
+			data: []byte{0x04, 0x6D, 0x1E, 0x0C, 0x52, 0x3A},
+			time: time.Date(2026, 10, 18, 12, 30, 0, 0, time.Local),
+		},
+		{
+			name: "type I",
+			data: []byte{0x06, 0x6D, 0x2D, 0x1E, 0xEC, 0x52, 0x3A, 0x2A},
+			time: time.Date(2026, 10, 18, 12, 30, 45, 0, time.Local),
+		},
+		{
+			name: "unknown type",
+			data: []byte{0x03, 0x6D, 0x01, 0x02, 0x03},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, _, err := ParseRecords(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != 1 || records[0].Quantity != QuantityTimePoint {
+				t.Fatalf("got %+v, want a time point", records)
+			}
+
+			if !records[0].Time.Equal(tt.time) {
+				t.Errorf("got %s, want %s", records[0].Time, tt.time)
+			}
+
+			if !bytes.Equal(records[0].Data, tt.data[2:]) {
+				t.Errorf("data: got % X, want % X", records[0].Data, tt.data[2:])
+			}
+		})
+	}
+}
+
+func TestDecodePrimaryVIF(t *testing.T) {
+	tests := []struct {
+		vif      byte
+		quantity Quantity
+		scale    float64
+	}{
+		{0x03, QuantityEnergy, 1e-3},
+		{0x07, QuantityEnergy, 10},
+		{0x0E, QuantityEnergy, 1e6 / 3.6e6},
+		{0x13, QuantityVolume, 1e-3},
+		{0x16, QuantityVolume, 1},
+		{0x1B, QuantityMass, 1},
+		{0x20, QuantityOnTime, 1.0 / 3600},
+		{0x26, QuantityOperatingTime, 1},
+		{0x27, QuantityOperatingTime, 24},
+		{0x2B, QuantityPower, 1},
+		{0x3B, QuantityVolumeFlow, 1e-3},
+		{0x43, QuantityVolumeFlow, 1e-4 * 60},
+		{0x5A, QuantityFlowTemperature, 0.1},
+		{0x5F, QuantityReturnTemperature, 1},
+		{0x62, QuantityTemperatureDifference, 0.1},
+		{0x67, QuantityExternalTemperature, 1},
+		{0x68, QuantityPressure, 1e-3},
+		{0x6C, QuantityTimePoint, 1},
+		{0x78, QuantityFabricationNumber, 1},
+		{0x7F, QuantityUnknown, 1},
+	}
+
+	for _, tt := range tests {
+		info := decodePrimaryVIF(tt.vif)
+
+		if info.quantity != tt.quantity || math.Abs(info.scale-tt.scale) > 1e-12 {
+			t.Errorf("VIF 0x%02X: got %s × %v, want %s × %v", tt.vif, info.quantity, info.scale, tt.quantity, tt.scale)
+		}
+	}
+}
+
+func TestDecodeBCD(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  float64
+		err   bool
+	}{
+		{"positive", []byte{0x21, 0x43}, 4321, false},
+		{"negative", []byte{0x45, 0xF1}, -145, false},
+		{"negative with zero digit", []byte{0x21, 0xF0}, -21, false},
+		{"F below the most significant digit", []byte{0xF1, 0x01}, 0, true},
+		{"invalid digit", []byte{0x1A}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := decodeBCD(tt.value)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %v, want an error", v)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v != tt.want {
+				t.Fatalf("got %v, want %v", v, tt.want)
+			}
+		})
+	}
+}
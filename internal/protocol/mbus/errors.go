@@ -0,0 +1,12 @@
+package mbus
+
+import (
+	"errors"
+)
+
+var (
+	ErrDeviceNotResponding = errors.New("the device is not responding")
+	ErrCollision           = errors.New("several devices answered at once")
+	ErrUnsupportedCI       = errors.New("unsupported control information field")
+	ErrInvalidResponse     = errors.New("invalid response")
+)
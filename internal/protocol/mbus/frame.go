@@ -0,0 +1,162 @@
+package mbus
+
+const (
+	frameAck   byte = 0xE5
+	frameShort      = 0x10
+	frameLong       = 0x68
+	frameStop       = 0x16
+)
+
+// C field values of the master.
+const (
+	cSndNke byte = 0x40
+	cSndUd       = 0x53
+	cReqUd2      = 0x5B
+	// cFCB is the frame count bit of SND_UD and REQ_UD2.
+	cFCB = 0x20
+)
+
+// CI field values.
+const (
+	ciSelect       byte = 0x52
+	ciVariableData      = 0x72
+)
+
+const (
+	// AddressNetworkLayer addresses the device selected by its secondary address.
+	AddressNetworkLayer byte = 0xFD
+	// AddressBroadcast addresses all devices, they must not answer.
+	AddressBroadcast byte = 0xFF
+	// MaxPrimaryAddress is the highest primary address of a device.
+	MaxPrimaryAddress byte = 250
+)
+
+type frame struct {
+	// ack is set for the single character acknowledgement.
+	ack     bool
+	control byte
+	address byte
+	ci      byte
+	data    []byte
+}
+
+func encodeShortFrame(control byte, address byte) []byte {
+	return []byte{frameShort, control, address, control + address, frameStop}
+}
+
+func encodeLongFrame(control byte, address byte, ci byte, data []byte) []byte {
+	length := byte(len(data) + 3)
+
+	b := make([]byte, 0, len(data)+9)
+	b = append(b, frameLong, length, length, frameLong, control, address, ci)
+	b = append(b, data...)
+
+	return append(b, calculateChecksum(b[4:]), frameStop)
+}
+
+// decoder extracts frames from a byte stream.
+type decoder struct {
+	buffer []byte
+
+	droppedBytes uint64
+	crcErrors    uint64
+}
+
+func (d *decoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+// next returns the next valid frame or errNeedMoreBytes if the buffer holds none.
+func (d *decoder) next() (frame, error) {
+	for {
+		if len(d.buffer) < 1 {
+			return frame{}, errNeedMoreBytes
+		}
+
+		switch d.buffer[0] {
+		case frameAck:
+			d.buffer = d.buffer[1:]
+
+			return frame{ack: true}, nil
+		case frameShort:
+			if len(d.buffer) < 5 {
+				return frame{}, errNeedMoreBytes
+			}
+
+			window := d.buffer[:5]
+			if window[4] != frameStop {
+				d.skip()
+				continue
+			}
+
+			if calculateChecksum(window[1:3]) != window[3] {
+				d.crcErrors++
+				d.skip()
+				continue
+			}
+
+			d.buffer = d.buffer[5:]
+
+			return frame{control: window[1], address: window[2]}, nil
+		case frameLong:
+			if len(d.buffer) < 4 {
+				return frame{}, errNeedMoreBytes
+			}
+
+			length := int(d.buffer[1])
+			if d.buffer[2] != d.buffer[1] || d.buffer[3] != frameLong || length < 3 {
+				d.skip()
+				continue
+			}
+
+			if len(d.buffer) < length+6 {
+				return frame{}, errNeedMoreBytes
+			}
+
+			window := d.buffer[:length+6]
+			if window[length+5] != frameStop {
+				d.skip()
+				continue
+			}
+
+			if calculateChecksum(window[4:length+4]) != window[length+4] {
+				d.crcErrors++
+				d.skip()
+				continue
+			}
+
+			f := frame{
+				control: window[4],
+				address: window[5],
+				ci:      window[6],
+				data:    append([]byte(nil), window[7:length+4]...),
+			}
+
+			d.buffer = d.buffer[length+6:]
+
+			return f, nil
+		default:
+			d.skip()
+		}
+	}
+}
+
+func (d *decoder) skip() {
+	d.buffer = d.buffer[1:]
+	d.droppedBytes++
+}
+
+// reset drops the rest of the buffer, e.g. when the transaction is over.
+func (d *decoder) reset() {
+	d.droppedBytes += uint64(len(d.buffer))
+	d.buffer = nil
+}
+
+func calculateChecksum(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+
+	return sum
+}
@@ -0,0 +1,125 @@
+package mbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func corrupt(data []byte, i int) []byte {
+	data = append([]byte(nil), data...)
+	data[i] ^= 0xFF
+
+	return data
+}
+
+func concat(chunks ...[]byte) []byte {
+	return bytes.Join(chunks, nil)
+}
+
+func TestDecoder(t *testing.T) {
+	short := encodeShortFrame(cReqUd2, 0x05)
+	long := encodeLongFrame(0x08, 0x05, ciVariableData, []byte{0x01, 0x02, 0x03})
+
+	tests := []struct {
+		name    string
+		chunks  [][]byte
+		frames  []frame
+		dropped uint64
+		crc     uint64
+	}{
+		{
+			name:   "ack",
+			chunks: [][]byte{{frameAck}},
+			frames: []frame{{ack: true}},
+		},
+		{
+			name:   "short frame",
+			chunks: [][]byte{short},
+			frames: []frame{{control: cReqUd2, address: 0x05}},
+		},
+		{
+			name:   "long frame",
+			chunks: [][]byte{long},
+			frames: []frame{{control: 0x08, address: 0x05, ci: ciVariableData, data: []byte{0x01, 0x02, 0x03}}},
+		},
+		{
+			name:   "split reads",
+			chunks: [][]byte{long[:2], long[2:7], long[7:]},
+			frames: []frame{{control: 0x08, address: 0x05, ci: ciVariableData, data: []byte{0x01, 0x02, 0x03}}},
+		},
+		{
+			name:   "frames in one read",
+			chunks: [][]byte{concat([]byte{frameAck}, short)},
+			frames: []frame{{ack: true}, {control: cReqUd2, address: 0x05}},
+		},
+		{
+			name:    "junk prefix",
+			chunks:  [][]byte{concat([]byte{0x00, 0x13}, short)},
+			frames:  []frame{{control: cReqUd2, address: 0x05}},
+			dropped: 2,
+		},
+		{
+			name:    "bad checksum",
+			chunks:  [][]byte{concat(corrupt(long, len(long)-2), short)},
+			frames:  []frame{{control: cReqUd2, address: 0x05}},
+			dropped: uint64(len(long)),
+			crc:     1,
+		},
+		{
+			name:    "mismatched lengths",
+			chunks:  [][]byte{concat([]byte{frameLong, 0x06, 0x05, frameLong}, short)},
+			frames:  []frame{{control: cReqUd2, address: 0x05}},
+			dropped: 4,
+		},
+		{
+			name:    "missing stop character",
+			chunks:  [][]byte{concat(corrupt(short, 4), short)},
+			frames:  []frame{{control: cReqUd2, address: 0x05}},
+			dropped: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &decoder{}
+
+			var frames []frame
+			for _, chunk := range tt.chunks {
+				d.write(chunk)
+
+				for {
+					f, err := d.next()
+					if errors.Is(err, errNeedMoreBytes) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("next: %v", err)
+					}
+
+					frames = append(frames, f)
+				}
+			}
+
+			if len(frames) != len(tt.frames) {
+				t.Fatalf("got %+v, want %+v", frames, tt.frames)
+			}
+
+			for i, f := range frames {
+				want := tt.frames[i]
+				if f.ack != want.ack || f.control != want.control || f.address != want.address ||
+					f.ci != want.ci || !bytes.Equal(f.data, want.data) {
+					t.Errorf("frame %d: got %+v, want %+v", i, f, want)
+				}
+			}
+
+			if d.droppedBytes != tt.dropped {
+				t.Errorf("dropped bytes: got %d, want %d", d.droppedBytes, tt.dropped)
+			}
+
+			if d.crcErrors != tt.crc {
+				t.Errorf("crc errors: got %d, want %d", d.crcErrors, tt.crc)
+			}
+		})
+	}
+}
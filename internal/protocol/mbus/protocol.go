@@ -0,0 +1,335 @@
+package mbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var errNeedMoreBytes = errors.New("need more bytes")
+
+type MBus struct {
+	bus    *bus.Bus
+	policy bus.Policy
+
+	// fcb is the frame count bit of the next REQ_UD2 by device, see fcbKey.
+	mu  sync.Mutex
+	fcb map[string]bool
+
+	log *zap.Logger
+}
+
+func NewMBus(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *MBus {
+	return &MBus{
+		bus:    bus,
+		policy: policy,
+		fcb:    make(map[string]bool),
+		log:    log,
+	}
+}
+
+// Initialize sends SND_NKE, the device resets its communication state and answers with ACK.
+func (s *MBus) Initialize(ctx context.Context, address byte) error {
+	err := s.request(ctx, func(ctx context.Context, tx *bus.Tx) error {
+		return s.initialize(ctx, tx, address)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.setFCB(primaryKey(address), true)
+
+	return nil
+}
+
+// InitializeSecondary selects the device by the secondary address and sends it SND_NKE,
+// which also ends the selection. Every read selects the device again.
+func (s *MBus) InitializeSecondary(ctx context.Context, address SecondaryAddress) error {
+	err := s.request(ctx, func(ctx context.Context, tx *bus.Tx) error {
+		err := s.selectDevice(ctx, tx, address)
+		if err != nil {
+			return err
+		}
+
+		return s.initialize(ctx, tx, AddressNetworkLayer)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.setFCB(address.String(), true)
+
+	return nil
+}
+
+// ReadData requests the user data of the device at the primary address.
+func (s *MBus) ReadData(ctx context.Context, address byte) (Data, error) {
+	var data Data
+
+	err := s.request(ctx, func(ctx context.Context, tx *bus.Tx) error {
+		var err error
+		data, err = s.readData(ctx, tx, address, primaryKey(address))
+
+		return err
+	})
+	if err != nil {
+		return Data{}, err
+	}
+
+	return data, nil
+}
+
+// ReadSecondary selects the device by the secondary address and requests its user data.
+// Both requests run in one transaction, so other meters can not take the selection over.
+func (s *MBus) ReadSecondary(ctx context.Context, address SecondaryAddress) (Data, error) {
+	var data Data
+
+	err := s.request(ctx, func(ctx context.Context, tx *bus.Tx) error {
+		err := s.selectDevice(ctx, tx, address)
+		if err != nil {
+			return err
+		}
+
+		data, err = s.readData(ctx, tx, AddressNetworkLayer, address.String())
+
+		return err
+	})
+	if err != nil {
+		return Data{}, err
+	}
+
+	return data, nil
+}
+
+// Select selects the devices matching the secondary address, the address may have wildcards.
+// It returns ErrCollision if several devices answered.
+func (s *MBus) Select(ctx context.Context, address SecondaryAddress) error {
+	return s.request(ctx, func(ctx context.Context, tx *bus.Tx) error {
+		return s.selectDevice(ctx, tx, address)
+	})
+}
+
+// Scan searches the devices by the secondary address: digits of the identification number
+// are fixed one by one from the most significant while several devices answer the selection.
+func (s *MBus) Scan(ctx context.Context, found func(Data)) error {
+	return s.scan(ctx, wildcardID, 0, found)
+}
+
+func (s *MBus) scan(ctx context.Context, id string, pos int, found func(Data)) error {
+	for digit := byte('0'); digit <= '9'; digit++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		mask := id[:pos] + string(digit) + id[pos+1:]
+		address := SecondaryAddress{
+			ID:           mask,
+			Manufacturer: wildcardVendor,
+			Version:      wildcardVersion,
+			Medium:       wildcardMedium,
+		}
+
+		var data Data
+		err := s.request(ctx, func(ctx context.Context, tx *bus.Tx) error {
+			err := s.selectDevice(ctx, tx, address)
+			if err != nil {
+				return err
+			}
+
+			// the frame count bit of the selections of a scan is not tracked
+			data, err = s.readData(ctx, tx, AddressNetworkLayer, "")
+
+			return err
+		})
+
+		switch {
+		case err == nil:
+			found(data)
+		case errors.Is(err, ErrDeviceNotResponding):
+		case errors.Is(err, ErrCollision) && pos < idDigits-1:
+			err = s.scan(ctx, mask, pos+1, found)
+			if err != nil {
+				return err
+			}
+		default:
+			s.log.Warn("scan", zap.String("mask", mask), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (s *MBus) request(ctx context.Context, fn func(ctx context.Context, tx *bus.Tx) error) error {
+	err := s.bus.Request(ctx, s.policy, fn)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrDeviceNotResponding
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *MBus) initialize(ctx context.Context, tx *bus.Tx, address byte) error {
+	err := tx.Write(encodeShortFrame(cSndNke, address))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.receive(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if !resp.ack {
+		return fmt.Errorf("%w: ACK expected", ErrInvalidResponse)
+	}
+
+	return nil
+}
+
+func (s *MBus) selectDevice(ctx context.Context, tx *bus.Tx, address SecondaryAddress) error {
+	err := tx.Write(encodeLongFrame(cSndUd, AddressNetworkLayer, ciSelect, address.encode()))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.receive(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if !resp.ack {
+		return fmt.Errorf("%w: ACK expected", ErrInvalidResponse)
+	}
+
+	return nil
+}
+
+// readData sends REQ_UD2 with the frame count bit of the key. The bit is toggled only
+// after a valid RSP_UD, so a retry repeats it and the device repeats its last response.
+func (s *MBus) readData(ctx context.Context, tx *bus.Tx, address byte, key string) (Data, error) {
+	s.mu.Lock()
+	fcb := s.fcb[key]
+	s.mu.Unlock()
+
+	control := byte(cReqUd2)
+	if fcb {
+		control |= cFCB
+	}
+
+	err := tx.Write(encodeShortFrame(control, address))
+	if err != nil {
+		return Data{}, err
+	}
+
+	resp, err := s.receive(ctx, tx)
+	if err != nil {
+		return Data{}, err
+	}
+
+	if resp.ack || resp.control&0x0F != 0x08 {
+		return Data{}, fmt.Errorf("%w: RSP_UD expected", ErrInvalidResponse)
+	}
+
+	if key != "" {
+		s.setFCB(key, !fcb)
+	}
+
+	data, err := parseData(resp.ci, resp.data)
+	if err != nil {
+		return Data{}, err
+	}
+
+	s.log.Debug(
+		"read data",
+		zap.Stringer("address", data.Address),
+		zap.Int("records", len(data.Records)),
+	)
+
+	return data, nil
+}
+
+func (s *MBus) setFCB(key string, fcb bool) {
+	s.mu.Lock()
+	s.fcb[key] = fcb
+	s.mu.Unlock()
+}
+
+// primaryKey returns the frame count bit key of the primary address, the secondary
+// addresses are keyed by their string of 16 characters.
+func primaryKey(address byte) string {
+	return strconv.Itoa(int(address))
+}
+
+// receive returns the next frame. Garbage received instead of a frame means that several
+// devices answered at once, it is reported as ErrCollision when the time is over.
+func (s *MBus) receive(ctx context.Context, tx *bus.Tx) (frame, error) {
+	dec := &decoder{}
+	defer func() {
+		dec.reset()
+		s.reportLineErrors(tx, dec)
+	}()
+
+	for {
+		data, err := tx.Read(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && (len(dec.buffer) > 0 || dec.droppedBytes > 0 || dec.crcErrors > 0) {
+				return frame{}, ErrCollision
+			}
+
+			return frame{}, err
+		}
+
+		dec.write(data)
+
+		resp, err := dec.next()
+		if err == errNeedMoreBytes {
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+func (s *MBus) reportLineErrors(tx *bus.Tx, dec *decoder) {
+	if dec.droppedBytes == 0 && dec.crcErrors == 0 {
+		return
+	}
+
+	tx.ReportLineErrors(dec.droppedBytes, dec.crcErrors)
+
+	s.log.Warn(
+		"line errors",
+		zap.Uint64("droppedBytes", dec.droppedBytes),
+		zap.Uint64("crcErrors", dec.crcErrors),
+	)
+}
+
+// ParseAddress parses a primary address 0..250 or a secondary address.
+func ParseAddress(s string) (primary byte, secondary *SecondaryAddress, err error) {
+	s = strings.TrimSpace(s)
+
+	if len(s) <= 3 {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 || v > int(MaxPrimaryAddress) {
+			return 0, nil, fmt.Errorf("invalid primary address \"%s\"", s)
+		}
+
+		return byte(v), nil, nil
+	}
+
+	address, err := ParseSecondaryAddress(s)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return 0, &address, nil
+}
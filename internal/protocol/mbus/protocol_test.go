@@ -0,0 +1,260 @@
+package mbus
+
+import (
+	"context"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testBus simulates the devices of a bus: selected devices acknowledge at once,
+// so several of them garble the answer, and REQ_UD2 is answered by the selected
+// device or the device of the primary address.
+type testBus struct {
+	devices []SecondaryAddress
+
+	mu       sync.Mutex
+	selected []int
+	// drop is the number of REQ_UD2 left unanswered.
+	drop int
+	// requests are the C fields of the REQ_UD2 received.
+	requests []byte
+}
+
+func newTestMBus(t *testing.T, devices *testBus, policy bus.Policy) *MBus {
+	t.Helper()
+
+	client, device := net.Pipe()
+	t.Cleanup(func() { _ = device.Close() })
+
+	go devices.serve(device)
+
+	b := bus.NewBus(client, 0, policy, zap.NewNop())
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewMBus(b, policy, zap.NewNop())
+}
+
+func (b *testBus) serve(conn net.Conn) {
+	dec := &decoder{}
+	buf := make([]byte, 256)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		dec.write(buf[:n])
+
+		for {
+			req, err := dec.next()
+			if err != nil {
+				break
+			}
+
+			resp := b.answer(req)
+			if resp == nil {
+				continue
+			}
+
+			_, err = conn.Write(resp)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (b *testBus) answer(req frame) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case req.ci == ciSelect:
+		mask := DecodeSecondaryAddress(req.data)
+
+		b.selected = nil
+		for i, address := range b.devices {
+			if matches(mask, address) {
+				b.selected = append(b.selected, i)
+			}
+		}
+
+		return b.acknowledge()
+	case req.control == cSndNke:
+		if req.address == AddressNetworkLayer {
+			defer func() { b.selected = nil }()
+
+			return b.acknowledge()
+		}
+
+		if int(req.address) < len(b.devices) {
+			return []byte{frameAck}
+		}
+	case req.control&^cFCB == cReqUd2:
+		b.requests = append(b.requests, req.control)
+
+		if b.drop > 0 {
+			b.drop--
+
+			return nil
+		}
+
+		device := int(req.address)
+		if req.address == AddressNetworkLayer {
+			if len(b.selected) != 1 {
+				return b.acknowledge()
+			}
+
+			device = b.selected[0]
+		}
+
+		if device < len(b.devices) {
+			header := append(b.devices[device].encode(), 0x01, 0x00, 0x00, 0x00)
+
+			return encodeLongFrame(0x08, byte(device), ciVariableData, header)
+		}
+	}
+
+	return nil
+}
+
+// acknowledge returns the answer of the selected devices, garbled if there are several.
+func (b *testBus) acknowledge() []byte {
+	switch len(b.selected) {
+	case 0:
+		return nil
+	case 1:
+		return []byte{frameAck}
+	default:
+		return []byte{0x65}
+	}
+}
+
+func (b *testBus) fcbs() []bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fcbs := make([]bool, len(b.requests))
+	for i, control := range b.requests {
+		fcbs[i] = control&cFCB > 0
+	}
+
+	return fcbs
+}
+
+func matches(mask SecondaryAddress, address SecondaryAddress) bool {
+	for i := range mask.ID {
+		if mask.ID[i] != wildcardDigit && mask.ID[i] != address.ID[i] {
+			return false
+		}
+	}
+
+	return (mask.Manufacturer == wildcardVendor || mask.Manufacturer == address.Manufacturer) &&
+		(mask.Version == wildcardVersion || mask.Version == address.Version) &&
+		(mask.Medium == wildcardMedium || mask.Medium == address.Medium)
+}
+
+func testAddress(id string) SecondaryAddress {
+	return SecondaryAddress{ID: id, Manufacturer: 0x6A49, Version: 0x01, Medium: MediumWater}
+}
+
+func TestScanResolvesCollisions(t *testing.T) {
+	devices := &testBus{
+		devices: []SecondaryAddress{
+			testAddress("12345678"),
+			testAddress("12399999"),
+			testAddress("70000001"),
+		},
+	}
+	s := newTestMBus(t, devices, bus.Policy{Timeout: 20 * time.Millisecond})
+
+	var found []string
+	err := s.Scan(context.Background(), func(data Data) {
+		found = append(found, data.Address.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(found)
+	if got := strings.Join(found, " "); got != "12345678 12399999 70000001" {
+		t.Fatalf("found %s", got)
+	}
+}
+
+func TestFCBIsToggledAfterResponse(t *testing.T) {
+	devices := &testBus{
+		devices: []SecondaryAddress{testAddress("12345678")},
+		drop:    1,
+	}
+	s := newTestMBus(t, devices, bus.Policy{Timeout: 50 * time.Millisecond, Retries: 1})
+
+	err := s.Initialize(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = s.ReadData(context.Background(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the retry of the dropped request repeats its bit
+	want := []bool{true, true, false}
+	if got := devices.fcbs(); !equalBools(got, want) {
+		t.Fatalf("got frame count bits %v, want %v", got, want)
+	}
+}
+
+func TestFCBOfSecondaryAddresses(t *testing.T) {
+	a, b := testAddress("12345678"), testAddress("87654321")
+	devices := &testBus{devices: []SecondaryAddress{a, b}}
+	s := newTestMBus(t, devices, bus.Policy{Timeout: 50 * time.Millisecond})
+
+	for _, address := range []SecondaryAddress{a, b} {
+		err := s.InitializeSecondary(context.Background(), address)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, address := range []SecondaryAddress{a, b, a} {
+		data, err := s.ReadSecondary(context.Background(), address)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data.Address != address {
+			t.Fatalf("got data of %s, want %s", data.Address, address)
+		}
+	}
+
+	// every device keeps its own bit although both are read at the network layer address
+	want := []bool{true, true, false}
+	if got := devices.fcbs(); !equalBools(got, want) {
+		t.Fatalf("got frame count bits %v, want %v", got, want)
+	}
+}
+
+func equalBools(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
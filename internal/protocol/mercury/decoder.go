@@ -0,0 +1,82 @@
+package mercury
+
+import (
+	"bytes"
+)
+
+// decoder extracts the response from a byte stream. Frames carry no length, so
+// the decoder expects either a frame of the requested length or a short status
+// frame: address, status and CRC.
+type decoder struct {
+	address []byte
+	length  int
+	buffer  []byte
+
+	droppedBytes uint64
+	crcErrors    uint64
+}
+
+func newDecoder(address []byte, length int) *decoder {
+	return &decoder{address: address, length: length}
+}
+
+func (d *decoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+// next returns the data of the frame without address and CRC or errNeedMoreBytes.
+func (d *decoder) next() ([]byte, error) {
+	statusLen := len(d.address) + 3
+
+	for {
+		if len(d.buffer) < len(d.address) {
+			return nil, errNeedMoreBytes
+		}
+
+		if !bytes.Equal(d.buffer[:len(d.address)], d.address) {
+			d.skip()
+			continue
+		}
+
+		if len(d.buffer) >= d.length && checkCrc(d.buffer[:d.length]) {
+			return d.take(d.length), nil
+		}
+
+		if d.length != statusLen && len(d.buffer) >= statusLen && checkCrc(d.buffer[:statusLen]) {
+			return d.take(statusLen), nil
+		}
+
+		if len(d.buffer) < d.length {
+			return nil, errNeedMoreBytes
+		}
+
+		d.crcErrors++
+		d.skip()
+	}
+}
+
+func (d *decoder) take(length int) []byte {
+	data := make([]byte, length-len(d.address)-2)
+	copy(data, d.buffer[len(d.address):length-2])
+
+	d.buffer = d.buffer[length:]
+
+	return data
+}
+
+func (d *decoder) skip() {
+	d.buffer = d.buffer[1:]
+	d.droppedBytes++
+}
+
+// reset drops the rest of the buffer, e.g. when the transaction is over.
+func (d *decoder) reset() {
+	d.droppedBytes += uint64(len(d.buffer))
+	d.buffer = nil
+}
+
+func checkCrc(frame []byte) bool {
+	n := len(frame)
+
+	return calculateCrc(frame[:n-2]) == uint16(frame[n-2])|uint16(frame[n-1])<<8
+}
@@ -0,0 +1,132 @@
+package mercury
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var testAddress = []byte{0x80}
+
+// testFrame appends the CRC to the address and the data.
+func testFrame(address []byte, data ...byte) []byte {
+	f := append(append([]byte(nil), address...), data...)
+	crc := calculateCrc(f)
+
+	return append(f, byte(crc), byte(crc>>8))
+}
+
+func corrupt(data []byte) []byte {
+	data = append([]byte(nil), data...)
+	data[len(data)-1] ^= 0xFF
+
+	return data
+}
+
+func concat(chunks ...[]byte) []byte {
+	return bytes.Join(chunks, nil)
+}
+
+func TestCalculateCrc(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		crc  uint16
+	}{
+		{"check value", []byte("123456789"), 0x4B37},
+		{"read holding register", []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01}, 0x0A84},
+		{"empty", nil, 0xFFFF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if crc := calculateCrc(tt.data); crc != tt.crc {
+				t.Fatalf("got 0x%04X, want 0x%04X", crc, tt.crc)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	// a reply of three data bytes, e.g. an aux value of Mercury 230
+	valid := testFrame(testAddress, 0x00, 0x4C, 0x59)
+	status := testFrame(testAddress, status230ChannelNotOpen)
+
+	tests := []struct {
+		name    string
+		chunks  [][]byte
+		data    []byte
+		dropped uint64
+		crc     uint64
+	}{
+		{
+			name:   "data frame",
+			chunks: [][]byte{valid},
+			data:   []byte{0x00, 0x4C, 0x59},
+		},
+		{
+			name:   "status frame",
+			chunks: [][]byte{status},
+			data:   []byte{status230ChannelNotOpen},
+		},
+		{
+			name:   "split reads",
+			chunks: [][]byte{valid[:1], valid[1:4], valid[4:]},
+			data:   []byte{0x00, 0x4C, 0x59},
+		},
+		{
+			name:    "junk prefix",
+			chunks:  [][]byte{concat([]byte{0xFF, 0x00, 0x13}, valid)},
+			data:    []byte{0x00, 0x4C, 0x59},
+			dropped: 3,
+		},
+		{
+			name:    "frame of another device",
+			chunks:  [][]byte{concat(testFrame([]byte{0x21}, 0x00, 0x4C, 0x59), valid)},
+			data:    []byte{0x00, 0x4C, 0x59},
+			dropped: uint64(len(valid)),
+		},
+		{
+			name:    "bad crc",
+			chunks:  [][]byte{concat(corrupt(valid), valid)},
+			data:    []byte{0x00, 0x4C, 0x59},
+			dropped: uint64(len(valid)),
+			crc:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDecoder(testAddress, len(valid))
+
+			var (
+				data []byte
+				err  error
+			)
+			for _, chunk := range tt.chunks {
+				d.write(chunk)
+
+				data, err = d.next()
+				if err != nil && !errors.Is(err, errNeedMoreBytes) {
+					t.Fatalf("next: %v", err)
+				}
+			}
+
+			if err != nil {
+				t.Fatalf("next: %v", err)
+			}
+
+			if !bytes.Equal(data, tt.data) {
+				t.Errorf("got % X, want % X", data, tt.data)
+			}
+
+			if d.droppedBytes != tt.dropped {
+				t.Errorf("dropped bytes: got %d, want %d", d.droppedBytes, tt.dropped)
+			}
+
+			if d.crcErrors != tt.crc {
+				t.Errorf("crc errors: got %d, want %d", d.crcErrors, tt.crc)
+			}
+		})
+	}
+}
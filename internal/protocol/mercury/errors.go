@@ -0,0 +1,36 @@
+package mercury
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDeviceNotResponding = errors.New("the device is not responding")
+	ErrInvalidCommand      = errors.New("invalid command or parameter")
+	ErrInternal            = errors.New("internal error of the device")
+	ErrAccessLevel         = errors.New("insufficient access level")
+	ErrClockCorrected      = errors.New("the clock has already been corrected today")
+	ErrChannelNotOpen      = errors.New("the communication channel is not open")
+	ErrUnknown             = errors.New("unknown error")
+	ErrInvalidResponse     = errors.New("invalid response")
+)
+
+// DeviceError is returned when the device answers with a status frame instead of data.
+// The underlying error is one of the Err* values and can be checked with errors.Is.
+type DeviceError struct {
+	Code byte
+	Err  error
+}
+
+func (e *DeviceError) Error() string {
+	if e.Err == ErrUnknown {
+		return fmt.Sprintf("%s, code: %d", e.Err.Error(), e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
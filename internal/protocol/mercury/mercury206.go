@@ -0,0 +1,126 @@
+package mercury
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	comm206WriteTime  byte = 0x02
+	comm206ReadTime        = 0x21
+	comm206ReadEnergy      = 0x27
+	comm206ReadUIP         = 0x63
+)
+
+// Mercury206 is a client of single-phase Mercury 200/203/206 meters, the address
+// is the serial number of the meter. Reading requires no session.
+type Mercury206 struct {
+	client
+}
+
+// Instant holds the instantaneous values of a single-phase meter.
+type Instant struct {
+	Voltage     float64
+	Current     float64
+	ActivePower float64
+}
+
+func NewMercury206(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *Mercury206 {
+	return &Mercury206{
+		client: client{
+			bus:    bus,
+			policy: policy,
+			log:    log,
+		},
+	}
+}
+
+// ReadEnergy returns the active energy of the tariffs T1-T4 in kWh.
+func (s *Mercury206) ReadEnergy(ctx context.Context, address uint32) ([]float64, error) {
+	resp, err := s.read(ctx, address, comm206ReadEnergy, nil, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	tariffs := make([]float64, 4)
+	for i := range tariffs {
+		value, err := decodeBCD(resp[i*4 : i*4+4])
+		if err != nil {
+			return nil, err
+		}
+
+		tariffs[i] = float64(value) / 100
+	}
+
+	return tariffs, nil
+}
+
+func (s *Mercury206) ReadInstant(ctx context.Context, address uint32) (Instant, error) {
+	resp, err := s.read(ctx, address, comm206ReadUIP, nil, 7)
+	if err != nil {
+		return Instant{}, err
+	}
+
+	voltage, err := decodeBCD(resp[0:2])
+	if err != nil {
+		return Instant{}, err
+	}
+
+	current, err := decodeBCD(resp[2:4])
+	if err != nil {
+		return Instant{}, err
+	}
+
+	power, err := decodeBCD(resp[4:7])
+	if err != nil {
+		return Instant{}, err
+	}
+
+	return Instant{
+		Voltage:     float64(voltage) / 10,
+		Current:     float64(current) / 100,
+		ActivePower: float64(power),
+	}, nil
+}
+
+func (s *Mercury206) ReadTime(ctx context.Context, address uint32) (time.Time, error) {
+	resp, err := s.read(ctx, address, comm206ReadTime, nil, 7)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return decodeDate(resp[6], resp[5], resp[4], resp[1], resp[2], resp[3])
+}
+
+func (s *Mercury206) WriteTime(ctx context.Context, address uint32, t time.Time) error {
+	_, err := s.read(ctx, address, comm206WriteTime, []byte{
+		toBCD(weekday(t)),
+		toBCD(t.Hour()),
+		toBCD(t.Minute()),
+		toBCD(t.Second()),
+		toBCD(t.Day()),
+		toBCD(int(t.Month())),
+		toBCD(t.Year() - 2000),
+	}, 0)
+
+	return err
+}
+
+// read sends the command and returns dataLen bytes following the echoed command code.
+func (s *Mercury206) read(ctx context.Context, address uint32, command byte, data []byte, dataLen int) ([]byte, error) {
+	addr := []byte{byte(address >> 24), byte(address >> 16), byte(address >> 8), byte(address)}
+
+	resp, err := s.request(ctx, addr, append([]byte{command}, data...), dataLen+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) != dataLen+1 || resp[0] != command {
+		return nil, fmt.Errorf("%w: %X", ErrInvalidResponse, resp)
+	}
+
+	return resp[1:], nil
+}
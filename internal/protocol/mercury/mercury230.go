@@ -0,0 +1,266 @@
+package mercury
+
+import (
+	"context"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	comm230TestChannel  byte = 0x00
+	comm230OpenChannel       = 0x01
+	comm230CloseChannel      = 0x02
+	comm230Write             = 0x03
+	comm230ReadTime          = 0x04
+	comm230ReadEnergy        = 0x05
+	comm230ReadParam         = 0x08
+)
+
+const (
+	param230SerialNumber byte = 0x00
+	param230Aux               = 0x11
+	param230SetTime           = 0x0C
+)
+
+const (
+	status230OK             byte = 0x00
+	status230InvalidCommand      = 0x01
+	status230Internal            = 0x02
+	status230AccessLevel         = 0x03
+	status230ClockCorrected      = 0x04
+	status230ChannelNotOpen      = 0x05
+)
+
+// Auxiliary values read with param230Aux, the phase number 1-3 is added to
+// the phase dependent ones, 0 selects the sum over phases.
+const (
+	AuxActivePower   byte = 0x00
+	AuxReactivePower      = 0x04
+	AuxFullPower          = 0x08
+	AuxVoltage            = 0x10
+	AuxCurrent            = 0x20
+	AuxPowerFactor        = 0x30
+	AuxFrequency          = 0x40
+)
+
+type AccessLevel byte
+
+const (
+	AccessLevelUser  AccessLevel = 0x01
+	AccessLevelAdmin AccessLevel = 0x02
+)
+
+// Energy holds the energy registers in kWh and kVArh.
+type Energy struct {
+	ActiveImport   float64
+	ActiveExport   float64
+	ReactiveImport float64
+	ReactiveExport float64
+}
+
+// Mercury230 is a client of three-phase Mercury 230/231/233/234/236 meters.
+// Every request except TestChannel requires an open channel.
+type Mercury230 struct {
+	client
+}
+
+func NewMercury230(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *Mercury230 {
+	return &Mercury230{
+		client: client{
+			bus:    bus,
+			policy: policy,
+			log:    log,
+		},
+	}
+}
+
+func (s *Mercury230) TestChannel(ctx context.Context, address byte) error {
+	return s.command(ctx, address, []byte{comm230TestChannel})
+}
+
+// OpenChannel opens a session, the password is six characters, "111111" by default
+// for the user and "222222" for the admin level. The device closes the channel
+// after about four minutes without requests.
+func (s *Mercury230) OpenChannel(ctx context.Context, address byte, level AccessLevel, password string) error {
+	if len(password) != 6 {
+		return fmt.Errorf("invalid password length: %d", len(password))
+	}
+
+	payload := []byte{comm230OpenChannel, byte(level)}
+	for i := 0; i < len(password); i++ {
+		c := password[i]
+		if c >= '0' && c <= '9' {
+			c -= '0'
+		}
+
+		payload = append(payload, c)
+	}
+
+	return s.command(ctx, address, payload)
+}
+
+func (s *Mercury230) CloseChannel(ctx context.Context, address byte) error {
+	return s.command(ctx, address, []byte{comm230CloseChannel})
+}
+
+// ReadSerialNumber returns the serial number and the manufacturing date.
+func (s *Mercury230) ReadSerialNumber(ctx context.Context, address byte) (string, time.Time, error) {
+	resp, err := s.read(ctx, address, []byte{comm230ReadParam, param230SerialNumber}, 7)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	serial := fmt.Sprintf("%02d%02d%02d%02d", resp[0], resp[1], resp[2], resp[3])
+
+	date, err := decodeDate(resp[6], resp[5], resp[4], 0, 0, 0)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return serial, date, nil
+}
+
+func (s *Mercury230) ReadTime(ctx context.Context, address byte) (time.Time, error) {
+	resp, err := s.read(ctx, address, []byte{comm230ReadTime, 0x00}, 8)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return decodeDate(resp[6], resp[5], resp[4], resp[2], resp[1], resp[0])
+}
+
+// WriteTime sets the clock, it requires the admin access level.
+func (s *Mercury230) WriteTime(ctx context.Context, address byte, t time.Time) error {
+	var winter byte
+	if !t.IsDST() {
+		winter = 1
+	}
+
+	return s.command(ctx, address, []byte{
+		comm230Write,
+		param230SetTime,
+		toBCD(t.Second()),
+		toBCD(t.Minute()),
+		toBCD(t.Hour()),
+		toBCD(weekday(t)),
+		toBCD(t.Day()),
+		toBCD(int(t.Month())),
+		toBCD(t.Year() - 2000),
+		winter,
+	})
+}
+
+// ReadEnergy returns the energy accumulated from reset, tariff 0 is the sum over all tariffs.
+func (s *Mercury230) ReadEnergy(ctx context.Context, address byte, tariff int) (Energy, error) {
+	if tariff < 0 || tariff > 4 {
+		return Energy{}, fmt.Errorf("invalid tariff: %d", tariff)
+	}
+
+	resp, err := s.read(ctx, address, []byte{comm230ReadEnergy, 0x00, byte(tariff)}, 16)
+	if err != nil {
+		return Energy{}, err
+	}
+
+	values := make([]float64, 4)
+	for i := range values {
+		b := resp[i*4 : i*4+4]
+		raw := uint32(b[1])<<24 | uint32(b[0])<<16 | uint32(b[3])<<8 | uint32(b[2])
+		if raw == 0xFFFFFFFF {
+			// the register is not supported by the modification
+			raw = 0
+		}
+
+		values[i] = float64(raw) / 1000
+	}
+
+	return Energy{
+		ActiveImport:   values[0],
+		ActiveExport:   values[1],
+		ReactiveImport: values[2],
+		ReactiveExport: values[3],
+	}, nil
+}
+
+// ReadAux returns an auxiliary value, param is one of Aux* plus the phase number.
+// Values are scaled to V, A, W, VAr, VA and Hz, powers are negative when exported.
+func (s *Mercury230) ReadAux(ctx context.Context, address byte, param byte) (float64, error) {
+	resp, err := s.read(ctx, address, []byte{comm230ReadParam, param230Aux, param}, 3)
+	if err != nil {
+		return 0, err
+	}
+
+	value := float64(uint32(resp[0]&0x3F)<<16 | uint32(resp[2])<<8 | uint32(resp[1]))
+
+	switch param & 0xF0 {
+	case AuxActivePower:
+		// active, reactive and full powers share the high nibble, the two high
+		// bits of the value are the directions of active and reactive power
+		switch {
+		case param&0x0C == AuxActivePower && resp[0]&0x80 > 0,
+			param&0x0C == AuxReactivePower && resp[0]&0x40 > 0:
+			value = -value
+		}
+
+		return value / 100, nil
+	case AuxVoltage, AuxFrequency:
+		return value / 100, nil
+	case AuxCurrent, AuxPowerFactor:
+		return value / 1000, nil
+	default:
+		return 0, fmt.Errorf("unsupported aux param: 0x%02X", param)
+	}
+}
+
+// command sends a request answered by a status frame.
+func (s *Mercury230) command(ctx context.Context, address byte, payload []byte) error {
+	resp, err := s.request(ctx, []byte{address}, payload, 1)
+	if err != nil {
+		return err
+	}
+
+	if resp[0] != status230OK {
+		return mapStatus230(resp[0])
+	}
+
+	return nil
+}
+
+func (s *Mercury230) read(ctx context.Context, address byte, payload []byte, dataLen int) ([]byte, error) {
+	resp, err := s.request(ctx, []byte{address}, payload, dataLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) == 1 {
+		return nil, mapStatus230(resp[0])
+	}
+
+	if len(resp) != dataLen {
+		return nil, ErrInvalidResponse
+	}
+
+	return resp, nil
+}
+
+func mapStatus230(code byte) error {
+	var err error
+
+	switch code & 0x0F {
+	case status230InvalidCommand:
+		err = ErrInvalidCommand
+	case status230Internal:
+		err = ErrInternal
+	case status230AccessLevel:
+		err = ErrAccessLevel
+	case status230ClockCorrected:
+		err = ErrClockCorrected
+	case status230ChannelNotOpen:
+		err = ErrChannelNotOpen
+	default:
+		err = ErrUnknown
+	}
+
+	return &DeviceError{Code: code, Err: err}
+}
@@ -0,0 +1,113 @@
+package mercury
+
+import (
+	"context"
+	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+)
+
+var errNeedMoreBytes = errors.New("need more bytes")
+
+// client implements framing common for Mercury meters: address, command, data and CRC-16.
+type client struct {
+	bus    *bus.Bus
+	policy bus.Policy
+
+	log *zap.Logger
+}
+
+// request sends the payload and returns the response data of dataLen bytes,
+// or a single status byte when the device answers with a status frame.
+func (c *client) request(ctx context.Context, address []byte, payload []byte, dataLen int) ([]byte, error) {
+	req := make([]byte, 0, len(address)+len(payload)+2)
+	req = append(req, address...)
+	req = append(req, payload...)
+	crc := calculateCrc(req)
+	req = append(req, byte(crc), byte(crc>>8))
+
+	var resp []byte
+	err := c.bus.Request(ctx, c.policy, func(ctx context.Context, tx *bus.Tx) error {
+		err := tx.Write(req)
+		if err != nil {
+			return err
+		}
+
+		resp, err = c.receiveResponse(ctx, tx, newDecoder(address, len(address)+dataLen+2))
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrDeviceNotResponding
+		}
+
+		return nil, err
+	}
+
+	c.log.Debug(
+		"receive response",
+		zap.Binary("request", payload),
+		zap.Binary("response", resp),
+	)
+
+	return resp, nil
+}
+
+func (c *client) receiveResponse(ctx context.Context, tx *bus.Tx, dec *decoder) ([]byte, error) {
+	defer func() {
+		dec.reset()
+		c.reportLineErrors(tx, dec)
+	}()
+
+	for {
+		data, err := tx.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		dec.write(data)
+
+		resp, err := dec.next()
+		if err == errNeedMoreBytes {
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+func (c *client) reportLineErrors(tx *bus.Tx, dec *decoder) {
+	if dec.droppedBytes == 0 && dec.crcErrors == 0 {
+		return
+	}
+
+	tx.ReportLineErrors(dec.droppedBytes, dec.crcErrors)
+
+	c.log.Warn(
+		"line errors",
+		zap.Uint64("droppedBytes", dec.droppedBytes),
+		zap.Uint64("crcErrors", dec.crcErrors),
+	)
+}
+
+func calculateCrc(data []byte) uint16 {
+	var (
+		i, j   int
+		result uint16 = 0xFFFF
+	)
+
+	for i = 0; i < len(data); i++ {
+		result ^= uint16(data[i])
+
+		for j = 0; j < 8; j++ {
+			if result&0x1 > 0 {
+				result = (result >> 1) ^ 0xA001
+			} else {
+				result = result >> 1
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,178 @@
+package mercury
+
+import (
+	"context"
+	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// newTestBus returns a bus to a device on a pipe. The device answers every request
+// with the data returned by answer, framed with the address of the request.
+func newTestBus(t *testing.T, addressLen int, answer func(payload []byte) []byte) (*bus.Bus, bus.Policy) {
+	t.Helper()
+
+	client, device := net.Pipe()
+	t.Cleanup(func() { _ = device.Close() })
+
+	go serveDevice(device, addressLen, answer)
+
+	policy := bus.Policy{Timeout: time.Second}
+	b := bus.NewBus(client, 0, policy, zap.NewNop())
+	t.Cleanup(func() { _ = client.Close() })
+
+	return b, policy
+}
+
+// serveDevice expects every request in one write, as the client sends it.
+func serveDevice(conn net.Conn, addressLen int, answer func(payload []byte) []byte) {
+	buf := make([]byte, 256)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if n < addressLen+2 || !checkCrc(buf[:n]) {
+			continue
+		}
+
+		address := buf[:addressLen]
+		payload := append([]byte(nil), buf[addressLen:n-2]...)
+
+		_, err = conn.Write(testFrame(address, answer(payload)...))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestMercury230ReadAux(t *testing.T) {
+	tests := []struct {
+		name  string
+		param byte
+		data  []byte
+		value float64
+		err   error
+	}{
+		{"voltage of phase 1", AuxVoltage + 1, []byte{0x00, 0x4C, 0x59}, 228.60, nil},
+		{"current of phase 2", AuxCurrent + 2, []byte{0x00, 0xE8, 0x03}, 1.000, nil},
+		{"frequency", AuxFrequency, []byte{0x00, 0x88, 0x13}, 50.00, nil},
+		{"power factor", AuxPowerFactor, []byte{0x00, 0xB6, 0x03}, 0.950, nil},
+		{"imported active power", AuxActivePower, []byte{0x00, 0x10, 0x27}, 100.00, nil},
+		{"exported active power", AuxActivePower + 1, []byte{0x80, 0x10, 0x27}, -100.00, nil},
+		{"active power with exported reactive power", AuxActivePower, []byte{0x40, 0x10, 0x27}, 100.00, nil},
+		{"exported reactive power", AuxReactivePower + 3, []byte{0x40, 0x10, 0x27}, -100.00, nil},
+		{"reactive power with exported active power", AuxReactivePower, []byte{0x80, 0x10, 0x27}, 100.00, nil},
+		{"full power ignores directions", AuxFullPower, []byte{0xC0, 0x10, 0x27}, 100.00, nil},
+		{"large power", AuxActivePower, []byte{0x81, 0x00, 0x00}, -655.36, nil},
+		{"channel not open", AuxVoltage + 1, []byte{status230ChannelNotOpen}, 0, ErrChannelNotOpen},
+		{"access level", AuxVoltage + 1, []byte{status230AccessLevel}, 0, ErrAccessLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request []byte
+			b, policy := newTestBus(t, 1, func(payload []byte) []byte {
+				request = payload
+
+				return tt.data
+			})
+
+			value, err := NewMercury230(b, policy, zap.NewNop()).ReadAux(context.Background(), 0x80, tt.param)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if math.Abs(value-tt.value) > 1e-9 {
+				t.Errorf("got %v, want %v", value, tt.value)
+			}
+
+			if want := []byte{comm230ReadParam, param230Aux, tt.param}; string(request) != string(want) {
+				t.Errorf("request: got % X, want % X", request, want)
+			}
+		})
+	}
+}
+
+func TestMercury230ReadEnergy(t *testing.T) {
+	b, policy := newTestBus(t, 1, func([]byte) []byte {
+		return []byte{
+			0xBC, 0x00, 0x4E, 0x61, // 12345678 Wh with the byte order of the device
+			0x00, 0x00, 0xE8, 0x03,
+			0x00, 0x00, 0x00, 0x00,
+			0xFF, 0xFF, 0xFF, 0xFF, // not supported by the modification
+		}
+	})
+
+	energy, err := NewMercury230(b, policy, zap.NewNop()).ReadEnergy(context.Background(), 0x80, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Energy{ActiveImport: 12345.678, ActiveExport: 1}
+	if energy != want {
+		t.Fatalf("got %+v, want %+v", energy, want)
+	}
+}
+
+func TestMercury206(t *testing.T) {
+	const address uint32 = 0x00A1B2C3
+
+	b, policy := newTestBus(t, 4, func(payload []byte) []byte {
+		switch payload[0] {
+		case comm206ReadEnergy:
+			return []byte{
+				comm206ReadEnergy,
+				0x00, 0x01, 0x23, 0x45,
+				0x00, 0x00, 0x67, 0x89,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			}
+		case comm206ReadUIP:
+			return []byte{comm206ReadUIP, 0x22, 0x95, 0x05, 0x12, 0x00, 0x11, 0x76}
+		default:
+			// a reply to another command
+			return []byte{0x28, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+		}
+	})
+	m := NewMercury206(b, policy, zap.NewNop())
+
+	tariffs, err := m.ReadEnergy(context.Background(), address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []float64{123.45, 67.89, 0, 0} {
+		if tariffs[i] != want {
+			t.Errorf("tariff %d: got %v, want %v", i+1, tariffs[i], want)
+		}
+	}
+
+	instant, err := m.ReadInstant(context.Background(), address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Instant{Voltage: 229.5, Current: 5.12, ActivePower: 1176}); instant != want {
+		t.Errorf("got %+v, want %+v", instant, want)
+	}
+
+	_, err = m.ReadTime(context.Background(), address)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("reply to another command: got %v, want %v", err, ErrInvalidResponse)
+	}
+}
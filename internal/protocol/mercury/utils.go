@@ -0,0 +1,71 @@
+package mercury
+
+import (
+	"fmt"
+	"time"
+)
+
+func toBCD(v int) byte {
+	return byte(v/10<<4 | v%10)
+}
+
+func fromBCD(b byte) (int, error) {
+	if b>>4 > 9 || b&0x0F > 9 {
+		return 0, fmt.Errorf("invalid BCD byte: 0x%02X", b)
+	}
+
+	return int(b>>4)*10 + int(b&0x0F), nil
+}
+
+// decodeBCD converts a big-endian BCD number of several bytes.
+func decodeBCD(data []byte) (int, error) {
+	var result int
+	for _, b := range data {
+		v, err := fromBCD(b)
+		if err != nil {
+			return 0, err
+		}
+
+		result = result*100 + v
+	}
+
+	return result, nil
+}
+
+// weekday returns the device day of week: Monday is 1, Sunday is 7.
+func weekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+
+	return int(t.Weekday())
+}
+
+// decodeDate builds the time from BCD fields, year counted from 2000.
+func decodeDate(year, month, day, hour, minute, second byte) (time.Time, error) {
+	fields := []byte{year, month, day, hour, minute, second}
+	values := make([]int, len(fields))
+	for i, b := range fields {
+		v, err := fromBCD(b)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		values[i] = v
+	}
+
+	if values[1] < 1 || values[1] > 12 || values[2] < 1 || values[2] > 31 || values[3] > 23 || values[4] > 59 || values[5] > 59 {
+		return time.Time{}, fmt.Errorf("invalid date: %v", fields)
+	}
+
+	return time.Date(
+		2000+values[0],
+		time.Month(values[1]),
+		values[2],
+		values[3],
+		values[4],
+		values[5],
+		0,
+		time.Local,
+	), nil
+}
@@ -0,0 +1,104 @@
+package mercury
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeBCD(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		value int
+		err   bool
+	}{
+		{"one byte", []byte{0x42}, 42, false},
+		{"energy register", []byte{0x00, 0x01, 0x23, 0x45}, 12345, false},
+		{"voltage", []byte{0x22, 0x95}, 2295, false},
+		{"empty", nil, 0, false},
+		{"invalid low digit", []byte{0x01, 0x2A}, 0, true},
+		{"invalid high digit", []byte{0xA1}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := decodeBCD(tt.data)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %d, want an error", value)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if value != tt.value {
+				t.Fatalf("got %d, want %d", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestToBCD(t *testing.T) {
+	for v := 0; v < 100; v++ {
+		got, err := fromBCD(toBCD(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != v {
+			t.Fatalf("got %d, want %d", got, v)
+		}
+	}
+}
+
+func TestDecodeDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []byte
+		date   time.Time
+		err    bool
+	}{
+		{
+			name:   "date",
+			fields: []byte{0x26, 0x10, 0x18, 0x23, 0x59, 0x30},
+			date:   time.Date(2026, 10, 18, 23, 59, 30, 0, time.Local),
+		},
+		{
+			name:   "invalid month",
+			fields: []byte{0x26, 0x13, 0x18, 0x00, 0x00, 0x00},
+			err:    true,
+		},
+		{
+			name:   "invalid BCD",
+			fields: []byte{0x26, 0x10, 0x1F, 0x00, 0x00, 0x00},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.fields
+
+			date, err := decodeDate(f[0], f[1], f[2], f[3], f[4], f[5])
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %s, want an error", date)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !date.Equal(tt.date) {
+				t.Fatalf("got %s, want %s", date, tt.date)
+			}
+		})
+	}
+}
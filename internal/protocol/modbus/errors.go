@@ -0,0 +1,39 @@
+package modbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDeviceNotResponding = errors.New("the device is not responding")
+	ErrIllegalFunction     = errors.New("the function code is not supported by the device")
+	ErrIllegalDataAddress  = errors.New("the data address is not allowed by the device")
+	ErrIllegalDataValue    = errors.New("the value in the request is not allowed by the device")
+	ErrDeviceFailure       = errors.New("unrecoverable error occurred while performing the request")
+	ErrDeviceBusy          = errors.New("the device is busy processing a long-duration command")
+	ErrGatewayPath         = errors.New("the gateway path is unavailable")
+	ErrGatewayTarget       = errors.New("the gateway target device failed to respond")
+	ErrUnknown             = errors.New("unknown exception")
+	ErrInvalidResponse     = errors.New("invalid response")
+)
+
+// ExceptionError is returned when the device answers with an exception response.
+// The underlying error is one of the Err* values and can be checked with errors.Is.
+type ExceptionError struct {
+	Function byte
+	Code     byte
+	Err      error
+}
+
+func (e *ExceptionError) Error() string {
+	if e.Err == ErrUnknown {
+		return fmt.Sprintf("%s, function: 0x%02X, code: %d", e.Err.Error(), e.Function, e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *ExceptionError) Unwrap() error {
+	return e.Err
+}
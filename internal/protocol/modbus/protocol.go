@@ -0,0 +1,259 @@
+package modbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+)
+
+const (
+	fnReadHoldingRegisters byte = 0x03
+	fnReadInputRegisters        = 0x04
+	fnWriteSingleRegister       = 0x06
+)
+
+const (
+	exceptionFlag byte = 0x80
+)
+
+const (
+	exCodeIllegalFunction    byte = 0x01
+	exCodeIllegalDataAddress      = 0x02
+	exCodeIllegalDataValue        = 0x03
+	exCodeDeviceFailure           = 0x04
+	exCodeDeviceBusy              = 0x06
+	exCodeGatewayPath             = 0x0A
+	exCodeGatewayTarget           = 0x0B
+)
+
+// MaxRegisters is the maximum number of registers read by one request.
+const MaxRegisters = 125
+
+var errNeedMoreBytes = errors.New("need more bytes")
+
+// codec wraps PDUs into the ADUs of the transport.
+type codec interface {
+	// encode returns the ADU and a decoder of the response to it.
+	encode(unit byte, pdu []byte) ([]byte, frameDecoder)
+}
+
+type frameDecoder interface {
+	write(data []byte)
+	// next returns the PDU of the next valid response or errNeedMoreBytes.
+	next() ([]byte, error)
+	reset()
+	lineErrors() (droppedBytes uint64, crcErrors uint64)
+}
+
+type Modbus struct {
+	bus    *bus.Bus
+	policy bus.Policy
+	codec  codec
+
+	log *zap.Logger
+}
+
+// NewModbus returns a Modbus RTU client of the devices on a serial line.
+func NewModbus(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *Modbus {
+	return &Modbus{
+		bus:    bus,
+		policy: policy,
+		codec:  rtuCodec{},
+		log:    log,
+	}
+}
+
+// NewModbusTCP returns a Modbus TCP client, the bus is a connection to a device or a gateway.
+func NewModbusTCP(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *Modbus {
+	return &Modbus{
+		bus:    bus,
+		policy: policy,
+		codec:  &tcpCodec{},
+		log:    log,
+	}
+}
+
+func (s *Modbus) ReadHoldingRegisters(ctx context.Context, unit byte, address uint16, quantity uint16) ([]uint16, error) {
+	return s.readRegisters(ctx, unit, fnReadHoldingRegisters, address, quantity)
+}
+
+func (s *Modbus) ReadInputRegisters(ctx context.Context, unit byte, address uint16, quantity uint16) ([]uint16, error) {
+	return s.readRegisters(ctx, unit, fnReadInputRegisters, address, quantity)
+}
+
+func (s *Modbus) WriteSingleRegister(ctx context.Context, unit byte, address uint16, value uint16) error {
+	resp, err := s.request(ctx, unit, []byte{
+		fnWriteSingleRegister,
+		byte(address >> 8), byte(address),
+		byte(value >> 8), byte(value),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(resp) != 5 || uint16(resp[3])<<8|uint16(resp[4]) != value {
+		return ErrInvalidResponse
+	}
+
+	return nil
+}
+
+func (s *Modbus) readRegisters(
+	ctx context.Context,
+	unit byte,
+	function byte,
+	address uint16,
+	quantity uint16,
+) ([]uint16, error) {
+	if quantity == 0 || quantity > MaxRegisters {
+		return nil, fmt.Errorf("invalid registers quantity: %d", quantity)
+	}
+
+	resp, err := s.request(ctx, unit, []byte{
+		function,
+		byte(address >> 8), byte(address),
+		byte(quantity >> 8), byte(quantity),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) < 2 || int(resp[1]) != int(quantity)*2 || len(resp) != int(resp[1])+2 {
+		return nil, ErrInvalidResponse
+	}
+
+	registers := make([]uint16, quantity)
+	for i := range registers {
+		registers[i] = uint16(resp[2+i*2])<<8 | uint16(resp[3+i*2])
+	}
+
+	s.log.Debug(
+		"read registers",
+		zap.Uint8("unit", unit),
+		zap.Uint8("function", function),
+		zap.Uint16("address", address),
+		zap.Uint16s("registers", registers),
+	)
+
+	return registers, nil
+}
+
+// request sends the PDU to the unit and returns the PDU of the response.
+func (s *Modbus) request(ctx context.Context, unit byte, pdu []byte) ([]byte, error) {
+	var resp []byte
+	err := s.bus.Request(ctx, s.policy, func(ctx context.Context, tx *bus.Tx) error {
+		req, dec := s.codec.encode(unit, pdu)
+
+		err := tx.Write(req)
+		if err != nil {
+			return err
+		}
+
+		resp, err = s.receiveResponse(ctx, tx, dec)
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrDeviceNotResponding
+		}
+
+		return nil, err
+	}
+
+	if resp[0]&exceptionFlag > 0 {
+		if len(resp) < 2 {
+			return nil, ErrInvalidResponse
+		}
+
+		return nil, mapExceptionCode(pdu[0], resp[1])
+	}
+
+	return resp, nil
+}
+
+func (s *Modbus) receiveResponse(ctx context.Context, tx *bus.Tx, dec frameDecoder) ([]byte, error) {
+	defer func() {
+		dec.reset()
+		s.reportLineErrors(tx, dec)
+	}()
+
+	for {
+		data, err := tx.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		dec.write(data)
+
+		pdu, err := dec.next()
+		if err == errNeedMoreBytes {
+			continue
+		}
+
+		return pdu, nil
+	}
+}
+
+func (s *Modbus) reportLineErrors(tx *bus.Tx, dec frameDecoder) {
+	droppedBytes, crcErrors := dec.lineErrors()
+	if droppedBytes == 0 && crcErrors == 0 {
+		return
+	}
+
+	tx.ReportLineErrors(droppedBytes, crcErrors)
+
+	s.log.Warn(
+		"line errors",
+		zap.Uint64("droppedBytes", droppedBytes),
+		zap.Uint64("crcErrors", crcErrors),
+	)
+}
+
+func mapExceptionCode(function byte, code byte) error {
+	var err error
+
+	switch code {
+	case exCodeIllegalFunction:
+		err = ErrIllegalFunction
+	case exCodeIllegalDataAddress:
+		err = ErrIllegalDataAddress
+	case exCodeIllegalDataValue:
+		err = ErrIllegalDataValue
+	case exCodeDeviceFailure:
+		err = ErrDeviceFailure
+	case exCodeDeviceBusy:
+		err = ErrDeviceBusy
+	case exCodeGatewayPath:
+		err = ErrGatewayPath
+	case exCodeGatewayTarget:
+		err = ErrGatewayTarget
+	default:
+		err = ErrUnknown
+	}
+
+	return &ExceptionError{Function: function, Code: code, Err: err}
+}
+
+func calculateCrc(data []byte) uint16 {
+	var (
+		i, j   int
+		result uint16 = 0xFFFF
+	)
+
+	for i = 0; i < len(data); i++ {
+		result ^= uint16(data[i])
+
+		for j = 0; j < 8; j++ {
+			if result&0x1 > 0 {
+				result = (result >> 1) ^ 0xA001
+			} else {
+				result = result >> 1
+			}
+		}
+	}
+
+	return result
+}
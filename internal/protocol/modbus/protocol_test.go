@@ -0,0 +1,242 @@
+package modbus
+
+import (
+	"context"
+	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+const testUnit byte = 0x11
+
+var testPolicy = bus.Policy{Timeout: time.Second}
+
+// testRegisters is a register map of one value of every data type.
+var testRegisters = []struct {
+	function  byte
+	address   uint16
+	dataType  DataType
+	wordOrder WordOrder
+	value     float64
+}{
+	{fnReadHoldingRegisters, 0x0000, TypeUint16, WordOrderBig, 230},
+	{fnReadHoldingRegisters, 0x0001, TypeInt16, WordOrderBig, -12},
+	{fnReadHoldingRegisters, 0x0010, TypeUint32, WordOrderLittle, 123456},
+	{fnReadInputRegisters, 0x0000, TypeInt32, WordOrderBig, -654321},
+	{fnReadInputRegisters, 0x0002, TypeFloat32, WordOrderLittle, 49.5},
+	{fnReadInputRegisters, 0x0100, TypeUint64, WordOrderBig, 1 << 40},
+	{fnReadInputRegisters, 0x0104, TypeFloat64, WordOrderLittle, 12345.678},
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+
+	for _, r := range testRegisters {
+		values, err := Encode(r.value, r.dataType, r.wordOrder)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.function == fnReadHoldingRegisters {
+			server.SetHoldingRegisters(testUnit, r.address, values)
+		} else {
+			server.SetInputRegisters(testUnit, r.address, values)
+		}
+	}
+
+	return server
+}
+
+func newTestBus(t *testing.T, port io.ReadWriteCloser) *bus.Bus {
+	t.Helper()
+
+	b := bus.NewBus(port, 0, testPolicy, zap.NewNop())
+	t.Cleanup(func() { _ = port.Close() })
+
+	return b
+}
+
+// newTCPClient connects a Modbus TCP client to the server.
+func newTCPClient(t *testing.T, server *Server) *Modbus {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewModbusTCP(newTestBus(t, conn), testPolicy, zap.NewNop())
+}
+
+// newRTUClient connects a Modbus RTU client to a device on a pipe which serves
+// the register tables of the server.
+func newRTUClient(t *testing.T, server *Server) *Modbus {
+	t.Helper()
+
+	client, device := net.Pipe()
+	t.Cleanup(func() { _ = device.Close() })
+
+	go serveRTU(server, device)
+
+	return NewModbus(newTestBus(t, client), testPolicy, zap.NewNop())
+}
+
+// newClients returns TCP and RTU clients of the register tables of the server.
+func newClients(t *testing.T, server *Server) map[string]*Modbus {
+	t.Helper()
+
+	return map[string]*Modbus{
+		"tcp": newTCPClient(t, server),
+		"rtu": newRTUClient(t, server),
+	}
+}
+
+// serveRTU answers the requests of the client, all of them are 8 bytes long.
+func serveRTU(server *Server, conn net.Conn) {
+	req := make([]byte, writeFrameLen)
+
+	for {
+		_, err := io.ReadFull(conn, req)
+		if err != nil {
+			return
+		}
+
+		if calculateCrc(req[:6]) != uint16(req[6])|uint16(req[7])<<8 {
+			continue
+		}
+
+		resp := append([]byte{req[0]}, server.process(req[0], req[1:6])...)
+		crc := calculateCrc(resp)
+		resp = append(resp, byte(crc), byte(crc>>8))
+
+		_, err = conn.Write(resp)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestReadRegisterMap(t *testing.T) {
+	server := newTestServer(t)
+
+	for name, client := range newClients(t, server) {
+		t.Run(name, func(t *testing.T) {
+			for _, r := range testRegisters {
+				count, _ := r.dataType.Registers()
+
+				read := client.ReadHoldingRegisters
+				if r.function == fnReadInputRegisters {
+					read = client.ReadInputRegisters
+				}
+
+				registers, err := read(context.Background(), testUnit, r.address, uint16(count))
+				if err != nil {
+					t.Fatalf("%s at 0x%04X: %v", r.dataType, r.address, err)
+				}
+
+				value, err := Decode(registers, r.dataType, r.wordOrder)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if value != r.value {
+					t.Errorf("%s at 0x%04X: got %v, want %v", r.dataType, r.address, value, r.value)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteSingleRegister(t *testing.T) {
+	server := newTestServer(t)
+
+	for name, client := range newClients(t, server) {
+		t.Run(name, func(t *testing.T) {
+			err := client.WriteSingleRegister(context.Background(), testUnit, 0x0000, 0xBEEF)
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			registers, err := client.ReadHoldingRegisters(context.Background(), testUnit, 0x0000, 1)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+
+			if registers[0] != 0xBEEF {
+				t.Fatalf("got 0x%04X, want 0xBEEF", registers[0])
+			}
+		})
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		unit     byte
+		address  uint16
+		quantity uint16
+		err      error
+	}{
+		{"unmapped address", testUnit, 0x0200, 1, ErrIllegalDataAddress},
+		{"range over unmapped address", testUnit, 0x0001, 2, ErrIllegalDataAddress},
+		{"unknown unit", testUnit + 1, 0x0000, 1, ErrGatewayTarget},
+	}
+
+	for name, client := range newClients(t, server) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				_, err := client.ReadHoldingRegisters(context.Background(), tt.unit, tt.address, tt.quantity)
+
+				var exception *ExceptionError
+				if !errors.As(err, &exception) || !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				if exception.Function != fnReadHoldingRegisters {
+					t.Errorf("function: got 0x%02X, want 0x%02X", exception.Function, fnReadHoldingRegisters)
+				}
+			})
+		}
+	}
+}
+
+func TestShortExceptionResponse(t *testing.T) {
+	client, device := net.Pipe()
+	defer device.Close()
+
+	// the device answers with an exception PDU which lacks the exception code
+	go func() {
+		header := make([]byte, mbapHeaderLen)
+		_, err := io.ReadFull(device, header)
+		if err != nil {
+			return
+		}
+
+		id, unit, length, _ := decodeMBAP(header)
+		_, err = io.ReadFull(device, make([]byte, length-1))
+		if err != nil {
+			return
+		}
+
+		_, _ = device.Write(encodeMBAP(id, unit, []byte{fnReadHoldingRegisters | exceptionFlag}))
+	}()
+
+	m := NewModbusTCP(newTestBus(t, client), testPolicy, zap.NewNop())
+
+	_, err := m.ReadHoldingRegisters(context.Background(), testUnit, 0x0000, 1)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidResponse)
+	}
+}
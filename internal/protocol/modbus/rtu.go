@@ -0,0 +1,97 @@
+package modbus
+
+const (
+	minFrameLen       = 5
+	exceptionFrameLen = 5
+	writeFrameLen     = 8
+)
+
+// rtuCodec frames PDUs as RTU ADUs: unit id, PDU and CRC-16.
+type rtuCodec struct{}
+
+// rtuDecoder extracts RTU frames of one device from a byte stream. RTU frames carry
+// no length, so it is derived from the function code and the byte count field.
+type rtuDecoder struct {
+	unit     byte
+	function byte
+	buffer   []byte
+
+	droppedBytes uint64
+	crcErrors    uint64
+}
+
+func (rtuCodec) encode(unit byte, pdu []byte) ([]byte, frameDecoder) {
+	adu := make([]byte, 0, len(pdu)+3)
+	adu = append(adu, unit)
+	adu = append(adu, pdu...)
+	crc := calculateCrc(adu)
+	adu = append(adu, byte(crc), byte(crc>>8))
+
+	return adu, &rtuDecoder{unit: unit, function: pdu[0]}
+}
+
+func (d *rtuDecoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+// next returns the PDU of the next valid frame or errNeedMoreBytes if the buffer holds none.
+func (d *rtuDecoder) next() ([]byte, error) {
+	for {
+		if len(d.buffer) < 3 {
+			return nil, errNeedMoreBytes
+		}
+
+		if d.buffer[0] != d.unit || d.buffer[1]&^exceptionFlag != d.function {
+			d.skip()
+			continue
+		}
+
+		length := d.frameLen()
+		if len(d.buffer) < length {
+			return nil, errNeedMoreBytes
+		}
+
+		window := d.buffer[:length]
+		crc := uint16(window[length-2]) | uint16(window[length-1])<<8
+		if calculateCrc(window[:length-2]) != crc {
+			d.crcErrors++
+			d.skip()
+			continue
+		}
+
+		pdu := make([]byte, length-3)
+		copy(pdu, window[1:length-2])
+
+		d.buffer = d.buffer[length:]
+
+		return pdu, nil
+	}
+}
+
+func (d *rtuDecoder) frameLen() int {
+	if d.buffer[1]&exceptionFlag > 0 {
+		return exceptionFrameLen
+	}
+
+	switch d.function {
+	case fnReadHoldingRegisters, fnReadInputRegisters:
+		return minFrameLen + int(d.buffer[2])
+	default:
+		return writeFrameLen
+	}
+}
+
+func (d *rtuDecoder) skip() {
+	d.buffer = d.buffer[1:]
+	d.droppedBytes++
+}
+
+// reset drops the rest of the buffer, e.g. when the transaction is over.
+func (d *rtuDecoder) reset() {
+	d.droppedBytes += uint64(len(d.buffer))
+	d.buffer = nil
+}
+
+func (d *rtuDecoder) lineErrors() (uint64, uint64) {
+	return d.droppedBytes, d.crcErrors
+}
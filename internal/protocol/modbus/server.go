@@ -0,0 +1,182 @@
+package modbus
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// Server is an in-process stand-in for a Modbus TCP device or gateway. It serves
+// register reads and single register writes from the register tables of its units.
+type Server struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	units map[byte]*serverUnit
+
+	wg sync.WaitGroup
+}
+
+type serverUnit struct {
+	holding map[uint16]uint16
+	input   map[uint16]uint16
+}
+
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		units:    make(map[byte]*serverUnit),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// SetHoldingRegisters stores values starting from the address, the unit is created on the first call.
+func (s *Server) SetHoldingRegisters(unit byte, address uint16, values []uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.unit(unit)
+	for i, value := range values {
+		u.holding[address+uint16(i)] = value
+	}
+}
+
+// SetInputRegisters stores values starting from the address, the unit is created on the first call.
+func (s *Server) SetInputRegisters(unit byte, address uint16, values []uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.unit(unit)
+	for i, value := range values {
+		u.input[address+uint16(i)] = value
+	}
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) unit(unit byte) *serverUnit {
+	u, ok := s.units[unit]
+	if !ok {
+		u = &serverUnit{
+			holding: make(map[uint16]uint16),
+			input:   make(map[uint16]uint16),
+		}
+		s.units[unit] = u
+	}
+
+	return u
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	header := make([]byte, mbapHeaderLen)
+
+	for {
+		_, err := io.ReadFull(conn, header)
+		if err != nil {
+			return
+		}
+
+		id, unit, length, ok := decodeMBAP(header)
+		if !ok {
+			return
+		}
+
+		pdu := make([]byte, length-1)
+		_, err = io.ReadFull(conn, pdu)
+		if err != nil {
+			return
+		}
+
+		_, err = conn.Write(encodeMBAP(id, unit, s.process(unit, pdu)))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) process(unit byte, pdu []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	function := pdu[0]
+
+	u, ok := s.units[unit]
+	if !ok {
+		return []byte{function | exceptionFlag, exCodeGatewayTarget}
+	}
+
+	if len(pdu) != 5 {
+		return []byte{function | exceptionFlag, exCodeIllegalDataValue}
+	}
+
+	address := uint16(pdu[1])<<8 | uint16(pdu[2])
+	value := uint16(pdu[3])<<8 | uint16(pdu[4])
+
+	switch function {
+	case fnReadHoldingRegisters, fnReadInputRegisters:
+		table := u.holding
+		if function == fnReadInputRegisters {
+			table = u.input
+		}
+
+		if value == 0 || value > MaxRegisters {
+			return []byte{function | exceptionFlag, exCodeIllegalDataValue}
+		}
+
+		resp := []byte{function, byte(value * 2)}
+		for i := uint16(0); i < value; i++ {
+			register, ok := table[address+i]
+			if !ok {
+				return []byte{function | exceptionFlag, exCodeIllegalDataAddress}
+			}
+
+			resp = append(resp, byte(register>>8), byte(register))
+		}
+
+		return resp
+	case fnWriteSingleRegister:
+		if _, ok := u.holding[address]; !ok {
+			return []byte{function | exceptionFlag, exCodeIllegalDataAddress}
+		}
+
+		u.holding[address] = value
+
+		return pdu
+	default:
+		return []byte{function | exceptionFlag, exCodeIllegalFunction}
+	}
+}
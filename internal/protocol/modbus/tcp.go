@@ -0,0 +1,105 @@
+package modbus
+
+import (
+	"sync/atomic"
+)
+
+const (
+	mbapHeaderLen = 7
+	// maxADULen is the maximum length of a Modbus TCP ADU.
+	maxADULen = 260
+)
+
+// tcpCodec frames PDUs as Modbus TCP ADUs: MBAP header and PDU. Every request
+// gets its own transaction id, so late responses of timed out requests are skipped.
+type tcpCodec struct {
+	transactionID atomic.Uint32
+}
+
+type tcpDecoder struct {
+	transactionID uint16
+	unit          byte
+	function      byte
+	buffer        []byte
+
+	droppedBytes uint64
+}
+
+func (c *tcpCodec) encode(unit byte, pdu []byte) ([]byte, frameDecoder) {
+	id := uint16(c.transactionID.Add(1))
+
+	adu := encodeMBAP(id, unit, pdu)
+
+	return adu, &tcpDecoder{transactionID: id, unit: unit, function: pdu[0]}
+}
+
+func (d *tcpDecoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+func (d *tcpDecoder) next() ([]byte, error) {
+	for {
+		if len(d.buffer) < mbapHeaderLen+1 {
+			return nil, errNeedMoreBytes
+		}
+
+		id, unit, length, ok := decodeMBAP(d.buffer)
+		if !ok {
+			d.skip(1)
+			continue
+		}
+
+		if len(d.buffer) < mbapHeaderLen-1+length {
+			return nil, errNeedMoreBytes
+		}
+
+		adu := d.buffer[:mbapHeaderLen-1+length]
+		if id != d.transactionID || unit != d.unit || adu[mbapHeaderLen]&^exceptionFlag != d.function {
+			d.skip(len(adu))
+			continue
+		}
+
+		pdu := make([]byte, len(adu)-mbapHeaderLen)
+		copy(pdu, adu[mbapHeaderLen:])
+
+		d.buffer = d.buffer[len(adu):]
+
+		return pdu, nil
+	}
+}
+
+func (d *tcpDecoder) skip(n int) {
+	d.buffer = d.buffer[n:]
+	d.droppedBytes += uint64(n)
+}
+
+func (d *tcpDecoder) reset() {
+	d.droppedBytes += uint64(len(d.buffer))
+	d.buffer = nil
+}
+
+func (d *tcpDecoder) lineErrors() (uint64, uint64) {
+	return d.droppedBytes, 0
+}
+
+func encodeMBAP(id uint16, unit byte, pdu []byte) []byte {
+	length := len(pdu) + 1
+
+	adu := make([]byte, 0, mbapHeaderLen+len(pdu))
+	adu = append(adu, byte(id>>8), byte(id), 0x00, 0x00, byte(length>>8), byte(length), unit)
+	adu = append(adu, pdu...)
+
+	return adu
+}
+
+// decodeMBAP parses the MBAP header, length counts the unit id and the PDU.
+func decodeMBAP(data []byte) (id uint16, unit byte, length int, ok bool) {
+	id = uint16(data[0])<<8 | uint16(data[1])
+	protocol := uint16(data[2])<<8 | uint16(data[3])
+	length = int(data[4])<<8 | int(data[5])
+	unit = data[6]
+
+	ok = protocol == 0 && length >= 2 && length <= maxADULen-mbapHeaderLen+1
+
+	return id, unit, length, ok
+}
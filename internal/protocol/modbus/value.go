@@ -0,0 +1,113 @@
+package modbus
+
+import (
+	"fmt"
+	"math"
+)
+
+type DataType string
+
+const (
+	TypeUint16  DataType = "uint16"
+	TypeInt16   DataType = "int16"
+	TypeUint32  DataType = "uint32"
+	TypeInt32   DataType = "int32"
+	TypeFloat32 DataType = "float32"
+	TypeUint64  DataType = "uint64"
+	TypeInt64   DataType = "int64"
+	TypeFloat64 DataType = "float64"
+)
+
+// WordOrder is the order of registers of multi-register values,
+// the bytes inside of a register are always big-endian.
+type WordOrder string
+
+const (
+	// WordOrderBig stores the most significant word first.
+	WordOrderBig WordOrder = "big"
+	// WordOrderLittle stores the least significant word first.
+	WordOrderLittle WordOrder = "little"
+)
+
+// Registers returns the number of registers occupied by a value of the type.
+func (t DataType) Registers() (int, error) {
+	switch t {
+	case TypeUint16, TypeInt16:
+		return 1, nil
+	case TypeUint32, TypeInt32, TypeFloat32:
+		return 2, nil
+	case TypeUint64, TypeInt64, TypeFloat64:
+		return 4, nil
+	default:
+		return 0, fmt.Errorf("unsupported data type \"%s\"", t)
+	}
+}
+
+// Decode converts registers to a value of the data type.
+func Decode(registers []uint16, dataType DataType, wordOrder WordOrder) (float64, error) {
+	count, err := dataType.Registers()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(registers) != count {
+		return 0, fmt.Errorf("invalid registers count for %s: %d", dataType, len(registers))
+	}
+
+	var raw uint64
+	for i := 0; i < count; i++ {
+		word := registers[i]
+		if wordOrder == WordOrderLittle {
+			word = registers[count-1-i]
+		}
+
+		raw = raw<<16 | uint64(word)
+	}
+
+	switch dataType {
+	case TypeUint16, TypeUint32, TypeUint64:
+		return float64(raw), nil
+	case TypeInt16:
+		return float64(int16(raw)), nil
+	case TypeInt32:
+		return float64(int32(raw)), nil
+	case TypeInt64:
+		return float64(int64(raw)), nil
+	case TypeFloat32:
+		return float64(math.Float32frombits(uint32(raw))), nil
+	default:
+		return math.Float64frombits(raw), nil
+	}
+}
+
+// Encode converts a value to registers of the data type, the opposite of Decode.
+func Encode(value float64, dataType DataType, wordOrder WordOrder) ([]uint16, error) {
+	count, err := dataType.Registers()
+	if err != nil {
+		return nil, err
+	}
+
+	var raw uint64
+	switch dataType {
+	case TypeUint16, TypeUint32, TypeUint64:
+		raw = uint64(value)
+	case TypeInt16, TypeInt32, TypeInt64:
+		raw = uint64(int64(value))
+	case TypeFloat32:
+		raw = uint64(math.Float32bits(float32(value)))
+	default:
+		raw = math.Float64bits(value)
+	}
+
+	registers := make([]uint16, count)
+	for i := 0; i < count; i++ {
+		word := uint16(raw >> (16 * (count - 1 - i)))
+		if wordOrder == WordOrderLittle {
+			registers[count-1-i] = word
+		} else {
+			registers[i] = word
+		}
+	}
+
+	return registers, nil
+}
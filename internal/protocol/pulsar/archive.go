@@ -0,0 +1,202 @@
+package pulsar
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+type ArchiveType uint16
+
+const (
+	ArchiveHourly  ArchiveType = 0x0001
+	ArchiveDaily   ArchiveType = 0x0002
+	ArchiveMonthly ArchiveType = 0x0003
+)
+
+// maxArchiveRecords limits the number of values requested in one package,
+// the device answers with errCodeMaxArchive on larger ranges.
+const maxArchiveRecords = 24
+
+// ValueType is the format of the values a device keeps in its channels and archives.
+type ValueType int
+
+const (
+	// ValueUint32 values are kept by electricity meters.
+	ValueUint32 ValueType = iota
+	// ValueFloat32 values are kept by heat meters.
+	ValueFloat32
+	// ValueFloat64 values are kept by water meters and pulse counters.
+	ValueFloat64
+)
+
+type ArchiveRecord struct {
+	Channel int
+	Time    time.Time
+	Value   float64
+}
+
+func (t ValueType) size() int {
+	if t == ValueFloat64 {
+		return 8
+	}
+
+	return 4
+}
+
+func (t ValueType) decode(data []byte) float64 {
+	switch t {
+	case ValueFloat32:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	case ValueFloat64:
+		return math.Float64frombits(binary.LittleEndian.Uint64(data))
+	default:
+		return float64(binary.LittleEndian.Uint32(data))
+	}
+}
+
+func (t ArchiveType) String() string {
+	switch t {
+	case ArchiveHourly:
+		return "hourly"
+	case ArchiveDaily:
+		return "daily"
+	case ArchiveMonthly:
+		return "monthly"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint16(t))
+	}
+}
+
+func (t ArchiveType) next(date time.Time, n int) time.Time {
+	switch t {
+	case ArchiveHourly:
+		return date.Add(time.Duration(n) * time.Hour)
+	case ArchiveDaily:
+		return date.AddDate(0, 0, n)
+	default:
+		return date.AddDate(0, n, 0)
+	}
+}
+
+func (t ArchiveType) truncate(date time.Time) time.Time {
+	switch t {
+	case ArchiveHourly:
+		return time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), 0, 0, 0, date.Location())
+	case ArchiveDaily:
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	default:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	}
+}
+
+// ReadArchive reads archived values of every channel in mask between from and to inclusive,
+// the values are decoded by the value type of the device. The range is split into packages
+// of maxArchiveRecords values per channel.
+func (s *Pulsar) ReadArchive(
+	ctx context.Context,
+	address [4]byte,
+	mask uint32,
+	archiveType ArchiveType,
+	valueType ValueType,
+	from, to time.Time,
+) ([]ArchiveRecord, error) {
+	switch archiveType {
+	case ArchiveHourly, ArchiveDaily, ArchiveMonthly:
+	default:
+		return nil, fmt.Errorf("unsupported archive type %d", archiveType)
+	}
+
+	switch valueType {
+	case ValueUint32, ValueFloat32, ValueFloat64:
+	default:
+		return nil, fmt.Errorf("unsupported value type %d", valueType)
+	}
+
+	from = archiveType.truncate(from)
+	to = archiveType.truncate(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid archive range: %s - %s", from, to)
+	}
+
+	var records []ArchiveRecord
+
+	for channel := 0; channel < 32; channel++ {
+		if mask&(1<<channel) == 0 {
+			continue
+		}
+
+		for start := from; !start.After(to); start = archiveType.next(start, maxArchiveRecords) {
+			end := archiveType.next(start, maxArchiveRecords-1)
+			if end.After(to) {
+				end = to
+			}
+
+			page, err := s.readArchivePage(ctx, address, channel, archiveType, valueType, start, end)
+			if err != nil {
+				return nil, err
+			}
+
+			records = append(records, page...)
+		}
+	}
+
+	return records, nil
+}
+
+func (s *Pulsar) readArchivePage(
+	ctx context.Context,
+	address [4]byte,
+	channel int,
+	archiveType ArchiveType,
+	valueType ValueType,
+	from, to time.Time,
+) ([]ArchiveRecord, error) {
+	mask := uint32(1) << channel
+
+	payload := []byte{
+		byte(mask), byte(mask >> 8), byte(mask >> 16), byte(mask >> 24),
+		byte(archiveType), byte(archiveType >> 8),
+	}
+	payload = append(payload, encodeTime(from)...)
+	payload = append(payload, encodeTime(to)...)
+
+	resp, err := s.request(ctx, address, commReadArchive, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Payload) < 10 {
+		return nil, fmt.Errorf("invalid archive response length: %d", len(resp.Payload))
+	}
+
+	date, err := decodeTime(resp.Payload[4:10])
+	if err != nil {
+		return nil, err
+	}
+
+	data := resp.Payload[10:]
+	size := valueType.size()
+	records := make([]ArchiveRecord, 0, len(data)/size)
+	for i := 0; i+size <= len(data); i += size {
+		records = append(records, ArchiveRecord{
+			Channel: channel,
+			Time:    archiveType.next(date, i/size),
+			Value:   valueType.decode(data[i:]),
+		})
+	}
+
+	s.log.Debug(
+		"ReadArchive",
+		zap.Int("channel", channel),
+		zap.Stringer("type", archiveType),
+		zap.Time("from", from),
+		zap.Time("to", to),
+		zap.Int("records", len(records)),
+	)
+
+	return records, nil
+}
@@ -0,0 +1,110 @@
+package pulsar
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// archiveDevice answers archive requests with the hour of every record since start
+// as its value, encoded by the value type, and keeps the number of records requested
+// in every package.
+type archiveDevice struct {
+	start     time.Time
+	valueType ValueType
+	pages     []int
+}
+
+func (d *archiveDevice) encode(value float64) []byte {
+	data := make([]byte, d.valueType.size())
+
+	switch d.valueType {
+	case ValueFloat32:
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(value)))
+	case ValueFloat64:
+		binary.LittleEndian.PutUint64(data, math.Float64bits(value))
+	default:
+		binary.LittleEndian.PutUint32(data, uint32(value))
+	}
+
+	return data
+}
+
+// answer answers the archive request with the records of the requested range.
+func (d *archiveDevice) answer(req frame) []byte {
+	from, _ := decodeTime(req.Payload[6:12])
+	to, _ := decodeTime(req.Payload[12:18])
+
+	payload := append([]byte(nil), req.Payload[:4]...)
+	payload = append(payload, encodeTime(from)...)
+
+	var records int
+	for at := from; !at.After(to); at = at.Add(time.Hour) {
+		payload = append(payload, d.encode(at.Sub(d.start).Hours())...)
+		records++
+	}
+	d.pages = append(d.pages, records)
+
+	return payload
+}
+
+func TestReadArchive(t *testing.T) {
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		valueType ValueType
+		hours     int
+		pages     []int
+	}{
+		{"one package", ValueUint32, 24, []int{24}},
+		{"package boundary", ValueUint32, 25, []int{24, 1}},
+		{"floats", ValueFloat32, 50, []int{24, 24, 2}},
+		{"doubles", ValueFloat64, 48, []int{24, 24}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := &archiveDevice{start: start, valueType: tt.valueType}
+
+			records, err := newTestPulsar(t, device.answer).ReadArchive(
+				context.Background(),
+				testAddress,
+				1<<2,
+				ArchiveHourly,
+				tt.valueType,
+				start,
+				start.Add(time.Duration(tt.hours-1)*time.Hour),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != tt.hours {
+				t.Fatalf("got %d records, want %d", len(records), tt.hours)
+			}
+
+			for i, record := range records {
+				if at := start.Add(time.Duration(i) * time.Hour); record.Channel != 2 || !record.Time.Equal(at) {
+					t.Errorf("record %d: channel %d at %s, want channel 2 at %s", i, record.Channel, record.Time, at)
+				}
+
+				if record.Value != float64(i) {
+					t.Errorf("record %d: value %v, want %d", i, record.Value, i)
+				}
+			}
+
+			if len(device.pages) != len(tt.pages) {
+				t.Fatalf("got packages %v, want %v", device.pages, tt.pages)
+			}
+
+			for i := range tt.pages {
+				if device.pages[i] != tt.pages[i] {
+					t.Fatalf("got packages %v, want %v", device.pages, tt.pages)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package pulsar
+
+// decoder extracts frames from a byte stream which may start in the middle of a frame,
+// contain line noise or several frames in one chunk. It looks for a window with
+// a sane length and a valid checksum and skips bytes one by one until it finds one.
+type decoder struct {
+	// address filters frames of other devices, the broadcast address accepts any.
+	address [4]byte
+	buffer  []byte
+
+	droppedBytes uint64
+	crcErrors    uint64
+}
+
+func newDecoder(address [4]byte) *decoder {
+	return &decoder{address: address}
+}
+
+func (d *decoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+// next returns the next valid frame or errNeedMoreBytes if the buffer holds none.
+func (d *decoder) next() (frame, error) {
+	for {
+		if len(d.buffer) < 6 {
+			return frame{}, errNeedMoreBytes
+		}
+
+		if !d.matchAddress(0) {
+			d.skip()
+			continue
+		}
+
+		length := int(d.buffer[5])
+		if length < minFrameLen {
+			d.skip()
+			continue
+		}
+
+		if len(d.buffer) < length {
+			// a noise byte taken for the length, which is likely with the broadcast address,
+			// must not hold back a complete frame behind it until the request times out
+			if offset, ok := d.findFrame(1); ok {
+				d.drop(offset)
+				continue
+			}
+
+			return frame{}, errNeedMoreBytes
+		}
+
+		if !d.checkFrame(0, length) {
+			d.crcErrors++
+			d.skip()
+			continue
+		}
+
+		var f frame
+		f.parseBytes(d.buffer[:length])
+
+		d.buffer = d.buffer[length:]
+
+		return f, nil
+	}
+}
+
+// findFrame returns the offset of the first complete valid frame at or after from.
+func (d *decoder) findFrame(from int) (int, bool) {
+	for offset := from; offset+6 <= len(d.buffer); offset++ {
+		if !d.matchAddress(offset) {
+			continue
+		}
+
+		length := int(d.buffer[offset+5])
+		if length < minFrameLen || offset+length > len(d.buffer) {
+			continue
+		}
+
+		if d.checkFrame(offset, length) {
+			return offset, true
+		}
+	}
+
+	return 0, false
+}
+
+func (d *decoder) checkFrame(offset int, length int) bool {
+	window := d.buffer[offset : offset+length]
+	crc := uint16(window[length-2]) | uint16(window[length-1])<<8
+
+	return calculateCrc(window[:length-2]) == crc
+}
+
+func (d *decoder) matchAddress(offset int) bool {
+	if d.address == BroadcastAddress {
+		return true
+	}
+
+	for i := 0; i < 4; i++ {
+		if d.buffer[offset+i] != d.address[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *decoder) skip() {
+	d.drop(1)
+}
+
+func (d *decoder) drop(n int) {
+	d.buffer = d.buffer[n:]
+	d.droppedBytes += uint64(n)
+}
+
+// reset drops the rest of the buffer, e.g. when the transaction is over.
+func (d *decoder) reset() {
+	d.droppedBytes += uint64(len(d.buffer))
+	d.buffer = nil
+}
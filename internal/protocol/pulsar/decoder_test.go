@@ -0,0 +1,192 @@
+package pulsar
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var testAddress = [4]byte{0x08, 0x83, 0x39, 0x76}
+
+func testFrame(address [4]byte, payload ...byte) []byte {
+	f := frame{
+		Address: address,
+		FN:      commReadChannels,
+		Payload: payload,
+		ID:      0x1234,
+	}
+
+	return f.generateBytes()
+}
+
+func corrupt(data []byte) []byte {
+	data = append([]byte(nil), data...)
+	data[len(data)-1] ^= 0xFF
+
+	return data
+}
+
+func concat(chunks ...[]byte) []byte {
+	return bytes.Join(chunks, nil)
+}
+
+func TestDecoder(t *testing.T) {
+	valid := testFrame(testAddress, 0x01, 0x02, 0x03, 0x04)
+	other := testFrame(testAddress, 0x05)
+
+	tests := []struct {
+		name    string
+		address [4]byte
+		chunks  [][]byte
+		frames  [][]byte
+		dropped uint64
+		crc     uint64
+	}{
+		{
+			name:    "one frame",
+			address: testAddress,
+			chunks:  [][]byte{valid},
+			frames:  [][]byte{valid},
+		},
+		{
+			name:    "junk prefix",
+			address: testAddress,
+			chunks:  [][]byte{concat([]byte{0xFF, 0x00, 0x13}, valid)},
+			frames:  [][]byte{valid},
+			dropped: 3,
+		},
+		{
+			name:    "split reads",
+			address: testAddress,
+			chunks:  [][]byte{valid[:3], valid[3:8], valid[8:]},
+			frames:  [][]byte{valid},
+		},
+		{
+			name:    "two frames in one read",
+			address: testAddress,
+			chunks:  [][]byte{concat(valid, other)},
+			frames:  [][]byte{valid, other},
+		},
+		{
+			name:    "bad crc",
+			address: testAddress,
+			chunks:  [][]byte{concat(corrupt(valid), other)},
+			frames:  [][]byte{other},
+			dropped: uint64(len(valid)),
+			crc:     1,
+		},
+		{
+			name:    "short first chunk",
+			address: testAddress,
+			chunks:  [][]byte{valid[:5], valid[5:]},
+			frames:  [][]byte{valid},
+		},
+		{
+			name:    "frame of another device",
+			address: testAddress,
+			chunks:  [][]byte{concat(testFrame([4]byte{0x01, 0x02, 0x03, 0x04}), valid)},
+			frames:  [][]byte{valid},
+			dropped: minFrameLen,
+		},
+		{
+			name:    "broadcast with junk length",
+			address: BroadcastAddress,
+			chunks:  [][]byte{concat([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0xFF}, valid)},
+			frames:  [][]byte{valid},
+			dropped: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDecoder(tt.address)
+
+			var frames [][]byte
+			for _, chunk := range tt.chunks {
+				d.write(chunk)
+
+				for {
+					f, err := d.next()
+					if errors.Is(err, errNeedMoreBytes) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("next: %v", err)
+					}
+
+					frames = append(frames, f.generateBytes())
+				}
+			}
+
+			if len(frames) != len(tt.frames) {
+				t.Fatalf("got %d frames, want %d", len(frames), len(tt.frames))
+			}
+
+			for i := range frames {
+				if !bytes.Equal(frames[i], tt.frames[i]) {
+					t.Errorf("frame %d: got % X, want % X", i, frames[i], tt.frames[i])
+				}
+			}
+
+			if d.droppedBytes != tt.dropped {
+				t.Errorf("dropped bytes: got %d, want %d", d.droppedBytes, tt.dropped)
+			}
+
+			if d.crcErrors != tt.crc {
+				t.Errorf("crc errors: got %d, want %d", d.crcErrors, tt.crc)
+			}
+		})
+	}
+}
+
+func FuzzDecoder(f *testing.F) {
+	valid := testFrame(testAddress, 0x01, 0x02, 0x03, 0x04)
+
+	f.Add(valid, 3)
+	f.Add(concat([]byte{0x00, 0x13}, valid, valid), 7)
+	f.Add(corrupt(valid), 1)
+
+	f.Fuzz(func(t *testing.T, data []byte, split int) {
+		for _, address := range [][4]byte{testAddress, BroadcastAddress} {
+			d := newDecoder(address)
+
+			if split < 0 || split > len(data) {
+				split = len(data)
+			}
+
+			var decoded int
+			for _, chunk := range [][]byte{data[:split], data[split:]} {
+				d.write(chunk)
+
+				for {
+					fr, err := d.next()
+					if errors.Is(err, errNeedMoreBytes) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("next: %v", err)
+					}
+
+					if address != BroadcastAddress && fr.Address != address {
+						t.Fatalf("frame of address % X", fr.Address)
+					}
+
+					crc := fr.CRC
+					raw := fr.generateBytes()
+					if crc != fr.CRC {
+						t.Fatalf("frame with invalid crc: % X", raw)
+					}
+
+					decoded += len(raw)
+				}
+			}
+
+			if uint64(decoded)+d.droppedBytes+uint64(len(d.buffer)) != uint64(len(data)) {
+				t.Fatalf(
+					"decoded %d, dropped %d and buffered %d bytes of %d",
+					decoded, d.droppedBytes, len(d.buffer), len(data),
+				)
+			}
+		}
+	})
+}
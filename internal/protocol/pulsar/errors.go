@@ -0,0 +1,40 @@
+package pulsar
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDeviceNotResponding = errors.New("the device is not responding")
+	ErrUnknownFunction     = errors.New("the requested function code is unknown")
+	ErrBitMask             = errors.New("error in request bitmask")
+	ErrRequestLength       = errors.New("invalid request length")
+	ErrParam               = errors.New("missing parameter")
+	ErrNeedAuth            = errors.New("the entry is blocked, authorization is required")
+	ErrWriteParamRange     = errors.New("the parameter written is outside the specified range")
+	ErrUnknownArchiveType  = errors.New("the requested archive type is missing")
+	ErrMaxArchive          = errors.New("exceeding the maximum number of archived values per package")
+	ErrUnknown             = errors.New("unknown error")
+	ErrRejected            = errors.New("the device rejected the request")
+	ErrAuthFailed          = errors.New("authorization failed")
+)
+
+// DeviceError is returned when the device answers with an error frame.
+// The underlying error is one of the Err* values and can be checked with errors.Is.
+type DeviceError struct {
+	Code byte
+	Err  error
+}
+
+func (e *DeviceError) Error() string {
+	if e.Err == ErrUnknown {
+		return fmt.Sprintf("%s, code: %d", e.Err.Error(), e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
@@ -2,7 +2,10 @@ package pulsar
 
 import (
 	"errors"
-	"fmt"
+)
+
+const (
+	minFrameLen = 10
 )
 
 var (
@@ -16,61 +19,38 @@ type frame struct {
 	Payload []byte
 	ID      uint16
 	CRC     uint16
-
-	buffer []byte
 }
 
-func (rr *frame) parseBytes(bytes []byte) error {
+// parseBytes fills the frame from a complete frame window. The window length
+// and checksum must be validated by the caller.
+func (rr *frame) parseBytes(bytes []byte) {
 	var i int
 
-	rr.buffer = append(rr.buffer, bytes...)
-
-	if len(rr.buffer) < 10 {
-		return errors.New("frame length less that 10")
-	}
-
 	for i = 0; i < 4; i++ {
-		rr.Address[i] = rr.buffer[i]
+		rr.Address[i] = bytes[i]
 	}
 
-	rr.FN = rr.buffer[i]
+	rr.FN = bytes[i]
 	i++
 
-	rr.Len = rr.buffer[i]
+	rr.Len = bytes[i]
 	i++
 
-	if int(rr.Len) > len(rr.buffer) {
-		return errNeedMoreBytes
-	}
-
-	if rr.Len > 10 {
-		j := 0
-		rr.Payload = make([]byte, rr.Len-4)
-
-		for i < int(rr.Len)-4 {
-			rr.Payload[j] = rr.buffer[i]
-			i++
-			j++
-		}
+	rr.Payload = nil
+	if rr.Len > minFrameLen {
+		rr.Payload = make([]byte, int(rr.Len)-minFrameLen)
+		copy(rr.Payload, bytes[i:int(rr.Len)-4])
+		i = int(rr.Len) - 4
 	}
 
-	rr.ID = uint16(rr.buffer[i])
+	rr.ID = uint16(bytes[i])
 	i++
-	rr.ID |= uint16(rr.buffer[i]) << 8
+	rr.ID |= uint16(bytes[i]) << 8
 	i++
 
-	crc := calculateCrc(rr.buffer[:i])
-
-	rr.CRC = uint16(rr.buffer[i])
-	i++
-	rr.CRC |= uint16(rr.buffer[i]) << 8
+	rr.CRC = uint16(bytes[i])
 	i++
-
-	if crc != rr.CRC {
-		return fmt.Errorf("invalid crc. Excepted: 0x%X. Actual: 0x%X", rr.CRC, crc)
-	}
-
-	return nil
+	rr.CRC |= uint16(bytes[i]) << 8
 }
 
 func (rr *frame) generateBytes() []byte {
@@ -2,10 +2,12 @@ package pulsar
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/lan143/metrology-master/internal/bus"
 	"go.uber.org/zap"
-	"io"
+	"math"
 	"math/rand"
 	"time"
 )
@@ -13,7 +15,12 @@ import (
 const (
 	commError        byte = 0x00
 	commReadChannels      = 0x01
+	commReadTime          = 0x04
+	commWriteTime         = 0x05
+	commReadArchive       = 0x06
 	commReadParam         = 0x0A
+	commWriteParam        = 0x0B
+	commAuthorize         = 0x0C
 )
 
 const (
@@ -34,7 +41,7 @@ const (
 )
 
 var (
-	ErrDeviceNotResponding = errors.New("the device is not responding")
+	BroadcastAddress = [4]byte{0x00, 0x00, 0x00, 0x00}
 )
 
 type Version struct {
@@ -43,28 +50,49 @@ type Version struct {
 }
 
 type Pulsar struct {
-	port      io.ReadWriteCloser
-	responses map[uint16]chan frame
+	bus    *bus.Bus
+	policy bus.Policy
 
 	log *zap.Logger
 }
 
-func NewPulsar(port io.ReadWriteCloser, log *zap.Logger) *Pulsar {
-	s := &Pulsar{
-		port: port,
-		log:  log,
+func NewPulsar(bus *bus.Bus, policy bus.Policy, log *zap.Logger) *Pulsar {
+	return &Pulsar{
+		bus:    bus,
+		policy: policy,
+		log:    log,
 	}
-	s.responses = make(map[uint16]chan frame)
-	go s.processResponse()
-
-	return s
 }
 
+// ReadChannels reads channels of devices which keep values as unsigned integers, e.g. electricity meters.
 func (s *Pulsar) ReadChannels(ctx context.Context, address [4]byte, mask uint32) ([]uint32, error) {
-	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
-	defer cancel()
+	resp, err := s.request(
+		ctx,
+		address,
+		commReadChannels,
+		[]byte{byte(mask), byte(mask >> 8), byte(mask >> 16), byte(mask >> 24)},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	respChan, err := s.sendRequest(
+	payload := make([]uint32, len(resp.Payload)/4)
+	for i := range payload {
+		payload[i] = binary.LittleEndian.Uint32(resp.Payload[i*4:])
+	}
+
+	s.log.Debug(
+		"ReadChannels",
+		zap.Any("uint32", payload),
+	)
+
+	return payload, nil
+}
+
+// ReadChannelsFloat64 reads channels of devices which keep values as doubles, e.g. water and pulse counters.
+func (s *Pulsar) ReadChannelsFloat64(ctx context.Context, address [4]byte, mask uint32) ([]float64, error) {
+	resp, err := s.request(
+		ctx,
 		address,
 		commReadChannels,
 		[]byte{byte(mask), byte(mask >> 8), byte(mask >> 16), byte(mask >> 24)},
@@ -73,34 +101,47 @@ func (s *Pulsar) ReadChannels(ctx context.Context, address [4]byte, mask uint32)
 		return nil, err
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ErrDeviceNotResponding
-	case resp := <-respChan:
-		if resp.FN == commError {
-			return nil, s.mapErrorCode(resp.Payload[0])
-		}
+	payload := make([]float64, len(resp.Payload)/8)
+	for i := range payload {
+		payload[i] = math.Float64frombits(binary.LittleEndian.Uint64(resp.Payload[i*8:]))
+	}
 
-		payload := make([]uint32, len(resp.Payload)/4)
-		for i := 0; i < len(resp.Payload)/4; i++ {
-			payload[i] = (uint32(resp.Payload[i+3]) << 24) | (uint32(resp.Payload[i+2]) << 16) |
-				(uint32(resp.Payload[i+1]) << 8) | uint32(resp.Payload[i])
-		}
+	s.log.Debug(
+		"ReadChannelsFloat64",
+		zap.Any("float64", payload),
+	)
 
-		s.log.Debug(
-			"ReadChannels",
-			zap.Any("uint32", payload),
-		)
+	return payload, nil
+}
 
-		return payload, nil
+// ReadChannelsFloat32 reads channels of devices which keep values as floats, e.g. heat meters.
+func (s *Pulsar) ReadChannelsFloat32(ctx context.Context, address [4]byte, mask uint32) ([]float32, error) {
+	resp, err := s.request(
+		ctx,
+		address,
+		commReadChannels,
+		[]byte{byte(mask), byte(mask >> 8), byte(mask >> 16), byte(mask >> 24)},
+	)
+	if err != nil {
+		return nil, err
 	}
+
+	payload := make([]float32, len(resp.Payload)/4)
+	for i := range payload {
+		payload[i] = math.Float32frombits(binary.LittleEndian.Uint32(resp.Payload[i*4:]))
+	}
+
+	s.log.Debug(
+		"ReadChannelsFloat32",
+		zap.Any("float32", payload),
+	)
+
+	return payload, nil
 }
 
 func (s *Pulsar) ReadParam(ctx context.Context, address [4]byte, index uint16) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
-	defer cancel()
-
-	respChan, err := s.sendRequest(
+	resp, err := s.request(
+		ctx,
 		address,
 		commReadParam,
 		[]byte{byte(index), byte(index >> 8)},
@@ -109,21 +150,129 @@ func (s *Pulsar) ReadParam(ctx context.Context, address [4]byte, index uint16) (
 		return nil, err
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ErrDeviceNotResponding
-	case resp := <-respChan:
-		if resp.FN == commError {
-			return nil, s.mapErrorCode(resp.Payload[0])
-		}
+	s.log.Debug(
+		"ReadParam",
+		zap.Any("Data", resp.Payload),
+	)
 
-		s.log.Debug(
-			"ReadParam",
-			zap.Any("Data", resp.Payload),
-		)
+	return resp.Payload, nil
+}
 
-		return resp.Payload, nil
+// ReadUID returns the address of the device answered the request,
+// so it can be used to identify a device by the broadcast address.
+func (s *Pulsar) ReadUID(ctx context.Context, address [4]byte) ([4]byte, error) {
+	resp, err := s.request(
+		ctx,
+		address,
+		commReadParam,
+		[]byte{byte(paramUID), byte(paramUID >> 8)},
+	)
+	if err != nil {
+		return [4]byte{}, err
 	}
+
+	s.log.Debug(
+		"ReadUID",
+		zap.Binary("address", resp.Address[:]),
+	)
+
+	return resp.Address, nil
+}
+
+func (s *Pulsar) ReadTime(ctx context.Context, address [4]byte) (time.Time, error) {
+	resp, err := s.request(ctx, address, commReadTime, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, err := decodeTime(resp.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	s.log.Debug(
+		"ReadTime",
+		zap.Time("time", date),
+	)
+
+	return date, nil
+}
+
+func (s *Pulsar) WriteTime(ctx context.Context, address [4]byte, date time.Time) error {
+	resp, err := s.request(ctx, address, commWriteTime, encodeTime(date))
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Payload) < 1 || resp.Payload[0] != 1 {
+		return ErrRejected
+	}
+
+	s.log.Debug(
+		"WriteTime",
+		zap.Time("time", date),
+	)
+
+	return nil
+}
+
+func (s *Pulsar) WriteParam(ctx context.Context, address [4]byte, index uint16, value []byte) error {
+	if len(value) > 8 {
+		return fmt.Errorf("invalid parameter value length: %d", len(value))
+	}
+
+	payload := make([]byte, 10)
+	payload[0] = byte(index)
+	payload[1] = byte(index >> 8)
+	copy(payload[2:], value)
+
+	resp, err := s.request(ctx, address, commWriteParam, payload)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Payload) < 1 || resp.Payload[0] != 1 {
+		return ErrRejected
+	}
+
+	s.log.Debug(
+		"WriteParam",
+		zap.Uint16("index", index),
+		zap.Binary("value", value),
+	)
+
+	return nil
+}
+
+// Authorize unlocks writing of protected parameters until the device is powered off
+// or the session times out on the device side.
+func (s *Pulsar) Authorize(ctx context.Context, address [4]byte, password uint32) error {
+	resp, err := s.request(
+		ctx,
+		address,
+		commAuthorize,
+		[]byte{byte(password), byte(password >> 8), byte(password >> 16), byte(password >> 24)},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Payload) < 1 || resp.Payload[0] != 1 {
+		return ErrAuthFailed
+	}
+
+	s.log.Debug("Authorize - complete")
+
+	return nil
+}
+
+func (s *Pulsar) WriteAddress(ctx context.Context, address [4]byte, newAddress [4]byte) error {
+	return s.WriteParam(
+		ctx,
+		address,
+		paramAddress,
+		[]byte{newAddress[3], newAddress[2], newAddress[1], newAddress[0]},
+	)
 }
 
 func (s *Pulsar) GetVersion(ctx context.Context, address [4]byte) (Version, error) {
@@ -132,6 +281,10 @@ func (s *Pulsar) GetVersion(ctx context.Context, address [4]byte) (Version, erro
 		return Version{}, err
 	}
 
+	if len(payload) < 8 {
+		return Version{}, fmt.Errorf("invalid version length: %d", len(payload))
+	}
+
 	version := Version{
 		SWVersion: fmt.Sprintf("%d.%d.%d.%d", payload[3], payload[2], payload[7], payload[6]),
 		HWVersion: fmt.Sprintf("%d.%d.%d-%d", payload[5], payload[4], payload[1], payload[0]),
@@ -145,7 +298,7 @@ func (s *Pulsar) GetVersion(ctx context.Context, address [4]byte) (Version, erro
 	return version, nil
 }
 
-func (s *Pulsar) sendRequest(address [4]byte, command byte, payload []byte) (chan frame, error) {
+func (s *Pulsar) request(ctx context.Context, address [4]byte, command byte, payload []byte) (frame, error) {
 	req := frame{
 		Address: address,
 		FN:      command,
@@ -154,93 +307,116 @@ func (s *Pulsar) sendRequest(address [4]byte, command byte, payload []byte) (cha
 		ID:      uint16(rand.Uint32()),
 		CRC:     0,
 	}
-
-	s.responses[req.ID] = make(chan frame, 1)
 	bytes := req.generateBytes()
 
 	s.log.Debug(
 		"send request",
 		zap.Any("request", req),
 	)
-	s.log.Debug(
-		"send bytes",
-		zap.Binary("bytes", bytes),
-	)
 
-	_, err := s.port.Write(bytes)
+	var resp frame
+	err := s.bus.Request(ctx, s.policy, func(ctx context.Context, tx *bus.Tx) error {
+		err := tx.Write(bytes)
+		if err != nil {
+			return err
+		}
+
+		return s.receiveResponse(ctx, tx, req, &resp)
+	})
 	if err != nil {
-		return nil, err
+		if errors.Is(err, context.DeadlineExceeded) {
+			return frame{}, ErrDeviceNotResponding
+		}
+
+		return frame{}, err
+	}
+
+	s.log.Debug("receive response", zap.Any("response", resp))
+
+	if resp.FN == commError {
+		if len(resp.Payload) < 1 {
+			return frame{}, &DeviceError{Err: ErrUnknown}
+		}
+
+		return frame{}, s.mapErrorCode(resp.Payload[0])
 	}
 
-	return s.responses[req.ID], nil
+	return resp, nil
 }
 
-func (s *Pulsar) processResponse() {
-	buff := make([]byte, 255)
-	resp := frame{}
+func (s *Pulsar) receiveResponse(ctx context.Context, tx *bus.Tx, req frame, resp *frame) error {
+	dec := newDecoder(req.Address)
+	defer func() {
+		dec.reset()
+		s.reportLineErrors(tx, dec)
+	}()
 
 	for {
-		n, err := s.port.Read(buff)
+		data, err := tx.Read(ctx)
 		if err != nil {
-			s.log.Error("read port", zap.Error(err))
-		}
-
-		if n == 0 {
-			s.log.Debug("read port - eof")
-			break
+			return err
 		}
 
-		s.log.Debug("receive bytes", zap.Binary("bytes", buff[:n]))
+		dec.write(data)
 
-		err = resp.parseBytes(buff[:n])
-		if err != nil {
-			if err != errNeedMoreBytes {
-				s.log.Error("parse bytes", zap.Error(err))
-				resp = frame{}
+		for {
+			*resp, err = dec.next()
+			if err == errNeedMoreBytes {
+				break
 			}
-			continue
-		}
 
-		s.log.Debug("receive response", zap.Any("response", resp))
+			if resp.ID != req.ID {
+				s.log.Error(
+					"process response",
+					zap.Error(fmt.Errorf("unexpected response ID: 0x%X, expected: 0x%X", resp.ID, req.ID)),
+				)
+				continue
+			}
 
-		ch, ok := s.responses[resp.ID]
-		if !ok {
-			s.log.Error(
-				"process response",
-				zap.Error(fmt.Errorf("not found response chan for ID: 0x%X", resp.ID)),
-			)
-			continue
+			return nil
 		}
+	}
+}
 
-		ch <- resp
-		close(ch)
-		delete(s.responses, resp.ID)
-
-		resp = frame{}
+func (s *Pulsar) reportLineErrors(tx *bus.Tx, dec *decoder) {
+	if dec.droppedBytes == 0 && dec.crcErrors == 0 {
+		return
 	}
+
+	tx.ReportLineErrors(dec.droppedBytes, dec.crcErrors)
+
+	s.log.Warn(
+		"line errors",
+		zap.Uint64("droppedBytes", dec.droppedBytes),
+		zap.Uint64("crcErrors", dec.crcErrors),
+	)
 }
 
 func (s *Pulsar) mapErrorCode(code byte) error {
+	var err error
+
 	switch code {
 	case errCodeUnknownFunction:
-		return errors.New("the requested function code is unknown")
+		err = ErrUnknownFunction
 	case errCodeBitMask:
-		return errors.New("error in request bitmask")
+		err = ErrBitMask
 	case errCodeRequestLength:
-		return errors.New("invalid request length")
+		err = ErrRequestLength
 	case errCodeParam:
-		return errors.New("missing parameter")
+		err = ErrParam
 	case errCodeNeedAuth:
-		return errors.New("the entry is blocked, authorization is required")
+		err = ErrNeedAuth
 	case errCodeWriteParamRange:
-		return errors.New("the parameter written is outside the specified range")
+		err = ErrWriteParamRange
 	case errCodeUnknownArchiveType:
-		return errors.New("the requested archive type is missing")
+		err = ErrUnknownArchiveType
 	case errCodeMaxArchive:
-		return errors.New("exceeding the maximum number of archived values per package")
+		err = ErrMaxArchive
 	default:
-		return fmt.Errorf("unknown error, code: %d", code)
+		err = ErrUnknown
 	}
+
+	return &DeviceError{Code: code, Err: err}
 }
 
 func calculateCrc(data []byte) uint16 {
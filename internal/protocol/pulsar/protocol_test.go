@@ -0,0 +1,104 @@
+package pulsar
+
+import (
+	"context"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"net"
+	"testing"
+	"time"
+)
+
+// newTestPulsar returns a client of a device on a pipe, the device answers every
+// request with the payload returned by answer.
+func newTestPulsar(t *testing.T, answer func(req frame) []byte) *Pulsar {
+	t.Helper()
+
+	client, device := net.Pipe()
+	t.Cleanup(func() { _ = device.Close() })
+
+	go serveDevice(device, answer)
+
+	policy := bus.Policy{Timeout: time.Second}
+	b := bus.NewBus(client, 0, policy, zap.NewNop())
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewPulsar(b, policy, zap.NewNop())
+}
+
+func serveDevice(conn net.Conn, answer func(req frame) []byte) {
+	dec := newDecoder(testAddress)
+	buf := make([]byte, 256)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		dec.write(buf[:n])
+
+		for {
+			req, err := dec.next()
+			if err != nil {
+				break
+			}
+
+			resp := frame{Address: req.Address, FN: req.FN, Payload: answer(req), ID: req.ID}
+
+			_, err = conn.Write(resp.generateBytes())
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		version Version
+		err     bool
+	}{
+		{
+			name:    "version",
+			payload: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			version: Version{SWVersion: "4.3.8.7", HWVersion: "6.5.2-1"},
+		},
+		{
+			name:    "short reply",
+			payload: []byte{0x01, 0x02, 0x03, 0x04},
+			err:     true,
+		},
+		{
+			name: "empty reply",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPulsar(t, func(frame) []byte {
+				return tt.payload
+			})
+
+			version, err := p.GetVersion(context.Background(), testAddress)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got version %+v, want an error", version)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if version != tt.version {
+				t.Fatalf("got %+v, want %+v", version, tt.version)
+			}
+		})
+	}
+}
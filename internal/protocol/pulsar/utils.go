@@ -1,8 +1,10 @@
 package pulsar
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func ParseAddress(address string) ([4]byte, error) {
@@ -22,3 +24,36 @@ func ParseAddress(address string) ([4]byte, error) {
 		byte(add),
 	}, nil
 }
+
+// encodeTime returns the device date representation: YY MM DD hh mm ss, year counted from 2000.
+func encodeTime(t time.Time) []byte {
+	return []byte{
+		byte(t.Year() - 2000),
+		byte(t.Month()),
+		byte(t.Day()),
+		byte(t.Hour()),
+		byte(t.Minute()),
+		byte(t.Second()),
+	}
+}
+
+func decodeTime(data []byte) (time.Time, error) {
+	if len(data) < 6 {
+		return time.Time{}, fmt.Errorf("invalid date length: %d", len(data))
+	}
+
+	if data[1] < 1 || data[1] > 12 || data[2] < 1 || data[2] > 31 || data[3] > 23 || data[4] > 59 || data[5] > 59 {
+		return time.Time{}, fmt.Errorf("invalid date: %v", data[:6])
+	}
+
+	return time.Date(
+		2000+int(data[0]),
+		time.Month(data[1]),
+		int(data[2]),
+		int(data[3]),
+		int(data[4]),
+		int(data[5]),
+		0,
+		time.Local,
+	), nil
+}
@@ -0,0 +1,177 @@
+package wmbus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// HCI of the IM871A and compatible dongles.
+const (
+	hciStart byte = 0xA5
+	// hciRadioLink is the endpoint of the received telegrams.
+	hciRadioLink = 0x02
+	// hciDataIndication is the message of a received telegram.
+	hciDataIndication = 0x03
+
+	hciFlagTimestamp = 0x20
+	hciFlagRSSI      = 0x40
+	hciFlagCRC       = 0x80
+)
+
+const (
+	// maxLineLen limits the hex line of the longest telegram with a prefix like the one of rtl-wmbus.
+	maxLineLen  = 2*(256+2*17) + 128
+	minFrameLen = linkHeaderLen + 1
+)
+
+var errNeedMoreBytes = errors.New("need more bytes")
+
+// decoder extracts frames starting with the L field from the output of a dongle. Both the HCI messages
+// of the IM871A and text lines of hex telegrams, e.g. of rtl-wmbus, are understood.
+type decoder struct {
+	buffer []byte
+
+	droppedBytes uint64
+}
+
+func (d *decoder) write(data []byte) {
+	d.buffer = append(d.buffer, data...)
+}
+
+// next returns the next frame or errNeedMoreBytes if the buffer holds none.
+func (d *decoder) next() ([]byte, error) {
+	for {
+		if len(d.buffer) < 1 {
+			return nil, errNeedMoreBytes
+		}
+
+		if d.buffer[0] == hciStart {
+			frame, ok, err := d.nextHCI()
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				return frame, nil
+			}
+
+			continue
+		}
+
+		frame, ok, err := d.nextLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return frame, nil
+		}
+	}
+}
+
+// nextHCI takes the HCI message: start, control and endpoint, message ID, length, payload
+// and optionally a timestamp, RSSI and CRC. The payload is the telegram without the L field.
+func (d *decoder) nextHCI() ([]byte, bool, error) {
+	if len(d.buffer) < 4 {
+		return nil, false, errNeedMoreBytes
+	}
+
+	control, msgID, length := d.buffer[1], d.buffer[2], int(d.buffer[3])
+	if control&0x0F != hciRadioLink || msgID != hciDataIndication || length < minFrameLen-1 {
+		d.skip(1)
+
+		return nil, false, nil
+	}
+
+	size := 4 + length
+	if control&hciFlagTimestamp != 0 {
+		size += 4
+	}
+
+	if control&hciFlagRSSI != 0 {
+		size++
+	}
+
+	if control&hciFlagCRC != 0 {
+		size += 2
+	}
+
+	if len(d.buffer) < size {
+		return nil, false, errNeedMoreBytes
+	}
+
+	frame := make([]byte, 0, length+1)
+	frame = append(frame, byte(length))
+	frame = append(frame, d.buffer[4:4+length]...)
+
+	d.buffer = d.buffer[size:]
+
+	return frame, true, nil
+}
+
+// nextLine takes the text line, the telegram is its last field separated by semicolons.
+// Binary bytes end the text right away, they may start a HCI message.
+func (d *decoder) nextLine() ([]byte, bool, error) {
+	end := bytes.IndexFunc(d.buffer, func(r rune) bool {
+		return r == '\n' || r != '\t' && r != '\r' && (r < ' ' || r > '~')
+	})
+	if end < 0 {
+		if len(d.buffer) > maxLineLen {
+			d.skip(1)
+
+			return nil, false, nil
+		}
+
+		return nil, false, errNeedMoreBytes
+	}
+
+	if d.buffer[end] != '\n' {
+		if end == 0 {
+			end = 1
+		}
+
+		d.skip(end)
+
+		return nil, false, nil
+	}
+
+	frame, ok := parseHexLine(string(d.buffer[:end]))
+	if !ok {
+		if strings.TrimSpace(string(d.buffer[:end])) != "" {
+			d.droppedBytes += uint64(end)
+		}
+
+		d.buffer = d.buffer[end+1:]
+
+		return nil, false, nil
+	}
+
+	d.buffer = d.buffer[end+1:]
+
+	return frame, true, nil
+}
+
+func (d *decoder) skip(n int) {
+	d.buffer = d.buffer[n:]
+	d.droppedBytes += uint64(n)
+}
+
+func parseHexLine(line string) ([]byte, bool) {
+	fields := strings.Split(strings.TrimSpace(line), ";")
+
+	field := ""
+	for i := len(fields) - 1; i >= 0 && field == ""; i-- {
+		field = strings.TrimSpace(fields[i])
+	}
+
+	field = strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+
+	frame, err := hex.DecodeString(field)
+	if err != nil || len(frame) < minFrameLen {
+		return nil, false
+	}
+
+	return frame, true
+}
@@ -0,0 +1,146 @@
+package wmbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// hciMessage wraps the frame into the data indication of the IM871A with the flags.
+func hciMessage(frame []byte, flags byte) []byte {
+	message := []byte{hciStart, hciRadioLink | flags, hciDataIndication, frame[0]}
+	message = append(message, frame[1:]...)
+
+	if flags&hciFlagTimestamp != 0 {
+		message = append(message, 0x01, 0x02, 0x03, 0x04)
+	}
+
+	if flags&hciFlagRSSI != 0 {
+		message = append(message, 0xC8)
+	}
+
+	if flags&hciFlagCRC != 0 {
+		message = append(message, 0xAB, 0xCD)
+	}
+
+	return message
+}
+
+func TestDecoder(t *testing.T) {
+	// a telegram without transport header
+	telegram := []byte{0x0A, 0x44, 0x93, 0x15, 0x78, 0x56, 0x34, 0x12, 0x33, 0x03, 0x78}
+	line := "0A44931578563412330378"
+
+	tests := []struct {
+		name    string
+		chunks  []string
+		frames  int
+		dropped uint64
+	}{
+		{
+			name:   "HCI message",
+			chunks: []string{string(hciMessage(telegram, 0))},
+			frames: 1,
+		},
+		{
+			name:   "HCI message with timestamp, RSSI and CRC",
+			chunks: []string{string(hciMessage(telegram, hciFlagTimestamp|hciFlagRSSI|hciFlagCRC))},
+			frames: 1,
+		},
+		{
+			name:   "split HCI messages",
+			chunks: []string{string(hciMessage(telegram, hciFlagRSSI)[:3]), string(hciMessage(telegram, hciFlagRSSI)[3:]) + string(hciMessage(telegram, 0))},
+			frames: 2,
+		},
+		{
+			name:    "HCI message of another endpoint",
+			chunks:  []string{string([]byte{hciStart, 0x01, 0x02, 0x00}) + string(hciMessage(telegram, 0))},
+			frames:  1,
+			dropped: 4,
+		},
+		{
+			name:   "hex line",
+			chunks: []string{line + "\n"},
+			frames: 1,
+		},
+		{
+			name:   "line of rtl-wmbus",
+			chunks: []string{"T1;1;1;2026-10-18 12:00:00.000;117;102;12345678;0x" + line + "\r\n"},
+			frames: 1,
+		},
+		{
+			name:   "line with a trailing separator",
+			chunks: []string{"C1;1;1;" + line + ";\n"},
+			frames: 1,
+		},
+		{
+			name:   "split lines",
+			chunks: []string{line[:5], line[5:] + "\n" + line, "\n"},
+			frames: 2,
+		},
+		{
+			name:   "empty lines",
+			chunks: []string{"\n\r\n" + line + "\n"},
+			frames: 1,
+		},
+		{
+			name:    "invalid hex",
+			chunks:  []string{"0A44ZZ\n" + line + "\n"},
+			frames:  1,
+			dropped: 6,
+		},
+		{
+			name:    "short telegram",
+			chunks:  []string{"0A4493\n"},
+			dropped: 6,
+		},
+		{
+			name:    "binary junk before a line",
+			chunks:  []string{"\x00\x01" + line + "\n"},
+			frames:  1,
+			dropped: 2,
+		},
+		{
+			name:   "HCI message after a line",
+			chunks: []string{line + "\n" + string(hciMessage(telegram, 0))},
+			frames: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &decoder{}
+
+			var frames [][]byte
+			for _, chunk := range tt.chunks {
+				d.write([]byte(chunk))
+
+				for {
+					f, err := d.next()
+					if errors.Is(err, errNeedMoreBytes) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("next: %v", err)
+					}
+
+					frames = append(frames, f)
+				}
+			}
+
+			if len(frames) != tt.frames {
+				t.Fatalf("got %d frames, want %d", len(frames), tt.frames)
+			}
+
+			for i, f := range frames {
+				if !bytes.Equal(f, telegram) {
+					t.Errorf("frame %d: got % X, want % X", i, f, telegram)
+				}
+			}
+
+			if d.droppedBytes != tt.dropped {
+				t.Errorf("dropped bytes: got %d, want %d", d.droppedBytes, tt.dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package wmbus
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// decrypt returns the payload in plain text. Mode 5 encrypts the configured number of blocks
+// with AES-128-CBC, the rest of the payload is sent unencrypted.
+func (t Telegram) decrypt(key []byte) ([]byte, error) {
+	switch t.Mode() {
+	case ModeNone:
+		return t.Payload, nil
+	case ModeAESCBC:
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedEncryption, t.Mode())
+	}
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: the telegram is encrypted, the key is not set", ErrDecryption)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err.Error())
+	}
+
+	n := int(t.ConfigWord>>4&0x0F) * aes.BlockSize
+	if n == 0 || n > len(t.Payload) {
+		n = len(t.Payload) / aes.BlockSize * aes.BlockSize
+	}
+
+	if n == 0 {
+		return nil, fmt.Errorf("%w: no encrypted blocks", ErrDecryption)
+	}
+
+	// the initialization vector is the manufacturer, the address and 8 access numbers
+	iv := make([]byte, 0, aes.BlockSize)
+	iv = append(iv, t.iv[:]...)
+	for len(iv) < aes.BlockSize {
+		iv = append(iv, t.AccessNumber)
+	}
+
+	plain := make([]byte, len(t.Payload))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain[:n], t.Payload[:n])
+	copy(plain[n:], t.Payload[n:])
+
+	// the encrypted data starts with two idle fillers to verify the key
+	if plain[0] != 0x2F || plain[1] != 0x2F {
+		return nil, fmt.Errorf("%w: wrong key", ErrDecryption)
+	}
+
+	return plain, nil
+}
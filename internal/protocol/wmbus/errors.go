@@ -0,0 +1,12 @@
+package wmbus
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidTelegram       = errors.New("invalid telegram")
+	ErrUnsupportedCI         = errors.New("unsupported control information field")
+	ErrUnsupportedEncryption = errors.New("unsupported encryption mode")
+	ErrDecryption            = errors.New("decryption failed")
+)
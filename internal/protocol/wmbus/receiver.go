@@ -0,0 +1,133 @@
+package wmbus
+
+import (
+	"context"
+	"errors"
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// restartDelay is the first delay of restarting the receive loop, it doubles up to restartMaxDelay.
+	restartDelay    = time.Second
+	restartMaxDelay = time.Minute
+)
+
+// Receiver listens to the port of the dongle and keeps the last telegram of every meter. Meters
+// send telegrams on their own, so the receiver holds the bus for its whole life.
+type Receiver struct {
+	bus *bus.Bus
+
+	mu        sync.Mutex
+	telegrams map[string]Telegram
+
+	log *zap.Logger
+}
+
+func NewReceiver(bus *bus.Bus, log *zap.Logger) *Receiver {
+	r := &Receiver{
+		bus:       bus,
+		telegrams: make(map[string]Telegram),
+		log:       log,
+	}
+	go r.run()
+
+	return r
+}
+
+// Last returns the last telegram of the meter with the identification number.
+func (r *Receiver) Last(id string) (Telegram, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.telegrams[id]
+
+	return t, ok
+}
+
+// run restarts the receive loop after errors, the delay is reset once the loop has run for a while.
+func (r *Receiver) run() {
+	delay := restartDelay
+
+	for {
+		started := time.Now()
+
+		err := r.receive()
+		if time.Since(started) > restartMaxDelay {
+			delay = restartDelay
+		}
+
+		r.log.Error("receiver is stopped", zap.Error(err), zap.Duration("restartDelay", delay))
+
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > restartMaxDelay {
+			delay = restartMaxDelay
+		}
+	}
+}
+
+func (r *Receiver) receive() error {
+	return r.bus.Transaction(context.Background(), func(tx *bus.Tx) error {
+		dec := &decoder{}
+
+		for {
+			data, err := tx.Read(context.Background())
+			if err != nil {
+				return err
+			}
+
+			dec.write(data)
+
+			for {
+				frame, err := dec.next()
+				if errors.Is(err, errNeedMoreBytes) {
+					break
+				}
+
+				t, err := ParseTelegram(frame)
+				if err != nil {
+					dec.droppedBytes += uint64(len(frame))
+					r.log.Debug("invalid telegram", zap.Binary("frame", frame), zap.Error(err))
+					continue
+				}
+
+				t.Received = time.Now()
+				r.store(t)
+			}
+
+			r.reportLineErrors(tx, dec)
+		}
+	})
+}
+
+func (r *Receiver) store(t Telegram) {
+	r.log.Debug(
+		"receive telegram",
+		zap.String("address", t.Address.String()),
+		zap.Uint8("accessNumber", t.AccessNumber),
+	)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.telegrams[t.Address.ID] = t
+}
+
+func (r *Receiver) reportLineErrors(tx *bus.Tx, dec *decoder) {
+	if dec.droppedBytes == 0 {
+		return
+	}
+
+	tx.ReportLineErrors(dec.droppedBytes, 0)
+
+	r.log.Warn(
+		"line errors",
+		zap.Uint64("droppedBytes", dec.droppedBytes),
+	)
+
+	dec.droppedBytes = 0
+}
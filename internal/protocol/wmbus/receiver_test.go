@@ -0,0 +1,42 @@
+package wmbus
+
+import (
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReceiverStoresTelegrams(t *testing.T) {
+	port, dongle := net.Pipe()
+	defer dongle.Close()
+
+	r := NewReceiver(bus.NewBus(port, 0, bus.DefaultPolicy(), zap.NewNop()), zap.NewNop())
+	defer port.Close()
+
+	sent := time.Now()
+
+	_, err := dongle.Write([]byte("0A44931578563412330378\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		telegram, ok := r.Last("12345678")
+		if ok {
+			if telegram.Received.Before(sent) {
+				t.Fatalf("received at %s, before it was sent at %s", telegram.Received, sent)
+			}
+
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the telegram is not stored")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,203 @@
+package wmbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"time"
+)
+
+// CI field values.
+const (
+	ciLongHeader  byte = 0x72
+	ciNoHeader         = 0x78
+	ciShortHeader      = 0x7A
+	// ciShortELL is the short extended link layer of C1 telegrams: communication control and access number.
+	ciShortELL = 0x8C
+)
+
+const (
+	// linkHeaderLen is the length of the L, C, M and A fields.
+	linkHeaderLen = 10
+	// blockLen is the length of the data blocks following the first one in the frame format A.
+	blockLen = 16
+	crcLen   = 2
+	// crcPoly is the CRC-16 polynomial of EN 13757-4.
+	crcPoly uint16 = 0x3D65
+)
+
+// Encryption modes of the configuration word.
+const (
+	ModeNone   byte = 0
+	ModeAESCBC      = 5
+)
+
+// Telegram is a wireless M-Bus telegram with the payload still encrypted.
+type Telegram struct {
+	// Address identifies the meter: the link layer address or the one of the long transport header.
+	Address      mbus_m.SecondaryAddress
+	Control      byte
+	CI           byte
+	AccessNumber byte
+	Status       byte
+	ConfigWord   uint16
+	Payload      []byte
+	// Received is the time the receiver got the telegram.
+	Received time.Time
+
+	// iv is the manufacturer and the address as sent, they start the initialization vector.
+	iv [8]byte
+}
+
+// ParseTelegram parses the frame starting with the L field. Dongles usually check and remove
+// the block CRCs, if the frame still holds them they are checked and removed here.
+func ParseTelegram(frame []byte) (Telegram, error) {
+	frame, err := removeBlockCRCs(frame)
+	if err != nil {
+		return Telegram{}, err
+	}
+
+	t := Telegram{
+		Control: frame[1],
+	}
+
+	// M(2) A(6) are sent as manufacturer, identification number, version and device type
+	copy(t.iv[:], frame[2:linkHeaderLen])
+	t.Address = decodeLinkAddress(frame[2:linkHeaderLen])
+
+	data := frame[linkHeaderLen:]
+	if len(data) < 1 {
+		return Telegram{}, fmt.Errorf("%w: no CI field", ErrInvalidTelegram)
+	}
+
+	if data[0] == ciShortELL {
+		if len(data) < 3 {
+			return Telegram{}, fmt.Errorf("%w: extended link layer is truncated", ErrInvalidTelegram)
+		}
+
+		data = data[3:]
+		if len(data) < 1 {
+			return Telegram{}, fmt.Errorf("%w: no CI field", ErrInvalidTelegram)
+		}
+	}
+
+	t.CI, data = data[0], data[1:]
+
+	switch t.CI {
+	case ciNoHeader:
+	case ciShortHeader:
+		if len(data) < 4 {
+			return Telegram{}, fmt.Errorf("%w: transport header is truncated", ErrInvalidTelegram)
+		}
+
+		t.AccessNumber, t.Status = data[0], data[1]
+		t.ConfigWord = binary.LittleEndian.Uint16(data[2:4])
+		data = data[4:]
+	case ciLongHeader:
+		if len(data) < 12 {
+			return Telegram{}, fmt.Errorf("%w: transport header is truncated", ErrInvalidTelegram)
+		}
+
+		// the meter behind a repeater or a gateway, the header order is A(4) M(2) version and device type
+		t.Address = mbus_m.DecodeSecondaryAddress(data[:8])
+		t.iv = [8]byte{data[4], data[5], data[0], data[1], data[2], data[3], data[6], data[7]}
+		t.AccessNumber, t.Status = data[8], data[9]
+		t.ConfigWord = binary.LittleEndian.Uint16(data[10:12])
+		data = data[12:]
+	default:
+		return Telegram{}, fmt.Errorf("%w: 0x%02X", ErrUnsupportedCI, t.CI)
+	}
+
+	t.Payload = append([]byte(nil), data...)
+
+	return t, nil
+}
+
+// Mode returns the encryption mode of the configuration word.
+func (t Telegram) Mode() byte {
+	return byte(t.ConfigWord>>8) & 0x1F
+}
+
+// Decode decrypts the payload with the key and parses the data records,
+// the key is not used by unencrypted telegrams.
+func (t Telegram) Decode(key []byte) (mbus_m.Data, error) {
+	payload, err := t.decrypt(key)
+	if err != nil {
+		return mbus_m.Data{}, err
+	}
+
+	records, more, err := mbus_m.ParseRecords(payload)
+	if err != nil {
+		return mbus_m.Data{}, err
+	}
+
+	return mbus_m.Data{
+		Address:      t.Address,
+		AccessNumber: t.AccessNumber,
+		Status:       t.Status,
+		Records:      records,
+		MoreRecords:  more,
+	}, nil
+}
+
+func decodeLinkAddress(data []byte) mbus_m.SecondaryAddress {
+	return mbus_m.DecodeSecondaryAddress([]byte{
+		data[2], data[3], data[4], data[5], data[0], data[1], data[6], data[7],
+	})
+}
+
+// removeBlockCRCs removes the CRCs of the frame format A: the first block holds the link header,
+// the next ones 16 bytes each. The L field does not count the CRCs.
+func removeBlockCRCs(frame []byte) ([]byte, error) {
+	if len(frame) < linkHeaderLen {
+		return nil, fmt.Errorf("%w: link header is truncated", ErrInvalidTelegram)
+	}
+
+	length := int(frame[0]) + 1
+	if len(frame) == length {
+		return frame, nil
+	}
+
+	if length < linkHeaderLen {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidTelegram, frame[0])
+	}
+
+	blocks := 1 + (length-linkHeaderLen+blockLen-1)/blockLen
+	if len(frame) < length+blocks*crcLen {
+		return nil, fmt.Errorf("%w: length %d, received %d bytes", ErrInvalidTelegram, frame[0], len(frame))
+	}
+
+	result := make([]byte, 0, length)
+	for size := linkHeaderLen; len(result) < length; size = blockLen {
+		if rest := length - len(result); size > rest {
+			size = rest
+		}
+
+		block := frame[:size]
+
+		if calculateCRC(block) != binary.BigEndian.Uint16(frame[size:size+crcLen]) {
+			return nil, fmt.Errorf("%w: block CRC", ErrInvalidTelegram)
+		}
+
+		result = append(result, block...)
+		frame = frame[size+crcLen:]
+	}
+
+	return result, nil
+}
+
+func calculateCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ crcPoly
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return ^crc
+}
@@ -0,0 +1,292 @@
+package wmbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	mbus_m "github.com/lan143/metrology-master/internal/protocol/mbus"
+	"testing"
+)
+
+// omsTelegram is the mode 5 example of OMS Vol.2 Annex N: water meter 12345678 of ELS
+// with the key 0102030405060708090A0B0C0D0E0F11.
+const omsTelegram = "2E4493157856341233037A2A0020255923C95AAA26D1B2E7493B013EC4A6F6D3529B520EDFF0EA6DEFC99D6D69EBF3"
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// withBlockCRCs inserts the CRCs of the frame format A.
+func withBlockCRCs(frame []byte) []byte {
+	var result []byte
+	for size := linkHeaderLen; len(frame) > 0; size = blockLen {
+		if size > len(frame) {
+			size = len(frame)
+		}
+
+		result = append(result, frame[:size]...)
+		result = binary.BigEndian.AppendUint16(result, calculateCRC(frame[:size]))
+		frame = frame[size:]
+	}
+
+	return result
+}
+
+func TestCalculateCRC(t *testing.T) {
+	// the check value of CRC-16/EN-13757
+	if crc := calculateCRC([]byte("123456789")); crc != 0xC2B7 {
+		t.Fatalf("got 0x%04X, want 0xC2B7", crc)
+	}
+}
+
+func TestRemoveBlockCRCs(t *testing.T) {
+	frame := decodeHex(t, omsTelegram)
+
+	badCRC := withBlockCRCs(frame)
+	badCRC[len(badCRC)-1] ^= 0xFF
+
+	tests := []struct {
+		name  string
+		frame []byte
+		want  []byte
+		err   error
+	}{
+		{"without CRCs", frame, frame, nil},
+		{"with CRCs", withBlockCRCs(frame), frame, nil},
+		{"header block only", withBlockCRCs(frame[:linkHeaderLen]), nil, ErrInvalidTelegram},
+		{"bad CRC", badCRC, nil, ErrInvalidTelegram},
+		{"truncated", withBlockCRCs(frame)[:len(frame)+2], nil, ErrInvalidTelegram},
+		{"truncated link header", frame[:linkHeaderLen-1], nil, ErrInvalidTelegram},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := removeBlockCRCs(tt.frame)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTelegram(t *testing.T) {
+	oms := decodeHex(t, omsTelegram)
+
+	tests := []struct {
+		name     string
+		frame    []byte
+		address  string
+		ci       byte
+		access   byte
+		config   uint16
+		payload  int
+		err      error
+		errorLen bool
+	}{
+		{
+			name:    "short header",
+			frame:   oms,
+			address: "1234567815933303",
+			ci:      ciShortHeader,
+			access:  0x2A,
+			config:  0x2520,
+			payload: 32,
+		},
+		{
+			name:    "short header with block CRCs",
+			frame:   withBlockCRCs(oms),
+			address: "1234567815933303",
+			ci:      ciShortHeader,
+			access:  0x2A,
+			config:  0x2520,
+			payload: 32,
+		},
+		{
+			name: "long header of a repeated meter",
+			frame: frame(
+				decodeHex(t, "44A511785634120107"),
+				decodeHex(t, "72"+"21436587"+"9315"+"33"+"07"+"10"+"00"+"0000"),
+				[]byte{0x04, 0x13, 0x01, 0x00, 0x00, 0x00},
+			),
+			address: "8765432115933307",
+			ci:      ciLongHeader,
+			access:  0x10,
+			payload: 6,
+		},
+		{
+			name: "no header",
+			frame: frame(
+				decodeHex(t, "44931578563412330778"),
+				[]byte{0x04, 0x13, 0x01, 0x00, 0x00, 0x00},
+			),
+			address: "1234567815933307",
+			ci:      ciNoHeader,
+			payload: 6,
+		},
+		{
+			name: "short extended link layer",
+			frame: frame(
+				decodeHex(t, "449315785634123307"+"8C2000"),
+				decodeHex(t, "7A0500"+"0000"),
+			),
+			address: "1234567815933307",
+			ci:      ciShortHeader,
+			access:  0x05,
+		},
+		{
+			name:  "unsupported CI",
+			frame: frame(decodeHex(t, "4493157856341233077F")),
+			err:   ErrUnsupportedCI,
+		},
+		{
+			name:  "truncated short header",
+			frame: frame(decodeHex(t, "449315785634123307"+"7A0500")),
+			err:   ErrInvalidTelegram,
+		},
+		{
+			name:  "truncated long header",
+			frame: frame(decodeHex(t, "449315785634123307"+"7221436587")),
+			err:   ErrInvalidTelegram,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telegram, err := ParseTelegram(tt.frame)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if address := telegram.Address.String(); address != tt.address {
+				t.Errorf("address: got %s, want %s", address, tt.address)
+			}
+
+			if telegram.CI != tt.ci || telegram.AccessNumber != tt.access || telegram.ConfigWord != tt.config {
+				t.Errorf(
+					"got CI 0x%02X, access number 0x%02X and config word 0x%04X",
+					telegram.CI, telegram.AccessNumber, telegram.ConfigWord,
+				)
+			}
+
+			if len(telegram.Payload) != tt.payload {
+				t.Errorf("got a payload of %d bytes, want %d", len(telegram.Payload), tt.payload)
+			}
+		})
+	}
+}
+
+// frame prefixes the L field to the chunks of the frame without it.
+func frame(chunks ...[]byte) []byte {
+	data := bytes.Join(chunks, nil)
+
+	return append([]byte{byte(len(data))}, data...)
+}
+
+func TestDecodeMode5(t *testing.T) {
+	key := decodeHex(t, "0102030405060708090A0B0C0D0E0F11")
+	oms := decodeHex(t, omsTelegram)
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{
+			name:  "IV of the link header",
+			frame: oms,
+		},
+		{
+			// the meter behind a repeater: the IV is built from the address of the
+			// long header, so the ciphertext is the one of the OMS example
+			name: "IV of the long header",
+			frame: frame(
+				decodeHex(t, "44A511214365870107"),
+				decodeHex(t, "72"+"78563412"+"9315"+"33"+"03"+"2A"+"00"+"2025"),
+				oms[15:],
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telegram, err := ParseTelegram(tt.frame)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if telegram.Mode() != ModeAESCBC {
+				t.Fatalf("got mode %d, want %d", telegram.Mode(), ModeAESCBC)
+			}
+
+			data, err := telegram.Decode(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(data.Records) != 3 {
+				t.Fatalf("got %d records, want 3", len(data.Records))
+			}
+
+			volume := data.Records[0]
+			if volume.Quantity != mbus_m.QuantityVolume || volume.Value != 28504.27 {
+				t.Errorf("got %s of %v, want volume of 28504.27", volume.Quantity, volume.Value)
+			}
+
+			if at := data.Records[1].Time.Format("2006-01-02 15:04"); at != "2008-05-31 23:50" {
+				t.Errorf("got time point %s, want 2008-05-31 23:50", at)
+			}
+		})
+	}
+}
+
+func TestDecodeMode5Errors(t *testing.T) {
+	telegram, err := ParseTelegram(decodeHex(t, omsTelegram))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{"wrong key", decodeHex(t, "000102030405060708090A0B0C0D0E0F")},
+		{"no key", nil},
+		{"short key", decodeHex(t, "0102")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := telegram.Decode(tt.key)
+			if !errors.Is(err, ErrDecryption) {
+				t.Fatalf("got %v, want %v", err, ErrDecryption)
+			}
+		})
+	}
+}
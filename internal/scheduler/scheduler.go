@@ -7,9 +7,14 @@ import (
 	"time"
 )
 
+const (
+	DefaultPeriod = 1 * time.Minute
+)
+
 type Scheduler struct {
-	jobs   []job.Job
-	jobCNs []chan struct{}
+	jobs    []job.Job
+	periods []time.Duration
+	jobCNs  []chan struct{}
 
 	log *zap.Logger
 }
@@ -21,14 +26,19 @@ func NewScheduler(log *zap.Logger) *Scheduler {
 }
 
 func (s *Scheduler) AddJob(job job.Job) {
+	s.AddPeriodicJob(job, DefaultPeriod)
+}
+
+func (s *Scheduler) AddPeriodicJob(job job.Job, period time.Duration) {
 	shutdownCh := make(chan struct{})
 	s.jobCNs = append(s.jobCNs, shutdownCh)
 	s.jobs = append(s.jobs, job)
+	s.periods = append(s.periods, period)
 }
 
 func (s *Scheduler) Run() error {
 	for i := range s.jobs {
-		go s.executeJob(s.jobs[i], s.jobCNs[i])
+		go s.executeJob(s.jobs[i], s.periods[i], s.jobCNs[i])
 	}
 
 	return nil
@@ -42,8 +52,10 @@ func (s *Scheduler) Shutdown() error {
 	return nil
 }
 
-func (s *Scheduler) executeJob(job job.Job, shutdownCh chan struct{}) {
-	ticker := time.NewTicker(1 * time.Minute)
+func (s *Scheduler) executeJob(job job.Job, period time.Duration, shutdownCh chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
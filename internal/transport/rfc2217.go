@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"errors"
+	"github.com/lan143/metrology-master/internal/transport/rfc2217"
+	"github.com/lan143/metrology-master/pkg/serial"
+	"go.uber.org/zap"
+	"net"
+)
+
+const (
+	RFC2217Scheme = "rfc2217://"
+)
+
+// NewRFC2217 returns a port of an RFC 2217 server. The port settings are negotiated
+// on every (re)connection, so they survive a restart of the converter.
+func NewRFC2217(address string, config serial.Config, log *zap.Logger) (*TCP, error) {
+	settings, err := buildSettings(config)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTCP(address, func(address string) (net.Conn, error) {
+		return rfc2217.Dial(address, settings, dialTimeout)
+	}, log)
+	t.baudRate = config.BaudRate
+
+	return t, nil
+}
+
+func buildSettings(config serial.Config) (rfc2217.Settings, error) {
+	settings := rfc2217.Settings{
+		BaudRate: config.BaudRate,
+		DataBits: config.DataBits,
+	}
+
+	switch config.StopBits {
+	case 1:
+		settings.StopBits = rfc2217.StopBitsOne
+	case 2:
+		settings.StopBits = rfc2217.StopBitsOnePointFive
+	case 3:
+		settings.StopBits = rfc2217.StopBitsTwo
+	default:
+		return rfc2217.Settings{}, errors.New("unsupported stop bits setting")
+	}
+
+	switch config.Parity {
+	case 0:
+		settings.Parity = rfc2217.ParityNone
+	case 1:
+		settings.Parity = rfc2217.ParityOdd
+	case 2:
+		settings.Parity = rfc2217.ParityEven
+	case 3:
+		settings.Parity = rfc2217.ParityMark
+	case 4:
+		settings.Parity = rfc2217.ParitySpace
+	default:
+		return rfc2217.Settings{}, errors.New("unsupported parity setting")
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,179 @@
+package rfc2217
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRefused = errors.New("the server refused the com port option")
+)
+
+// Conn is a client connection to an RFC 2217 server. Telnet commands are stripped
+// from the received data and IAC bytes are escaped in the sent data.
+type Conn struct {
+	net.Conn
+
+	parser  parser
+	pending []byte
+
+	wmu     sync.Mutex
+	acks    chan byte
+	refused bool
+}
+
+func Dial(address string, settings Settings, timeout time.Duration) (*Conn, error) {
+	raw, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		Conn: raw,
+		acks: make(chan byte, 16),
+	}
+	c.parser.onCommand = c.handleCommand
+	c.parser.onSub = c.handleSub
+
+	err = c.negotiate(settings, timeout)
+	if err != nil {
+		_ = raw.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		buff := make([]byte, len(p))
+
+		n, err := c.Conn.Read(buff)
+		if n > 0 {
+			c.pending = c.parser.feed(buff[:n])
+		}
+
+		if err != nil && len(c.pending) == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+
+	return n, nil
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	err := c.writeRaw(escape(p))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// SetBaudRate changes the baud rate of the remote port without reconnection.
+func (c *Conn) SetBaudRate(baudRate int) error {
+	baud := uint32(baudRate)
+
+	return c.writeRaw(
+		subnegotiation(optComPort, setBaudRate, byte(baud>>24), byte(baud>>16), byte(baud>>8), byte(baud)),
+	)
+}
+
+func (c *Conn) negotiate(settings Settings, timeout time.Duration) error {
+	err := c.writeRaw(append(append(command(will, optComPort), command(will, optBinary)...), command(do, optBinary)...))
+	if err != nil {
+		return err
+	}
+
+	commands := settings.commands()
+	for _, cmd := range commands {
+		err = c.writeRaw(cmd)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Conn.SetReadDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = c.Conn.SetReadDeadline(time.Time{})
+	}()
+
+	acked := make(map[byte]bool)
+	buff := make([]byte, 256)
+
+	for len(acked) < len(commands) {
+		select {
+		case code := <-c.acks:
+			if code >= setBaudRate && code <= setControl {
+				acked[code] = true
+			}
+			continue
+		default:
+		}
+
+		if c.refused {
+			return ErrRefused
+		}
+
+		n, err := c.Conn.Read(buff)
+		if n > 0 {
+			c.pending = append(c.pending, c.parser.feed(buff[:n])...)
+		}
+
+		if err != nil {
+			return fmt.Errorf("negotiate com port settings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Conn) handleCommand(cmd, option byte) {
+	switch cmd {
+	case do:
+		switch option {
+		case optComPort, optBinary:
+		default:
+			_ = c.writeRaw(command(wont, option))
+		}
+	case dont:
+		if option == optComPort {
+			c.refused = true
+		}
+	case will:
+		switch option {
+		case optBinary, optSGA:
+		default:
+			_ = c.writeRaw(command(dont, option))
+		}
+	}
+}
+
+func (c *Conn) handleSub(data []byte) {
+	if len(data) < 2 || data[0] != optComPort || data[1] < serverOffset {
+		return
+	}
+
+	select {
+	case c.acks <- data[1] - serverOffset:
+	default:
+	}
+}
+
+func (c *Conn) writeRaw(data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err := c.Conn.Write(data)
+
+	return err
+}
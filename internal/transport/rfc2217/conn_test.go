@@ -0,0 +1,89 @@
+package rfc2217
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDialNegotiatesSettings(t *testing.T) {
+	server, err := NewServer(func(rw io.ReadWriter) {
+		_, _ = io.Copy(rw, rw)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	settings := Settings{
+		BaudRate: 2400,
+		DataBits: 7,
+		Parity:   ParityEven,
+		StopBits: StopBitsTwo,
+	}
+
+	conn, err := Dial(server.Addr(), settings, time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if got := server.Settings(); got != settings {
+		t.Fatalf("server settings: got %+v, want %+v", got, settings)
+	}
+
+	err = conn.SetBaudRate(9600)
+	if err != nil {
+		t.Fatalf("set baud rate: %v", err)
+	}
+
+	// the data written after the baud rate is echoed once the server has handled it
+	data := []byte{0x01, iac, 0x02, iac, iac, 0x03}
+
+	_, err = conn.Write(data)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	echo := make([]byte, len(data))
+	_, err = io.ReadFull(conn, echo)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	if !bytes.Equal(echo, data) {
+		t.Fatalf("echo: got % X, want % X", echo, data)
+	}
+
+	if got := server.Settings().BaudRate; got != 9600 {
+		t.Fatalf("baud rate: got %d, want 9600", got)
+	}
+}
+
+func TestConnWriteEscapesIAC(t *testing.T) {
+	client, remote := net.Pipe()
+	defer client.Close()
+	defer remote.Close()
+
+	conn := &Conn{Conn: client}
+
+	go func() {
+		_, _ = conn.Write([]byte{0x10, iac, 0x20})
+	}()
+
+	want := []byte{0x10, iac, iac, 0x20}
+
+	got := make([]byte, len(want))
+	_, err := io.ReadFull(remote, got)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("sent: got % X, want % X", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package rfc2217
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// Server is an in-process stand-in for an RFC 2217 converter. It acknowledges
+// port settings, remembers the last negotiated ones and passes the data stream
+// to the handler, which plays the role of the devices on the remote line.
+type Server struct {
+	listener net.Listener
+	handler  func(rw io.ReadWriter)
+
+	mu       sync.Mutex
+	settings Settings
+
+	wg sync.WaitGroup
+}
+
+type serverConn struct {
+	conn    net.Conn
+	server  *Server
+	parser  parser
+	pending []byte
+
+	wmu sync.Mutex
+}
+
+func NewServer(handler func(rw io.ReadWriter)) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		handler:  handler,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Settings() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.settings
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &serverConn{
+			conn:   conn,
+			server: s,
+		}
+		c.parser.onCommand = c.handleCommand
+		c.parser.onSub = c.handleSub
+
+		go func() {
+			defer conn.Close()
+			s.handler(c)
+		}()
+	}
+}
+
+func (c *serverConn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		buff := make([]byte, len(p))
+
+		n, err := c.conn.Read(buff)
+		if n > 0 {
+			c.pending = c.parser.feed(buff[:n])
+		}
+
+		if err != nil && len(c.pending) == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+
+	return n, nil
+}
+
+func (c *serverConn) Write(p []byte) (int, error) {
+	err := c.writeRaw(escape(p))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (c *serverConn) handleCommand(cmd, option byte) {
+	switch cmd {
+	case will:
+		switch option {
+		case optComPort, optBinary:
+			_ = c.writeRaw(command(do, option))
+		default:
+			_ = c.writeRaw(command(dont, option))
+		}
+	case do:
+		switch option {
+		case optBinary:
+			_ = c.writeRaw(command(will, option))
+		default:
+			_ = c.writeRaw(command(wont, option))
+		}
+	}
+}
+
+func (c *serverConn) handleSub(data []byte) {
+	if len(data) < 3 || data[0] != optComPort {
+		return
+	}
+
+	c.server.mu.Lock()
+	switch data[1] {
+	case setBaudRate:
+		if len(data) >= 6 {
+			c.server.settings.BaudRate = int(data[2])<<24 | int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		}
+	case setDataSize:
+		c.server.settings.DataBits = int(data[2])
+	case setParity:
+		c.server.settings.Parity = int(data[2])
+	case setStopSize:
+		c.server.settings.StopBits = int(data[2])
+	}
+	c.server.mu.Unlock()
+
+	answer := append([]byte{optComPort, data[1] + serverOffset}, data[2:]...)
+	_ = c.writeRaw(subnegotiation(answer...))
+}
+
+func (c *serverConn) writeRaw(data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err := c.conn.Write(data)
+
+	return err
+}
@@ -0,0 +1,50 @@
+package rfc2217
+
+const (
+	setBaudRate byte = 1
+	setDataSize byte = 2
+	setParity   byte = 3
+	setStopSize byte = 4
+	setControl  byte = 5
+
+	// serverOffset is added to a command code in the server answers.
+	serverOffset byte = 100
+)
+
+const (
+	ParityNone  = 1
+	ParityOdd   = 2
+	ParityEven  = 3
+	ParityMark  = 4
+	ParitySpace = 5
+)
+
+const (
+	StopBitsOne          = 1
+	StopBitsTwo          = 2
+	StopBitsOnePointFive = 3
+)
+
+const (
+	controlNoFlowControl byte = 1
+)
+
+// Settings holds the port settings in RFC 2217 encoding.
+type Settings struct {
+	BaudRate int
+	DataBits int
+	Parity   int
+	StopBits int
+}
+
+func (s Settings) commands() [][]byte {
+	baud := uint32(s.BaudRate)
+
+	return [][]byte{
+		subnegotiation(optComPort, setBaudRate, byte(baud>>24), byte(baud>>16), byte(baud>>8), byte(baud)),
+		subnegotiation(optComPort, setDataSize, byte(s.DataBits)),
+		subnegotiation(optComPort, setParity, byte(s.Parity)),
+		subnegotiation(optComPort, setStopSize, byte(s.StopBits)),
+		subnegotiation(optComPort, setControl, controlNoFlowControl),
+	}
+}
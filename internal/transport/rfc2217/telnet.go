@@ -0,0 +1,117 @@
+package rfc2217
+
+const (
+	iac  byte = 255
+	dont byte = 254
+	do   byte = 253
+	wont byte = 252
+	will byte = 251
+	sb   byte = 250
+	se   byte = 240
+)
+
+const (
+	optBinary  byte = 0
+	optSGA     byte = 3
+	optComPort byte = 44
+)
+
+const (
+	stateData = iota
+	stateIAC
+	stateCommand
+	stateSub
+	stateSubIAC
+)
+
+// parser strips telnet commands from the stream and reports them through callbacks.
+// It keeps the state between chunks, so a command may be split across reads.
+type parser struct {
+	state   int
+	command byte
+	sub     []byte
+
+	onCommand func(command, option byte)
+	onSub     func(data []byte)
+}
+
+func (p *parser) feed(in []byte) []byte {
+	out := make([]byte, 0, len(in))
+
+	for _, b := range in {
+		switch p.state {
+		case stateData:
+			if b == iac {
+				p.state = stateIAC
+				continue
+			}
+			out = append(out, b)
+		case stateIAC:
+			switch b {
+			case iac:
+				out = append(out, iac)
+				p.state = stateData
+			case will, wont, do, dont:
+				p.command = b
+				p.state = stateCommand
+			case sb:
+				p.sub = p.sub[:0]
+				p.state = stateSub
+			default:
+				p.state = stateData
+			}
+		case stateCommand:
+			if p.onCommand != nil {
+				p.onCommand(p.command, b)
+			}
+			p.state = stateData
+		case stateSub:
+			if b == iac {
+				p.state = stateSubIAC
+				continue
+			}
+			p.sub = append(p.sub, b)
+		case stateSubIAC:
+			switch b {
+			case iac:
+				p.sub = append(p.sub, iac)
+				p.state = stateSub
+			case se:
+				if p.onSub != nil {
+					data := make([]byte, len(p.sub))
+					copy(data, p.sub)
+					p.onSub(data)
+				}
+				p.state = stateData
+			default:
+				p.state = stateData
+			}
+		}
+	}
+
+	return out
+}
+
+func escape(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+
+	for _, b := range data {
+		out = append(out, b)
+		if b == iac {
+			out = append(out, iac)
+		}
+	}
+
+	return out
+}
+
+func command(command, option byte) []byte {
+	return []byte{iac, command, option}
+}
+
+func subnegotiation(data ...byte) []byte {
+	out := []byte{iac, sb}
+	out = append(out, escape(data)...)
+
+	return append(out, iac, se)
+}
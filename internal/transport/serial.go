@@ -0,0 +1,36 @@
+package transport
+
+import (
+	"go.bug.st/serial"
+)
+
+// Serial is a local serial port which can switch the baud rate on the fly.
+type Serial struct {
+	serial.Port
+
+	mode serial.Mode
+}
+
+func NewSerial(port serial.Port, mode serial.Mode) *Serial {
+	return &Serial{
+		Port: port,
+		mode: mode,
+	}
+}
+
+// SetBaudRate waits until the written data is transmitted and changes the baud rate.
+func (s *Serial) SetBaudRate(baudRate int) error {
+	err := s.Port.Drain()
+	if err != nil {
+		return err
+	}
+
+	mode := s.mode
+	mode.BaudRate = baudRate
+
+	return s.Port.SetMode(&mode)
+}
+
+func (s *Serial) ResetBaudRate() error {
+	return s.SetBaudRate(s.mode.BaudRate)
+}
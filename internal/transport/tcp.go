@@ -0,0 +1,169 @@
+package transport
+
+import (
+	"github.com/lan143/metrology-master/internal/bus"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	TCPScheme = "tcp://"
+
+	dialTimeout    = 5 * time.Second
+	reconnectDelay = 1 * time.Second
+)
+
+// TCP is a serial port behind an Ethernet to RS-485 converter in TCP server mode.
+// The connection is established lazily and re-established after it drops, so
+// Read blocks until data arrives or the port is closed.
+type TCP struct {
+	address string
+	// baudRate is the configured baud rate of ports which support switching it.
+	baudRate int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+	done   chan struct{}
+
+	dial func(address string) (net.Conn, error)
+
+	log *zap.Logger
+}
+
+func NewTCP(address string, log *zap.Logger) *TCP {
+	return newTCP(address, func(address string) (net.Conn, error) {
+		return net.DialTimeout("tcp", address, dialTimeout)
+	}, log)
+}
+
+func newTCP(address string, dial func(address string) (net.Conn, error), log *zap.Logger) *TCP {
+	return &TCP{
+		address: address,
+		done:    make(chan struct{}),
+		dial:    dial,
+		log:     log.With(zap.String("address", address)),
+	}
+}
+
+func (t *TCP) Read(p []byte) (int, error) {
+	for {
+		conn, err := t.connect()
+		if err == io.EOF {
+			return 0, err
+		}
+
+		if err == nil {
+			var n int
+			n, err = conn.Read(p)
+			if n > 0 {
+				return n, nil
+			}
+
+			t.drop(conn, err)
+		}
+
+		select {
+		case <-t.done:
+			return 0, io.EOF
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (t *TCP) Write(p []byte) (int, error) {
+	conn, err := t.connect()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := conn.Write(p)
+	if err != nil {
+		t.drop(conn, err)
+	}
+
+	return n, err
+}
+
+// SetBaudRate changes the baud rate of the remote port if the connection supports it, e.g. RFC 2217.
+func (t *TCP) SetBaudRate(baudRate int) error {
+	conn, err := t.connect()
+	if err != nil {
+		return err
+	}
+
+	switcher, ok := conn.(interface{ SetBaudRate(baudRate int) error })
+	if !ok {
+		return bus.ErrBaudRateNotSupported
+	}
+
+	return switcher.SetBaudRate(baudRate)
+}
+
+func (t *TCP) ResetBaudRate() error {
+	return t.SetBaudRate(t.baudRate)
+}
+
+func (t *TCP) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+
+	t.closed = true
+	close(t.done)
+
+	if t.conn != nil {
+		err := t.conn.Close()
+		t.conn = nil
+
+		return err
+	}
+
+	return nil
+}
+
+func (t *TCP) connect() (net.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, io.EOF
+	}
+
+	if t.conn != nil {
+		return t.conn, nil
+	}
+
+	conn, err := t.dial(t.address)
+	if err != nil {
+		t.log.Error("dial", zap.Error(err))
+		return nil, err
+	}
+
+	t.log.Info("connected")
+	t.conn = conn
+
+	return conn, nil
+}
+
+func (t *TCP) drop(conn net.Conn, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn != conn {
+		return
+	}
+
+	if !t.closed {
+		t.log.Warn("connection lost", zap.Error(err))
+	}
+
+	_ = conn.Close()
+	t.conn = nil
+}
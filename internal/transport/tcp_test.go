@@ -0,0 +1,221 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func newListener(t *testing.T) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	return l
+}
+
+// read reads the next chunk of the port, failing the test after the timeout.
+func read(t *testing.T, port io.Reader, timeout time.Duration) ([]byte, error) {
+	t.Helper()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		buf := make([]byte, 16)
+		n, err := port.Read(buf)
+		done <- result{buf[:n], err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-time.After(timeout):
+		t.Fatalf("read did not return in %s", timeout)
+		return nil, nil
+	}
+}
+
+func TestTCPReconnectsAfterServerClosed(t *testing.T) {
+	l := newListener(t)
+
+	go func() {
+		for _, data := range [][]byte{{0x01}, {0x02}} {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			_, _ = conn.Write(data)
+			_ = conn.Close()
+		}
+	}()
+
+	port := NewTCP(l.Addr().String(), zap.NewNop())
+	defer port.Close()
+
+	for _, want := range [][]byte{{0x01}, {0x02}} {
+		data, err := read(t, port, reconnectDelay+time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, want) {
+			t.Fatalf("got % X, want % X", data, want)
+		}
+	}
+}
+
+func TestTCPWritesToNewConnection(t *testing.T) {
+	l := newListener(t)
+	received := make(chan []byte)
+
+	go func() {
+		// the first connection is closed before the port writes
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+
+		conn, err = l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buf := make([]byte, 16)
+		n, _ := conn.Read(buf)
+		received <- buf[:n]
+	}()
+
+	port := NewTCP(l.Addr().String(), zap.NewNop())
+	defer port.Close()
+
+	// the read notices the closed connection and reconnects
+	go func() {
+		_, _ = port.Read(make([]byte, 16))
+	}()
+
+	deadline := time.Now().Add(reconnectDelay + 2*time.Second)
+	for {
+		_, err := port.Write([]byte{0x03})
+		if err == nil {
+			select {
+			case data := <-received:
+				if !bytes.Equal(data, []byte{0x03}) {
+					t.Fatalf("got % X, want 03", data)
+				}
+
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the write did not reach a new connection")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestTCPCloseWhileReconnecting(t *testing.T) {
+	dials := make(chan struct{}, 16)
+	dial := func(string) (net.Conn, error) {
+		dials <- struct{}{}
+
+		return nil, errors.New("connection refused")
+	}
+
+	port := newTCP("127.0.0.1:1", dial, zap.NewNop())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := port.Read(make([]byte, 16))
+		done <- err
+	}()
+
+	// the read keeps redialing until the port is closed
+	<-dials
+
+	err := port.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Fatalf("read: got %v, want %v", err, io.EOF)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read did not return after close")
+	}
+
+	_, err = port.Write([]byte{0x01})
+	if err != io.EOF {
+		t.Fatalf("write: got %v, want %v", err, io.EOF)
+	}
+}
+
+func TestTCPCloseDropsConnection(t *testing.T) {
+	l := newListener(t)
+	closed := make(chan struct{})
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// the read returns once the port closed its end
+		_, _ = conn.Read(make([]byte, 16))
+		close(closed)
+	}()
+
+	port := NewTCP(l.Addr().String(), zap.NewNop())
+
+	_, err := port.Write([]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := port.Read(make([]byte, 16))
+		done <- err
+	}()
+
+	err = port.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Fatalf("read: got %v, want %v", err, io.EOF)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read did not return after close")
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the connection is still open")
+	}
+}
@@ -14,6 +14,8 @@ import (
 type command struct {
 	Args   []string
 	Lookup flagutil.LookupFunc
+	// LogOutput overrides the log output of the config when set.
+	LogOutput string
 
 	Context context.Context
 	Signals []os.Signal
@@ -45,6 +47,10 @@ func (c *command) init() (*zap.Logger, error) {
 		return nil, err
 	}
 
+	if c.LogOutput != "" {
+		c.config.Log.Output = c.LogOutput
+	}
+
 	return log2.New(*c.config.Log)
 }
 
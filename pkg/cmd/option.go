@@ -19,3 +19,17 @@ var (
 type (
 	Option func(*command)
 )
+
+func WithArgs(args []string) Option {
+	return func(c *command) {
+		c.Args = args
+	}
+}
+
+// WithLogOutput overrides the log output of the config, e.g. stderr for commands
+// printing their results to stdout.
+func WithLogOutput(output string) Option {
+	return func(c *command) {
+		c.LogOutput = output
+	}
+}
@@ -43,6 +43,10 @@ func (e *exporter) Set(value string) error {
 	for name := range defined {
 		f := e.flag.Lookup(name)
 
+		if t, ok := f.Value.(Tracer); ok {
+			t.Trace(e.origin, e.prefix)
+		}
+
 		e.origin.Var(
 			f.Value,
 			e.prefix+name,
@@ -7,28 +7,36 @@ import (
 
 type LookupFunc func(string) (string, bool)
 
+// ParseEnv sets the unspecified flags from the environment, the flags defined by the
+// values set, e.g. the ones of an included subset, are looked up in the next pass.
 func (fs *FlagSet) ParseEnv(lookup LookupFunc) (err error) {
 	replacer := strings.NewReplacer(".", "_", "-", "_")
 
-	fs.VisitUnspecified(func(f *flag.Flag) {
+	for {
+		defined := fs.count()
+
+		fs.VisitUnspecified(func(f *flag.Flag) {
+			if err != nil {
+				return
+			}
+
+			key := strings.ToUpper(f.Name)
+			key = replacer.Replace(key)
+
+			v, found := lookup(key)
+			if found {
+				err = fs.Set(f.Name, v)
+			}
+		})
+
 		if err != nil {
-			return
+			return fs.fail(err)
 		}
 
-		key := strings.ToUpper(f.Name)
-		key = replacer.Replace(key)
+		fs.update()
 
-		v, found := lookup(key)
-		if found {
-			err = fs.Set(f.Name, v)
+		if fs.count() == defined {
+			return nil
 		}
-	})
-
-	if err != nil {
-		return fs.fail(err)
 	}
-
-	fs.update()
-
-	return nil
 }
@@ -27,6 +27,16 @@ func (fs *FlagSet) update() {
 	})
 }
 
+// count returns the number of defined flags.
+func (fs *FlagSet) count() int {
+	var n int
+	fs.origin.VisitAll(func(*flag.Flag) {
+		n++
+	})
+
+	return n
+}
+
 func (fs *FlagSet) VisitUnspecified(fn func(*flag.Flag)) {
 	fs.origin.VisitAll(func(f *flag.Flag) {
 		if fs.specified[f.Name] {
@@ -45,7 +55,13 @@ func (fs *FlagSet) SetUnspecified(name, value string) error {
 	return fs.Set(name, value)
 }
 
+// Set sets the flag, the flags not defined yet are not set on the origin, which
+// would not let them be defined later.
 func (fs *FlagSet) Set(name, value string) error {
+	if fs.origin.Lookup(name) == nil {
+		return fmt.Errorf("flag provided but not defined: %s", name)
+	}
+
 	err := fs.origin.Set(name, value)
 	if err != nil {
 		return fmt.Errorf("invalid value %q for %s: %w", value, name, err)
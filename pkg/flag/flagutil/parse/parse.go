@@ -7,8 +7,45 @@ import (
 
 type SetFunc func(string, string) error
 
+// Setup sets the values of the map by their dotted keys. Setting a value may define
+// flags, e.g. the ones of an included subset, so the values failed to set are set
+// again as long as that makes progress, whatever the order of the keys in the map.
 func Setup(m map[string]any, set SetFunc) error {
-	return setup("", m, set)
+	var values [][2]string
+	err := setup("", m, func(key, value string) error {
+		values = append(values, [2]string{key, value})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for len(values) > 0 {
+		var (
+			failed   [][2]string
+			firstErr error
+		)
+
+		for _, v := range values {
+			err = set(v[0], v[1])
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				failed = append(failed, v)
+			}
+		}
+
+		if len(failed) == len(values) {
+			return firstErr
+		}
+
+		values = failed
+	}
+
+	return nil
 }
 
 func setup(key string, value any, set SetFunc) error {
@@ -0,0 +1,232 @@
+package parse
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// include defines the include flag of the prefix, which defines the port and the include
+// flags of every included name, the way the subsets of the config are included.
+func include(flags *flag.FlagSet, prefix string) {
+	flags.Func(prefix+"include", "", func(name string) error {
+		flags.String(prefix+name+".port", "", "")
+		include(flags, prefix+name+".")
+
+		return nil
+	})
+}
+
+func newTestFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	include(flags, "")
+
+	return flags
+}
+
+// checkValues checks the values of the flags, every flag of want must be defined.
+func checkValues(t *testing.T, flags *flag.FlagSet, want map[string]string) {
+	t.Helper()
+
+	for name, value := range want {
+		f := flags.Lookup(name)
+		if f == nil {
+			t.Errorf("%s is not defined", name)
+			continue
+		}
+
+		if got := f.Value.String(); got != value {
+			t.Errorf("%s: got %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]any
+		want map[string]string
+		err  string
+	}{
+		{
+			"dotted keys",
+			map[string]any{"a.port": "ttyUSB0", "include": "a"},
+			map[string]string{"a.port": "ttyUSB0"},
+			"",
+		},
+		{
+			"list",
+			map[string]any{
+				"a":       map[string]any{"port": "ttyUSB0"},
+				"b":       map[string]any{"port": "ttyUSB1"},
+				"include": []any{"a", "b"},
+			},
+			map[string]string{"a.port": "ttyUSB0", "b.port": "ttyUSB1"},
+			"",
+		},
+		{
+			"nested include",
+			map[string]any{
+				"a": map[string]any{
+					"b":       map[string]any{"port": "ttyUSB2"},
+					"include": "b",
+					"port":    "ttyUSB0",
+				},
+				"include": "a",
+			},
+			map[string]string{"a.port": "ttyUSB0", "a.b.port": "ttyUSB2"},
+			"",
+		},
+		{
+			"not defined",
+			map[string]any{"a.port": "ttyUSB0", "b.port": "ttyUSB1", "include": "a"},
+			map[string]string{"a.port": "ttyUSB0"},
+			"flag provided but not defined: b.port",
+		},
+		{
+			"invalid value",
+			map[string]any{"include": map[any]any{}},
+			nil,
+			"invalid value for include",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := newTestFlags()
+
+			err := Setup(tt.m, NewFlagSet(flags).SetUnspecified)
+			if tt.err == "" && err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+
+			checkValues(t, flags, tt.want)
+		})
+	}
+}
+
+func TestSetupFormatsValues(t *testing.T) {
+	var got []string
+	err := Setup(
+		map[string]any{"value": []any{"text", true, 5, int64(-6), uint64(7), 1.5}},
+		func(_, value string) error {
+			got = append(got, value)
+
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"text", "true", "5", "-6", "7", "1.5"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSetDoesNotDefineFlags(t *testing.T) {
+	flags := newTestFlags()
+	fs := NewFlagSet(flags)
+
+	err := fs.Set("a.port", "ttyUSB0")
+	if err == nil || err.Error() != "flag provided but not defined: a.port" {
+		t.Fatalf("got %v, want the flag not defined", err)
+	}
+
+	// the flag is defined by the include later
+	err = fs.Set("include", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = fs.Set("a.port", "ttyUSB0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkValues(t, flags, map[string]string{"a.port": "ttyUSB0"})
+}
+
+func TestParseEnv(t *testing.T) {
+	env := map[string]string{
+		"INCLUDE":    "a",
+		"A_PORT":     "ttyUSB1",
+		"A_INCLUDE":  "b",
+		"A_B_PORT":   "ttyUSB2",
+		"UNREAD_KEY": "value",
+	}
+
+	flags := newTestFlags()
+
+	// the flags of the command line are not overridden by the environment
+	err := flags.Parse([]string{"-include=a", "-a.port=ttyUSB0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewFlagSet(flags).ParseEnv(func(key string) (string, bool) {
+		value, ok := env[key]
+
+		return value, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkValues(t, flags, map[string]string{"a.port": "ttyUSB0", "a.b.port": "ttyUSB2"})
+}
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]string
+		err  string
+	}{
+		{
+			"values before include",
+			"a:\n  port: ttyUSB0\n  b:\n    port: ttyUSB2\n  include: b\ninclude:\n  - a\n",
+			map[string]string{"a.port": "ttyUSB0", "a.b.port": "ttyUSB2"},
+			"",
+		},
+		{
+			"not defined",
+			"b:\n  port: ttyUSB1\ninclude: a\n",
+			nil,
+			"flag provided but not defined: b.port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "config.yaml")
+
+			err := os.WriteFile(name, []byte(tt.data), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			flags := newTestFlags()
+
+			err = NewFlagSet(flags).ParseFile(name)
+			if tt.err == "" && err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+
+			checkValues(t, flags, tt.want)
+		})
+	}
+}
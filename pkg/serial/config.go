@@ -2,17 +2,33 @@ package serial
 
 import (
 	"flag"
+	"time"
 )
 
+const (
+	DefaultFrameGap = 20 * time.Millisecond
+)
+
+// Defaults are the request timeout, retries and backoff of ports which do not set them.
+type Defaults struct {
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+}
+
 type Config struct {
 	Port     string
 	BaudRate int
 	DataBits int
 	StopBits int
 	Parity   int
+	FrameGap time.Duration
+	Timeout  time.Duration
+	Retries  int
+	Backoff  time.Duration
 }
 
-func Export(flag *flag.FlagSet) *Config {
+func Export(flag *flag.FlagSet, defaults Defaults) *Config {
 	c := &Config{}
 
 	flag.StringVar(
@@ -45,6 +61,30 @@ func Export(flag *flag.FlagSet) *Config {
 		0,
 		"",
 	)
+	flag.DurationVar(
+		&c.FrameGap,
+		"frame-gap",
+		DefaultFrameGap,
+		"",
+	)
+	flag.DurationVar(
+		&c.Timeout,
+		"timeout",
+		defaults.Timeout,
+		"",
+	)
+	flag.IntVar(
+		&c.Retries,
+		"retries",
+		defaults.Retries,
+		"",
+	)
+	flag.DurationVar(
+		&c.Backoff,
+		"backoff",
+		defaults.Backoff,
+		"",
+	)
 
 	return c
 }